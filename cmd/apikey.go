@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+)
+
+const apiKeyUsage = `Usage:
+  main apikey create -name <name> -scopes read:prices,admin:users
+  main apikey revoke -id <id>
+  main apikey list`
+
+// runAPIKeyCommand manages API keys and returns the process exit code.
+func runAPIKeyCommand(env *cliEnv, args []string) int {
+	if len(args) == 0 {
+		return usageError(apiKeyUsage)
+	}
+
+	repos, err := env.repositories()
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	keys := service.NewAPIKeyService(repos.apiKeys, env.log)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+		name := fs.String("name", "", "Human-readable key name")
+		scopes := fs.String("scopes", "", "Comma-separated scopes")
+		if err := fs.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+
+		plain, key, err := keys.Create(ctx, *name, service.ParseScopes(*scopes))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error creating API key:", err)
+			return exitError
+		}
+
+		fmt.Printf("Created API key %d (%s) with scopes %v\n", key.ID, key.Name, key.Scopes)
+		fmt.Println("Store it now, it will not be shown again:")
+		fmt.Println(plain)
+		return exitOK
+
+	case "revoke":
+		fs := flag.NewFlagSet("apikey revoke", flag.ContinueOnError)
+		id := fs.Int64("id", 0, "Key ID to revoke")
+		if err := fs.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+
+		err := keys.Revoke(ctx, *id)
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			fmt.Fprintf(os.Stderr, "API key %d not found or already revoked\n", *id)
+			return exitError
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error revoking API key:", err)
+			return exitError
+		}
+
+		fmt.Printf("Revoked API key %d\n", *id)
+		return exitOK
+
+	case "list":
+		list, err := keys.List(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error listing API keys:", err)
+			return exitError
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tCREATED\tSTATUS")
+		for _, key := range list {
+			status := "active"
+			if key.Revoked() {
+				status = "revoked"
+			}
+
+			scopes := make([]string, 0, len(key.Scopes))
+			for _, scope := range key.Scopes {
+				scopes = append(scopes, string(scope))
+			}
+
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Prefix,
+				strings.Join(scopes, ","), key.CreatedAt.Format(time.DateTime), status)
+		}
+		w.Flush()
+		return exitOK
+
+	default:
+		return usageError(apiKeyUsage)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/infrastructure/notification/telegram"
+)
+
+const broadcastUsage = `Usage:
+  main broadcast [-yes] TEXT
+
+Without -yes only the number of recipients is printed.`
+
+// runBroadcast sends a message to every active subscriber and returns the
+// process exit code. Interrupting the command cancels the broadcast.
+func runBroadcast(env *cliEnv, args []string) int {
+	fs := flag.NewFlagSet("broadcast", flag.ContinueOnError)
+	confirm := fs.Bool("yes", false, "Send the broadcast instead of a dry run")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	text := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if text == "" {
+		return usageError(broadcastUsage)
+	}
+
+	repos, err := env.repositories()
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	tgNotifier, err := telegram.NewNotificationTelegram(env.log, env.cfg.TgToken, env.cfg.TgAPIURL, clock.Real())
+	if err != nil {
+		return fail("Error initializing Telegram: %v", err)
+	}
+
+	broadcaster := service.NewBroadcastService(repos.users, tgNotifier, env.log)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	draft, err := broadcaster.Prepare(ctx, text, 0)
+	if err != nil {
+		return fail("Error preparing broadcast: %v", err)
+	}
+
+	if !*confirm {
+		fmt.Printf("Dry run: would send to %d recipients. Re-run with -yes to send.\n", draft.Recipients)
+		return exitOK
+	}
+
+	finished := make(chan entity.Broadcast, 1)
+	report := func(b entity.Broadcast) {
+		fmt.Fprintf(os.Stderr, "%s: %d/%d sent, %d failed, %d blocked\n",
+			b.Status, b.Sent, b.Recipients, b.Failed, b.Blocked)
+		if b.Status != entity.BroadcastRunning {
+			select {
+			case finished <- b:
+			default:
+			}
+		}
+	}
+
+	if _, err := broadcaster.Confirm(ctx, draft.ID, report); err != nil {
+		return fail("Error starting broadcast: %v", err)
+	}
+
+	var final entity.Broadcast
+	select {
+	case final = <-finished:
+	case <-ctx.Done():
+		fmt.Fprintln(os.Stderr, "Interrupted, cancelling broadcast...")
+		cancelled, err := broadcaster.Cancel(draft.ID)
+		switch {
+		case errors.Is(err, service.ErrBroadcastState):
+			// It finished while we were being interrupted.
+			final = <-finished
+		case err != nil:
+			return fail("Error cancelling broadcast: %v", err)
+		default:
+			final = *cancelled
+		}
+	}
+
+	fmt.Printf("Broadcast %s %s: %d sent, %d failed, %d blocked of %d\n",
+		final.ID, final.Status, final.Sent, final.Failed, final.Blocked, final.Recipients)
+
+	if final.Status != entity.BroadcastDone {
+		return exitError
+	}
+	return exitOK
+}
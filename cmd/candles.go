@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/infrastructure/cryptoClient/bybit"
+)
+
+const candlesUsage = `Usage:
+  main candles backfill [-symbol SYMBOL] [-interval 1m|5m|15m|30m|1h|4h|1d|1w] [-since DURATION]`
+
+// runCandles manages the stored exchange candles and returns the process
+// exit code.
+func runCandles(env *cliEnv, args []string) int {
+	if len(args) == 0 || args[0] != "backfill" {
+		return usageError(candlesUsage)
+	}
+
+	return backfillCandles(env, args[1:])
+}
+
+// backfillCandles fetches the candles missing from storage over the given
+// period, such as those of a downtime, from the exchange.
+func backfillCandles(env *cliEnv, args []string) int {
+	fs := flag.NewFlagSet("candles backfill", flag.ContinueOnError)
+	symbolFlag := fs.String("symbol", "", "Only this symbol instead of every tracked one")
+	intervalFlag := fs.String("interval", string(entity.Interval1h), "Candle interval")
+	since := fs.Duration("since", 30*24*time.Hour, "How far back to fill gaps")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 || *since <= 0 {
+		return usageError(candlesUsage)
+	}
+
+	interval, ok := entity.ParseCandleInterval(*intervalFlag)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unsupported interval %q\n", *intervalFlag)
+		return usageError(candlesUsage)
+	}
+
+	symbols := supportedSymbols()
+	if *symbolFlag != "" {
+		symbol := entity.CurrencyName(strings.ToUpper(*symbolFlag))
+		if !isSupportedSymbol(symbol) {
+			fmt.Fprintf(os.Stderr, "unsupported symbol %q\n", *symbolFlag)
+			return usageError(candlesUsage)
+		}
+		symbols = []entity.CurrencyName{symbol}
+	}
+
+	repos, err := env.repositories()
+	if err != nil {
+		return fail("%v", err)
+	}
+	candles := service.NewCandleService(bybit.NewKlineClient(env.log, env.cfg.KlineURL), repos.candles, env.log)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	now := time.Now()
+	code := exitOK
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tINTERVAL\tSTORED")
+	for _, symbol := range symbols {
+		stored, err := candles.Backfill(ctx, symbol, interval, now.Add(-*since), now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error backfilling %s: %v\n", symbol, err)
+			code = exitError
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\n", symbol, interval, stored)
+	}
+	w.Flush()
+
+	return code
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"tgBotFinal/internal/config"
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/infrastructure/database"
+	"tgBotFinal/internal/logger"
+	"tgBotFinal/internal/repository/memory"
+	"tgBotFinal/internal/repository/postgres"
+	"tgBotFinal/internal/repository/sqlite"
+
+	"github.com/XSAM/otelsql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Exit codes shared by all subcommands.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const migrationsDir = "migrations"
+
+// cliEnv holds what subcommands share. The configuration, logger and
+// database are set up on first use so commands that need none of them,
+// such as "migrate create", work without a complete configuration.
+type cliEnv struct {
+	configPath string
+	// logOutput receives log records; commands other than serve log to
+	// stderr so their stdout stays machine-readable.
+	logOutput io.Writer
+
+	cfg *config.Config
+	log *slog.Logger
+	db  *sql.DB
+	// backend is database.Postgres or database.SQLite, picked from the
+	// scheme of DATABASE_URL when the database is opened.
+	backend string
+	repos   *repositories
+}
+
+func (e *cliEnv) load() error {
+	if e.cfg != nil {
+		return nil
+	}
+
+	cfg, err := config.Load(e.configPath)
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	out := e.logOutput
+	if out == nil {
+		out = os.Stderr
+	}
+
+	e.cfg = cfg
+	e.log = logger.New(out, cfg.LogLevel, cfg.LogFormat)
+	return nil
+}
+
+func (e *cliEnv) database() (*sql.DB, error) {
+	if err := e.load(); err != nil {
+		return nil, err
+	}
+
+	if e.db == nil {
+		backend, dsn := database.ParseURL(e.cfg.DatabaseURL)
+		db, err := connectDB(e.cfg, backend, dsn)
+		if err != nil {
+			return nil, fmt.Errorf("connect to %s database: %w", backend, err)
+		}
+		e.db, e.backend = db, backend
+	}
+
+	return e.db, nil
+}
+
+// repositories holds the storage of the configured backend.
+type repositories struct {
+	users        service.UserRepository
+	currencies   service.CurrencyRepository
+	priceHistory service.PriceHistoryRepository
+	apiKeys      service.APIKeyRepository
+	candles      service.CandleRepository
+	unitOfWork   service.UnitOfWork
+}
+
+// repositories returns the storage of the configured backend, or an
+// in-memory one in demo mode.
+func (e *cliEnv) repositories() (*repositories, error) {
+	if e.repos != nil {
+		return e.repos, nil
+	}
+	if err := e.load(); err != nil {
+		return nil, err
+	}
+
+	if e.cfg.Demo {
+		store := memory.NewStore()
+		e.repos = &repositories{
+			users:        memory.NewUserRepo(store),
+			currencies:   memory.NewCurrencyRepo(store),
+			priceHistory: memory.NewPriceHistoryRepo(store),
+			apiKeys:      memory.NewAPIKeyRepo(store),
+			candles:      memory.NewCandleRepo(store),
+			unitOfWork:   memory.NewUnitOfWork(store),
+		}
+		return e.repos, nil
+	}
+
+	db, err := e.database()
+	if err != nil {
+		return nil, err
+	}
+
+	if e.backend == database.SQLite {
+		e.repos = &repositories{
+			users:        sqlite.NewUserRepo(db, e.log),
+			currencies:   sqlite.NewCurrencyRepo(db, e.log),
+			priceHistory: sqlite.NewPriceHistoryRepo(db, e.log),
+			apiKeys:      sqlite.NewAPIKeyRepo(db, e.log),
+			candles:      sqlite.NewCandleRepo(db, e.log),
+			unitOfWork:   sqlite.NewUnitOfWork(db, e.log),
+		}
+		return e.repos, nil
+	}
+
+	e.repos = &repositories{
+		users:        postgres.NewUserRepo(db, e.log),
+		currencies:   postgres.NewCurrencyRepo(db, e.log),
+		priceHistory: postgres.NewPriceHistoryRepo(db, e.log),
+		apiKeys:      postgres.NewAPIKeyRepo(db, e.log),
+		candles:      postgres.NewCandleRepo(db, e.log),
+		unitOfWork:   postgres.NewUnitOfWork(db, e.log),
+	}
+	return e.repos, nil
+}
+
+func (e *cliEnv) close() error {
+	if e.db == nil {
+		return nil
+	}
+	db := e.db
+	e.db = nil
+	return db.Close()
+}
+
+// connectDB opens the pool for backend and checks that it is reachable.
+func connectDB(cfg *config.Config, backend, dsn string) (*sql.DB, error) {
+	var (
+		db  *sql.DB
+		err error
+	)
+	if backend == database.SQLite {
+		db, err = otelsql.Open("sqlite", sqlite.DSN(dsn),
+			otelsql.WithAttributes(semconv.DBSystemSqlite))
+	} else {
+		db, err = otelsql.Open("postgres", dsn,
+			otelsql.WithAttributes(semconv.DBSystemPostgreSQL))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if backend == database.SQLite {
+		// SQLite has a single writer; one connection serialises writes
+		// instead of failing them, and keeps :memory: databases alive.
+		db.SetMaxOpenConns(1)
+		db.SetConnMaxLifetime(0)
+	} else {
+		db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+		db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+		db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ping database: %w", err)
+	}
+
+	return db, nil
+}
+
+// migrateDB applies pending migrations.
+func migrateDB(db *sql.DB, backend string, appLog *slog.Logger) error {
+	migrator := database.NewMigrator(db, backend, appLog)
+	if err := migrator.CheckAndMigrate(migrationsDir); err != nil {
+		return fmt.Errorf("database migration failed: %w", err)
+	}
+	return nil
+}
+
+func fail(format string, args ...any) int {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	return exitError
+}
+
+func usageError(usage string) int {
+	fmt.Fprintln(os.Stderr, usage)
+	return exitUsage
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/infrastructure/notification/telegram/telegramtest"
+	"tgBotFinal/internal/repository/sqlite"
+)
+
+// TestServe_EndToEnd runs the bot with SQLite storage against a fake
+// Telegram Bot API and a fake exchange, and drives it through the webhook
+// the way Telegram does.
+func TestServe_EndToEnd(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts the whole bot")
+	}
+
+	tg := telegramtest.NewServer("e2e-token")
+	defer tg.Close()
+
+	exchange := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prices := map[string]string{"BTCUSDT": "60000.00", "ETHUSDT": "3000.00"}
+		symbols := []string{"BTCUSDT", "ETHUSDT"}
+		if symbol := r.URL.Query().Get("symbol"); symbol != "" {
+			symbols = []string{symbol}
+		}
+
+		var list []string
+		for _, symbol := range symbols {
+			list = append(list, fmt.Sprintf(`{"symbol": %q, "lastPrice": %q, `+
+				`"price24hPcnt": "0.015", "bid1Price": "1.00", "ask1Price": "1.05"}`, symbol, prices[symbol]))
+		}
+		fmt.Fprintf(w, `{"retCode": 0, "retMsg": "OK", "result": {"list": [%s]}}`, strings.Join(list, ", "))
+	}))
+	defer exchange.Close()
+
+	port := freePort(t)
+	dbPath := filepath.Join(t.TempDir(), "bot.db")
+
+	// Migrations are looked up relative to the working directory.
+	t.Chdir("..")
+	t.Setenv("DATABASE_URL", "sqlite://"+dbPath)
+	t.Setenv("TG_TOKEN", "e2e-token")
+	t.Setenv("TG_API_URL", tg.URL)
+	t.Setenv("API_URL", exchange.URL)
+	t.Setenv("HTTP_PORT", port)
+	t.Setenv("WEBHOOK_URL", "")
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("NOTIFY_INTERVAL", "1h")
+
+	exit := make(chan int, 1)
+	go func() { exit <- run([]string{"serve"}) }()
+
+	base := "http://127.0.0.1:" + port
+	waitHealthy(t, base, exit)
+	// WEBHOOK_URL must be https, so the fake is told where the bot listens.
+	tg.SetWebhook(base + webhookPath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	const chatID = 4242
+
+	t.Run("start", func(t *testing.T) {
+		if err := tg.SendUpdate(ctx, chatID, "alice", "/start"); err != nil {
+			t.Fatal(err)
+		}
+		got := waitMessages(t, ctx, tg, chatID, 2)
+		if !strings.Contains(got[0].Text, "Bot Activated") {
+			t.Errorf("First reply to /start = %q, want the activation message", got[0].Text)
+		}
+		assertPrices(t, got[1].Text)
+	})
+
+	t.Run("price", func(t *testing.T) {
+		if err := tg.SendUpdate(ctx, chatID, "alice", "/price"); err != nil {
+			t.Fatal(err)
+		}
+		got := waitMessages(t, ctx, tg, chatID, 3)
+		assertPrices(t, got[2].Text)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		if err := tg.SendUpdate(ctx, chatID, "alice", "/stop"); err != nil {
+			t.Fatal(err)
+		}
+		got := waitMessages(t, ctx, tg, chatID, 4)
+		if got[3].Text != "You have been successfully deactivated" {
+			t.Errorf("Reply to /stop = %q", got[3].Text)
+		}
+	})
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case code := <-exit:
+		if code != exitOK {
+			t.Fatalf("serve exit code = %d, want %d", code, exitOK)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("serve did not shut down")
+	}
+
+	db, err := sql.Open("sqlite", sqlite.DSN(dbPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	user, err := sqlite.NewUserRepo(db, slog.Default()).GetByChatID(context.Background(), chatID)
+	if err != nil {
+		t.Fatalf("User was not stored: %v", err)
+	}
+	if user.Username != "alice" || user.Active {
+		t.Errorf("Stored user = %+v, want alice unsubscribed", user)
+	}
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	return fmt.Sprint(l.Addr().(*net.TCPAddr).Port)
+}
+
+// waitHealthy waits until the bot answers its health check.
+func waitHealthy(t *testing.T, base string, exit <-chan int) {
+	t.Helper()
+
+	deadline := time.After(10 * time.Second)
+	for {
+		resp, err := http.Get(base + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+		}
+
+		select {
+		case code := <-exit:
+			t.Fatalf("serve exited with code %d before it was healthy", code)
+		case <-deadline:
+			t.Fatalf("bot not healthy: %v", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func waitMessages(t *testing.T, ctx context.Context, tg *telegramtest.Server, chatID int64, n int) []telegramtest.Message {
+	t.Helper()
+
+	got, err := tg.WaitMessages(ctx, chatID, n)
+	if err != nil {
+		t.Fatalf("Got %d messages, want %d: %+v", len(got), n, got)
+	}
+	return got
+}
+
+func assertPrices(t *testing.T, text string) {
+	t.Helper()
+
+	if !strings.Contains(text, "BTC: 60000.00 ▲ +1.50% (spread 0.05)") || !strings.Contains(text, "ETH: 3000.00") {
+		t.Errorf("Prices message = %q, want BTC and ETH prices with their change and spread", text)
+	}
+}
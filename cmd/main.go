@@ -1,139 +1,95 @@
 package main
 
 import (
-	"context"
-	"database/sql"
+	"errors"
 	"flag"
-	"net/http"
+	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
-	"tgBotFinal/internal/domain/service"
-	"tgBotFinal/internal/infrastructure/cryptoClient"
-	"tgBotFinal/internal/infrastructure/database"
-	"time"
-
-	"tgBotFinal/internal/config"
-	"tgBotFinal/internal/infrastructure/cryptoClient/bybit"
-	"tgBotFinal/internal/infrastructure/notification/telegram"
-	"tgBotFinal/internal/infrastructure/router/chi"
-	"tgBotFinal/internal/logger"
-	"tgBotFinal/internal/repository/postgres"
 
 	_ "github.com/lib/pq"
 )
 
-func main() {
-	migrateOnly := flag.Bool("migrate", false, "Run database migrations and exit")
-	flag.Parse()
-
-	// Load config
-	cfg := config.MustLoadConfig()
-
-	//Init logger
-	appLog := logger.NewLogger(cfg.LogLevel)
-	appLog.Info("Starting App")
-	appLog.Info("Loading config")
-
-	//Init DB
-	db, err := sql.Open("postgres", cfg.DatabaseURL)
-	if err != nil {
-		appLog.Error("Error connecting to database", "error", err)
-		os.Exit(1)
-	}
-	defer db.Close()
-
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(time.Minute * 5)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := db.PingContext(ctx); err != nil {
-		appLog.Error("Error pinging database", "error", err)
-		os.Exit(1)
-	}
-	appLog.Debug("Connected to database")
-
-	//Auto-migrate database
-	migrator := database.NewMigrator(db, appLog)
-	if err := migrator.CheckAndMigrate("migrations"); err != nil {
-		appLog.Error("Database migration failed", "error", err)
-		os.Exit(1)
-	}
-
-	if *migrateOnly {
-		appLog.Info("Migrations completed successfully, exiting...")
-		return
-	}
+const usage = `Usage:
+  main [-config FILE] [COMMAND] [ARGS]
+  main -demo
+
+Commands:
+  serve                                  Run the bot (default)
+  migrate up|down|status|create NAME     Manage database migrations
+  users list|activate|deactivate         Manage subscribers
+  prices fetch [SYMBOL]                  Fetch prices from the exchange once
+  webhook set [URL]|delete|info          Manage the Telegram webhook
+  broadcast [-yes] TEXT                  Send a message to every active subscriber
+  apikey create|revoke|list              Manage API keys
+  simulate [-fixture FILE] [-seed N]     Serve simulated exchange prices
+  candles backfill [-interval I]         Fill gaps in the stored exchange candles
+
+-demo runs the bot with in-memory storage, simulated prices and messages
+written to the log, so it needs neither a database nor Telegram; it is the
+same as DEMO=true. Send updates to POST /webhook/telegram to talk to it.
+
+The config file can also be set with CONFIG_FILE.
+Exit codes: 0 success, 1 failure, 2 usage error.`
+
+var commands = map[string]func(env *cliEnv, args []string) int{
+	"serve":     runServe,
+	"migrate":   runMigrate,
+	"users":     runUsers,
+	"prices":    runPrices,
+	"webhook":   runWebhook,
+	"broadcast": runBroadcast,
+	"apikey":    runAPIKeyCommand,
+	"simulate":  runSimulate,
+	"candles":   runCandles,
+}
 
-	//Init repositories
-	currencyRepo := postgres.NewCurrencyRepo(db, appLog)
-	userRepo := postgres.NewUserRepo(db, appLog)
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-	//init cryptoClient
-	byBitClient := bybit.NewClient(appLog, cfg.APIUrl)
+// run dispatches to a subcommand and returns the process exit code.
+func run(args []string) int {
+	fs := flag.NewFlagSet("main", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
 
-	// Wrap with cached client (TTL = 1 minute)
-	cachedClient := cryptoClient.NewCachedClient(byBitClient, time.Minute, appLog)
+	configPath := fs.String("config", os.Getenv("CONFIG_FILE"), "Path to a YAML or TOML config file")
+	migrateOnly := fs.Bool("migrate", false, "Run database migrations and exit (same as 'migrate up')")
+	demo := fs.Bool("demo", false, "Run the bot without external services (same as DEMO=true)")
 
-	//init notification
-	tgNotifier, err := telegram.NewNotificationTelegram(appLog, cfg.TgToken)
-	if err != nil {
-		appLog.Error("Error initializing Telegram", "error", err)
-		os.Exit(1)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
 	}
 
-	//init service
-	serv := service.NewCryptService(
-		currencyRepo,
-		userRepo,
-		cachedClient,
-		tgNotifier,
-		nil,
-		cfg.WebhookURL,
-		appLog,
-		"HTTP_PORT",
-	)
+	env := &cliEnv{configPath: *configPath}
+	defer env.close()
 
-	//init router
-	router := chi.NewChiRouter(appLog, userRepo, tgNotifier, cachedClient, serv)
-	serv.ChiRouter = router
-	// Graceful Shutdown
-	mainCtx, stop := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT,
-	)
-	defer stop()
-
-	serviceErr := make(chan error, 1)
-	go func() {
-		appLog.Info("Starting server")
-		if err := serv.Run(mainCtx); err != nil && err != http.ErrServerClosed {
-			appLog.Error("Error starting server", "error", err)
-			serviceErr <- err
-		}
-		close(serviceErr)
-	}()
-
-	select {
-	case <-mainCtx.Done():
-		appLog.Info("Received shutdown signal, initiating graceful shutdown...")
-	case err := <-serviceErr:
-		if err != nil {
-			appLog.Error("Service encountered error", "error", err)
+	args = fs.Args()
+	switch {
+	case *demo:
+		if len(args) > 0 {
+			return usageError(usage)
 		}
+		// Config files and the environment are still read, so the flag
+		// only has to switch DEMO on for config.Load to see it.
+		os.Setenv("DEMO", "true")
+		return runServe(env, nil)
+	case *migrateOnly:
+		return runMigrate(env, []string{"up"})
+	case len(args) == 0:
+		return runServe(env, nil)
+	case args[0] == "help" || args[0] == "-h" || args[0] == "--help":
+		fmt.Println(usage)
+		return exitOK
 	}
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
-
-	appLog.Info("Shutting down gracefully...")
-
-	if err := router.Shutdown(shutdownCtx); err != nil {
-		appLog.Error("Error shutting down router", "error", err)
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		return usageError(usage)
 	}
 
-	appLog.Info("Shutting down successfully")
-
+	return command(env, args[1:])
 }
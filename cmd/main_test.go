@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_ExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"help", []string{"help"}, exitOK},
+		{"unknown command", []string{"frobnicate"}, exitUsage},
+		{"unknown flag", []string{"-nope"}, exitUsage},
+		{"migrate without subcommand", []string{"migrate"}, exitUsage},
+		{"migrate unknown subcommand", []string{"migrate", "sideways"}, exitUsage},
+		{"migrate create without name", []string{"migrate", "create"}, exitUsage},
+		{"users without subcommand", []string{"users"}, exitUsage},
+		{"users activate bad id", []string{"users", "activate", "abc"}, exitUsage},
+		{"users unban without id", []string{"users", "unban"}, exitUsage},
+		{"users list conflicting filters", []string{"users", "list", "-active", "-inactive"}, exitUsage},
+		{"prices unknown symbol", []string{"prices", "fetch", "DOGE"}, exitUsage},
+		{"webhook without subcommand", []string{"webhook"}, exitUsage},
+		{"broadcast without text", []string{"broadcast", "-yes"}, exitUsage},
+		{"serve with arguments", []string{"serve", "now"}, exitUsage},
+		{"simulate with arguments", []string{"simulate", "now"}, exitUsage},
+		{"simulate missing fixture", []string{"simulate", "-fixture", "missing.csv"}, exitError},
+		{"candles without subcommand", []string{"candles"}, exitUsage},
+		{"candles unknown interval", []string{"candles", "backfill", "-interval", "2h"}, exitUsage},
+		{"candles unknown symbol", []string{"candles", "backfill", "-symbol", "DOGE"}, exitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := run(tt.args); got != tt.want {
+				t.Errorf("run(%q) = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_MigrateCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	if got := run([]string{"migrate", "-dir", dir, "create", "add_alerts"}); got != exitOK {
+		t.Fatalf("exit code = %d, want %d", got, exitOK)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*_add_alerts.sql"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("created files = %v (err %v), want one migration", files, err)
+	}
+
+	body, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "-- +goose Up") {
+		t.Errorf("migration body = %q, want a goose template", body)
+	}
+}
+
+func TestRun_SQLiteBackend(t *testing.T) {
+	t.Setenv("DATABASE_URL", "sqlite://"+filepath.Join(t.TempDir(), "bot.db"))
+	t.Setenv("TG_TOKEN", "test-token")
+
+	if got := run([]string{"migrate", "-dir", "../migrations", "up"}); got != exitOK {
+		t.Fatalf("migrate up exit code = %d, want %d", got, exitOK)
+	}
+	if got := run([]string{"users", "list"}); got != exitOK {
+		t.Errorf("users list exit code = %d, want %d", got, exitOK)
+	}
+	if got := run([]string{"users", "unban", "42"}); got != exitError {
+		t.Errorf("users unban of a missing user exit code = %d, want %d", got, exitError)
+	}
+}
+
+func TestRun_PricesFetchDemo(t *testing.T) {
+	t.Setenv("DEMO", "true")
+
+	// The simulated exchange answers without network access.
+	if got := run([]string{"prices", "fetch", "BTC"}); got != exitOK {
+		t.Errorf("prices fetch exit code = %d, want %d", got, exitOK)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"tgBotFinal/internal/infrastructure/database"
+)
+
+const migrateUsage = `Usage:
+  main migrate [-dir DIR] up|down|status
+  main migrate [-dir DIR] create NAME
+
+SQLite databases are migrated from DIR/sqlite; pass it as -dir to create
+a SQLite migration.`
+
+// runMigrate manages database migrations and returns the process exit code.
+func runMigrate(env *cliEnv, args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := fs.String("dir", migrationsDir, "Migrations directory")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	args = fs.Args()
+	if len(args) == 0 {
+		return usageError(migrateUsage)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return usageError(migrateUsage)
+		}
+
+		path, err := database.Create(*dir, args[1])
+		if err != nil {
+			return fail("Error creating migration: %v", err)
+		}
+
+		fmt.Println(path)
+		return exitOK
+	}
+
+	if len(args) != 1 {
+		return usageError(migrateUsage)
+	}
+
+	var migrate func(m *database.Migrator, dir string) error
+	switch args[0] {
+	case "up":
+		migrate = (*database.Migrator).Up
+	case "down":
+		migrate = (*database.Migrator).Down
+	case "status":
+		migrate = (*database.Migrator).Status
+	default:
+		return usageError(migrateUsage)
+	}
+
+	db, err := env.database()
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	if err := migrate(database.NewMigrator(db, env.backend, env.log), *dir); err != nil {
+		return fail("Error running migrate %s: %v", args[0], err)
+	}
+
+	return exitOK
+}
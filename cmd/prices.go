@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/entity"
+)
+
+const pricesUsage = `Usage:
+  main prices fetch [SYMBOL]`
+
+// runPrices queries the configured exchange once, bypassing the cache, and
+// returns the process exit code. In demo mode that is the simulated one.
+func runPrices(env *cliEnv, args []string) int {
+	if len(args) == 0 || args[0] != "fetch" || len(args) > 2 {
+		return usageError(pricesUsage)
+	}
+
+	symbols := supportedSymbols()
+	if len(args) == 2 {
+		symbol := entity.CurrencyName(strings.ToUpper(args[1]))
+		if !isSupportedSymbol(symbol) {
+			fmt.Fprintf(os.Stderr, "unsupported symbol %q\n", args[1])
+			return usageError(pricesUsage)
+		}
+		symbols = []entity.CurrencyName{symbol}
+	}
+
+	if err := env.load(); err != nil {
+		return fail("%v", err)
+	}
+	client := newExchange(env.cfg, clock.Real(), env.log)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	code := exitOK
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tPRICE\tUPDATED")
+	for _, symbol := range symbols {
+		price, err := client.GetPriceBySymbol(ctx, symbol)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching %s: %v\n", symbol, err)
+			code = exitError
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", price.Symbol, price.Price, price.Updated)
+	}
+	w.Flush()
+
+	return code
+}
+
+func supportedSymbols() []entity.CurrencyName {
+	ids := make([]int, 0, len(entity.TokenList))
+	for id := range entity.TokenList {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	symbols := make([]entity.CurrencyName, 0, len(ids))
+	for _, id := range ids {
+		symbols = append(symbols, entity.TokenList[id])
+	}
+	return symbols
+}
+
+func isSupportedSymbol(symbol entity.CurrencyName) bool {
+	for _, known := range entity.TokenList {
+		if known == symbol {
+			return true
+		}
+	}
+	return false
+}
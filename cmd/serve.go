@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/config"
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/infrastructure/cache"
+	"tgBotFinal/internal/infrastructure/cryptoClient"
+	"tgBotFinal/internal/infrastructure/cryptoClient/bybit"
+	"tgBotFinal/internal/infrastructure/cryptoClient/simulator"
+	"tgBotFinal/internal/infrastructure/database"
+	"tgBotFinal/internal/infrastructure/leader"
+	"tgBotFinal/internal/infrastructure/metrics"
+	"tgBotFinal/internal/infrastructure/notification/console"
+	"tgBotFinal/internal/infrastructure/notification/telegram"
+	"tgBotFinal/internal/infrastructure/router/chi"
+	"tgBotFinal/internal/infrastructure/tracing"
+	"tgBotFinal/internal/infrastructure/updates"
+	"tgBotFinal/internal/lifecycle"
+	"tgBotFinal/internal/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const serveUsage = `Usage:
+  main serve`
+
+// runServe runs the bot until it receives SIGINT, SIGTERM or SIGQUIT.
+func runServe(env *cliEnv, args []string) int {
+	if len(args) > 0 {
+		return usageError(serveUsage)
+	}
+
+	env.logOutput = os.Stdout
+	if err := env.load(); err != nil {
+		return fail("%v", err)
+	}
+	cfg, appLog := env.cfg, env.log
+
+	appLog.Info("Starting App")
+
+	//Init tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TraceExporter, "tgBotFinal")
+	if err != nil {
+		appLog.Error("Error initializing tracing", "error", err)
+		return exitError
+	}
+
+	//Init DB
+	var db *sql.DB
+	if cfg.Demo {
+		appLog.Warn("Demo mode: data is kept in memory, prices are simulated and messages are only logged")
+	} else {
+		db, err = env.database()
+		if err != nil {
+			appLog.Error("Error connecting to database", "error", err)
+			return exitError
+		}
+		defer env.close()
+
+		if err := migrateDB(db, env.backend, appLog); err != nil {
+			appLog.Error("Error migrating database", "error", err)
+			return exitError
+		}
+
+		if err := metrics.RegisterDB(db, env.backend); err != nil {
+			appLog.Warn("Failed to register database metrics", "error", err)
+		}
+	}
+
+	//Init repositories
+	repos, err := env.repositories()
+	if err != nil {
+		appLog.Error("Error initializing repositories", "error", err)
+		return exitError
+	}
+	currencyRepo := repos.currencies
+	userRepo := repos.users
+	priceHistoryRepo := repos.priceHistory
+	apiKeyService := service.NewAPIKeyService(repos.apiKeys, appLog)
+
+	clk := clock.Real()
+
+	//init cryptoClient
+	exchange := newExchange(cfg, clk, appLog)
+
+	// Wrap with cached client
+	priceStore, closeStore, err := newPriceStore(cfg, clk, appLog)
+	if err != nil {
+		appLog.Error("Error initializing price cache", "error", err)
+		return exitError
+	}
+	defer closeStore()
+	cachedClient := cryptoClient.NewCachedClient(exchange, priceStore, cfg.CacheTTL, cfg.CacheMaxStale, clk, appLog)
+
+	//init notification
+	webhookURL := cfg.WebhookURL
+	var tgNotifier service.Notification
+	if cfg.Demo {
+		tgNotifier = console.NewNotification(appLog)
+		// There is no Telegram to register the webhook with.
+		webhookURL = ""
+	} else {
+		tgNotifier, err = telegram.NewNotificationTelegram(appLog, cfg.TgToken, cfg.TgAPIURL, clk)
+		if err != nil {
+			appLog.Error("Error initializing Telegram", "error", err)
+			return exitError
+		}
+	}
+
+	//init leader election
+	instanceID := cfg.InstanceID
+	if instanceID == "" {
+		instanceID, _ = os.Hostname()
+	}
+	// A SQLite file or the demo's memory is not shared between replicas,
+	// so there is nobody to elect: the service leads alone.
+	var elector service.LeaderElector = service.NewSoloLeader(instanceID, metrics.Observer{})
+	if env.backend == database.Postgres {
+		elector = leader.NewPostgresElector(db, int64(cfg.LeaderLockKey), instanceID, cfg.LeaderCheckInterval, appLog)
+	}
+
+	//init service
+	serv := service.NewCryptService(
+		currencyRepo,
+		userRepo,
+		priceHistoryRepo,
+		cachedClient,
+		tgNotifier,
+		nil,
+		webhookURL,
+		appLog,
+		cfg.HTTPPort,
+		workerIntervals(cfg),
+		service.RetryPolicy{
+			Attempts:     cfg.PriceFetchRetries,
+			InitialDelay: cfg.PriceRetryInitialDelay,
+			MaxDelay:     cfg.PriceRetryMaxDelay,
+		},
+		elector,
+		repos.unitOfWork,
+		clk,
+		metrics.Observer{},
+	)
+
+	//init router
+	overflow, err := updates.ParsePolicy(cfg.UpdateOverflow)
+	if err != nil {
+		appLog.Error("Error initializing update processor", "error", err)
+		return exitError
+	}
+	processor := updates.NewProcessor(cfg.UpdateWorkers, cfg.UpdateQueueSize, overflow, cfg.UpdateQueueWait, appLog)
+	limits, err := rateLimits(cfg)
+	if err != nil {
+		appLog.Error("Error initializing rate limits", "error", err)
+		return exitError
+	}
+	limiter := service.NewCommandLimiter(userRepo, limits, appLog, metrics.Observer{})
+	broadcaster := service.NewBroadcastService(userRepo, tgNotifier, appLog)
+	router := chi.NewChiRouter(appLog, userRepo, currencyRepo, tgNotifier, cachedClient, exchange, serv, apiKeyService, broadcaster,
+		processor, limiter, cfg.CORSOrigins, cfg.AdminChatIDs)
+	serv.ChiRouter = router
+	// Graceful Shutdown
+	mainCtx, stop := signal.NotifyContext(context.Background(),
+		syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT,
+	)
+	defer stop()
+	// runCtx also stops the background tasks when the service fails.
+	runCtx, stopRun := context.WithCancel(mainCtx)
+	defer stopRun()
+
+	lc := lifecycle.New(appLog)
+	lc.Go("config reload", func(context.Context) {
+		watchConfigReload(runCtx, env.configPath, cfg, serv, appLog)
+	})
+
+	code := exitOK
+	serviceErr := make(chan error, 1)
+	lc.Go("service", func(context.Context) {
+		defer close(serviceErr)
+
+		appLog.Info("Starting server")
+		if err := serv.Run(runCtx); err != nil && err != http.ErrServerClosed {
+			appLog.Error("Error starting server", "error", err)
+			serviceErr <- err
+		}
+	})
+
+	select {
+	case <-mainCtx.Done():
+		appLog.Info("Received shutdown signal, initiating graceful shutdown...")
+	case err := <-serviceErr:
+		if err != nil {
+			appLog.Error("Service encountered error", "error", err)
+			code = exitError
+		}
+	}
+	stopRun()
+
+	// Webhooks are refused first so no new work arrives, then the work in
+	// flight is drained before the resources it uses are released.
+	lc.OnShutdown("http server", router.Shutdown)
+	lc.OnShutdown("update handlers", processor.Shutdown)
+	lc.OnShutdown("background tasks", func(ctx context.Context) error {
+		// Leadership must be handed over before the database is closed.
+		if err := lc.Wait(ctx); err != nil {
+			serv.Abort()
+			return err
+		}
+		return nil
+	})
+	lc.OnShutdown("broadcasts", broadcaster.Shutdown)
+	lc.OnShutdown("price cache", func(context.Context) error {
+		closeStore()
+		return nil
+	})
+	lc.OnShutdown("database", func(context.Context) error {
+		return env.close()
+	})
+	lc.OnShutdown("tracing", shutdownTracing)
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer shutdownCancel()
+
+	appLog.Info("Shutting down gracefully...")
+
+	if err := lc.Shutdown(shutdownCtx); err != nil {
+		appLog.Error("Graceful shutdown incomplete", "error", err)
+		return exitError
+	}
+
+	appLog.Info("Shutting down successfully")
+	return code
+}
+
+// newExchange returns the Bybit client, or a simulated exchange in demo
+// mode.
+func newExchange(cfg *config.Config, clk clock.Clock, appLog *slog.Logger) service.CryptoClient {
+	if cfg.Demo {
+		walk := simulator.NewRandomWalk(uint64(time.Now().UnixNano()), clk.Now(), 10*time.Second)
+		return simulator.NewClient(walk, clk)
+	}
+
+	return bybit.NewClient(appLog, cfg.APIUrl, clk)
+}
+
+// newPriceStore builds the price cache selected by CACHE_BACKEND. It keeps
+// prices as long as they may be served stale. The returned function
+// releases it.
+func newPriceStore(cfg *config.Config, clk clock.Clock, appLog *slog.Logger) (cache.Store, func(), error) {
+	retention := cfg.CacheTTL + cfg.CacheMaxStale
+
+	if cfg.CacheBackend != "redis" {
+		return cache.NewPriceCache(retention, clk), func() {}, nil
+	}
+
+	opts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse REDIS_URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf("ping redis: %w", err)
+	}
+
+	store := cache.NewRedisCache(client, "tgbot", retention, clk, appLog)
+	return store, sync.OnceFunc(func() {
+		store.Close()
+		client.Close()
+	}), nil
+}
+
+func rateLimits(cfg *config.Config) (service.RateLimits, error) {
+	chat, err := entity.ParseRateLimit(cfg.RateLimitChat)
+	if err != nil {
+		return service.RateLimits{}, err
+	}
+	commands, err := entity.ParseCommandRateLimits(cfg.RateLimitCommands)
+	if err != nil {
+		return service.RateLimits{}, err
+	}
+	global, err := entity.ParseRateLimit(cfg.RateLimitGlobal)
+	if err != nil {
+		return service.RateLimits{}, err
+	}
+
+	return service.RateLimits{
+		Chat:           chat,
+		Commands:       commands,
+		Global:         global,
+		AbuseThreshold: cfg.AbuseThreshold,
+		AbuseWindow:    cfg.AbuseWindow,
+		BanDuration:    cfg.AbuseBanDuration,
+	}, nil
+}
+
+func workerIntervals(cfg *config.Config) service.WorkerIntervals {
+	return service.WorkerIntervals{
+		Notify:           cfg.NotifyInterval,
+		CacheRefresh:     cfg.CacheRefreshInterval,
+		Digest:           cfg.DigestCheckInterval,
+		HistoryRetention: cfg.PriceHistoryRetention,
+	}
+}
+
+// watchConfigReload reloads the configuration on SIGHUP and applies the
+// settings that are safe to change at runtime. Other changes are compared
+// with the startup configuration and reported as needing a restart. An
+// invalid configuration is rejected and the running one is kept.
+func watchConfigReload(ctx context.Context, path string, cfg *config.Config, serv *service.CryptService, appLog *slog.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+
+		next, err := config.Load(path)
+		if err != nil {
+			appLog.Error("Config reload rejected", "error", err)
+			continue
+		}
+
+		if err := logger.SetLevel(next.LogLevel); err != nil {
+			appLog.Error("Failed to apply log level", "error", err)
+		}
+		serv.UpdateIntervals(workerIntervals(next))
+
+		if changed := cfg.ColdChanges(next); len(changed) > 0 {
+			appLog.Warn("Config changes need a restart to take effect", "settings", changed)
+		}
+
+		appLog.Info("Config reloaded", "log_level", next.LogLevel,
+			"notify_interval", next.NotifyInterval,
+			"cache_refresh_interval", next.CacheRefreshInterval,
+			"digest_check_interval", next.DigestCheckInterval)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/infrastructure/cryptoClient/simulator"
+)
+
+const simulateUsage = `Usage:
+  main simulate [-listen ADDR] [-fixture FILE] [-seed N] [-step DURATION] [-speed FACTOR]
+
+Serves simulated prices in the format of Bybit's /v5/market/tickers; run
+the bot with API_URL=http://ADDR/v5/market/tickers to use them. Without
+-fixture prices follow a random walk that moves every -step; a CSV or
+JSON fixture is replayed from its first price. -speed makes time pass
+faster than real time.`
+
+// runSimulate serves a simulated exchange until it receives SIGINT or
+// SIGTERM and returns the process exit code.
+func runSimulate(env *cliEnv, args []string) int {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, simulateUsage) }
+	listen := fs.String("listen", "127.0.0.1:9090", "Address to listen on")
+	fixture := fs.String("fixture", "", "CSV or JSON price fixture to replay")
+	seed := fs.Uint64("seed", uint64(time.Now().UnixNano()), "Seed of the random walk")
+	step := fs.Duration("step", 10*time.Second, "How often the random walk moves")
+	speed := fs.Float64("speed", 1, "How many times faster than real time prices move")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 || *step <= 0 || *speed <= 0 {
+		return usageError(simulateUsage)
+	}
+
+	var (
+		feed  simulator.Feed
+		start = time.Now()
+	)
+	if *fixture != "" {
+		replay, err := simulator.LoadFixture(*fixture)
+		if err != nil {
+			return fail("%v", err)
+		}
+		feed, start = replay, replay.Start()
+	} else {
+		feed = simulator.NewRandomWalk(*seed, start, *step)
+	}
+
+	srv := &http.Server{
+		Addr:              *listen,
+		Handler:           simulator.NewHandler(feed, clock.Scaled(start, *speed)),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	fmt.Fprintf(os.Stderr, "Serving simulated tickers on http://%s%s\n", *listen, simulator.TickersPath)
+
+	select {
+	case err := <-errc:
+		return fail("Error serving simulated exchange: %v", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fail("Error stopping simulated exchange: %v", err)
+	}
+
+	return exitOK
+}
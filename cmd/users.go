@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+const usersUsage = `Usage:
+  main users list [-active|-inactive] [-digest off|daily|weekly] [-username NAME] [-limit N] [-offset N]
+  main users activate CHAT_ID
+  main users deactivate CHAT_ID
+  main users unban CHAT_ID`
+
+// runUsers manages subscribers and returns the process exit code.
+func runUsers(env *cliEnv, args []string) int {
+	if len(args) == 0 {
+		return usageError(usersUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		return listUsers(env, args[1:])
+	case "activate":
+		return setUserActive(env, args[1:], true)
+	case "deactivate":
+		return setUserActive(env, args[1:], false)
+	case "unban":
+		return unbanUser(env, args[1:])
+	default:
+		return usageError(usersUsage)
+	}
+}
+
+func listUsers(env *cliEnv, args []string) int {
+	fs := flag.NewFlagSet("users list", flag.ContinueOnError)
+	active := fs.Bool("active", false, "Only active users")
+	inactive := fs.Bool("inactive", false, "Only inactive users")
+	digest := fs.String("digest", "", "Only users with this digest period")
+	username := fs.String("username", "", "Only users whose username contains this text")
+	limit := fs.Int("limit", 50, "Maximum number of users to print, 0 for all")
+	offset := fs.Int("offset", 0, "Number of users to skip")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 || (*active && *inactive) || *limit < 0 || *offset < 0 {
+		return usageError(usersUsage)
+	}
+
+	filter := entity.UserFilter{Username: *username, Limit: *limit, Offset: *offset}
+	if *active || *inactive {
+		filter.Active = active
+	}
+	if *digest != "" {
+		period, ok := entity.ParseDigestPeriod(*digest)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown digest period %q\n", *digest)
+			return usageError(usersUsage)
+		}
+		filter.Digest = period
+	}
+
+	repos, err := env.repositories()
+	if err != nil {
+		return fail("%v", err)
+	}
+	users := repos.users
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	list, err := users.List(ctx, filter)
+	if err != nil {
+		return fail("Error listing users: %v", err)
+	}
+	total, err := users.Count(ctx, filter)
+	if err != nil {
+		return fail("Error counting users: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHAT_ID\tUSERNAME\tACTIVE\tDIGEST\tTIMEZONE\tCREATED\tBANNED_UNTIL")
+	for _, user := range list {
+		banned := "-"
+		if user.IsBanned(time.Now()) {
+			banned = user.BannedUntil.Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%t\t%s\t%s\t%s\t%s\n", user.ChatID, user.Username, user.Active,
+			user.Digest, user.Timezone, user.CreatedAt.Format(time.DateTime), banned)
+	}
+	w.Flush()
+
+	fmt.Fprintf(os.Stderr, "%d of %d users\n", len(list), total)
+	return exitOK
+}
+
+func setUserActive(env *cliEnv, args []string, active bool) int {
+	if len(args) != 1 {
+		return usageError(usersUsage)
+	}
+
+	chatID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid chat id %q\n", args[0])
+		return usageError(usersUsage)
+	}
+
+	repos, err := env.repositories()
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err = repos.users.SetActive(ctx, chatID, active)
+	if errors.Is(err, service.ErrUserNotFound) {
+		return fail("User %d not found", chatID)
+	}
+	if err != nil {
+		return fail("Error updating user %d: %v", chatID, err)
+	}
+
+	state := "Deactivated"
+	if active {
+		state = "Activated"
+	}
+	fmt.Printf("%s user %d\n", state, chatID)
+	return exitOK
+}
+
+// unbanUser lifts a ban on every replica. The chat's recent commands still
+// count towards its rate limits until their windows expire.
+func unbanUser(env *cliEnv, args []string) int {
+	if len(args) != 1 {
+		return usageError(usersUsage)
+	}
+
+	chatID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid chat id %q\n", args[0])
+		return usageError(usersUsage)
+	}
+
+	repos, err := env.repositories()
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err = repos.users.SetBannedUntil(ctx, chatID, nil)
+	if errors.Is(err, service.ErrUserNotFound) {
+		return fail("User %d not found", chatID)
+	}
+	if err != nil {
+		return fail("Error unbanning user %d: %v", chatID, err)
+	}
+
+	fmt.Printf("Unbanned user %d\n", chatID)
+	return exitOK
+}
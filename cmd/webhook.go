@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tgBotFinal/internal/infrastructure/notification/telegram"
+	"tgBotFinal/internal/logger"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const webhookPath = "/webhook/telegram"
+
+const webhookUsage = `Usage:
+  main webhook set [URL]            (default: WEBHOOK_URL + ` + webhookPath + `)
+  main webhook delete [-drop-pending]
+  main webhook info`
+
+// runWebhook manages the Telegram webhook registration and returns the
+// process exit code.
+func runWebhook(env *cliEnv, args []string) int {
+	if len(args) == 0 {
+		return usageError(webhookUsage)
+	}
+
+	var request tgbotapi.Chattable
+	switch args[0] {
+	case "set":
+		if len(args) > 2 {
+			return usageError(webhookUsage)
+		}
+		if err := env.load(); err != nil {
+			return fail("%v", err)
+		}
+
+		link := strings.TrimSuffix(env.cfg.WebhookURL, "/") + webhookPath
+		if len(args) == 2 {
+			link = args[1]
+		} else if env.cfg.WebhookURL == "" {
+			fmt.Fprintln(os.Stderr, "WEBHOOK_URL is not set, pass the URL explicitly")
+			return usageError(webhookUsage)
+		}
+
+		config, err := tgbotapi.NewWebhook(link)
+		if err != nil {
+			return fail("Invalid webhook URL %q: %v", link, err)
+		}
+		request = config
+
+	case "delete":
+		fs := flag.NewFlagSet("webhook delete", flag.ContinueOnError)
+		dropPending := fs.Bool("drop-pending", false, "Drop updates waiting to be delivered")
+		if err := fs.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		if fs.NArg() > 0 {
+			return usageError(webhookUsage)
+		}
+		request = tgbotapi.DeleteWebhookConfig{DropPendingUpdates: *dropPending}
+
+	case "info":
+		if len(args) > 1 {
+			return usageError(webhookUsage)
+		}
+
+	default:
+		return usageError(webhookUsage)
+	}
+
+	if err := env.load(); err != nil {
+		return fail("%v", err)
+	}
+
+	bot, err := telegram.NewBotAPI(env.cfg.TgToken, env.cfg.TgAPIURL)
+	if err != nil {
+		return fail("Error connecting to Telegram: %s", logger.Redact(err.Error()))
+	}
+
+	if request == nil {
+		return printWebhookInfo(bot)
+	}
+
+	if _, err := bot.Request(request); err != nil {
+		return fail("Error running webhook %s: %s", args[0], logger.Redact(err.Error()))
+	}
+
+	fmt.Printf("Webhook %s: ok\n", args[0])
+	return exitOK
+}
+
+func printWebhookInfo(bot *tgbotapi.BotAPI) int {
+	info, err := bot.GetWebhookInfo()
+	if err != nil {
+		return fail("Error getting webhook info: %s", logger.Redact(err.Error()))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "URL\t%s\n", info.URL)
+	fmt.Fprintf(w, "PENDING\t%d\n", info.PendingUpdateCount)
+	fmt.Fprintf(w, "MAX_CONNECTIONS\t%d\n", info.MaxConnections)
+	if info.IPAddress != "" {
+		fmt.Fprintf(w, "IP_ADDRESS\t%s\n", info.IPAddress)
+	}
+	if info.LastErrorDate != 0 {
+		fmt.Fprintf(w, "LAST_ERROR\t%s %s\n",
+			time.Unix(int64(info.LastErrorDate), 0).UTC().Format(time.DateTime), info.LastErrorMessage)
+	}
+	w.Flush()
+
+	return exitOK
+}
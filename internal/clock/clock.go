@@ -0,0 +1,277 @@
+// Package clock abstracts the current time, timers and tickers so that code
+// depending on them can be driven by a fake in tests and simulations.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	// After sends the current time on the returned channel once d has
+	// passed.
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker is the part of time.Ticker the clocks provide.
+type Ticker interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+type realClock struct{}
+
+// Real returns the system clock.
+func Real() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return &realTicker{t: time.NewTicker(d)}
+}
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (t *realTicker) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t *realTicker) Reset(d time.Duration) {
+	t.t.Reset(d)
+}
+
+func (t *realTicker) Stop() {
+	t.t.Stop()
+}
+
+type scaledClock struct {
+	start  time.Time
+	origin time.Time
+	speed  float64
+}
+
+// Scaled returns a clock that reads start now and then runs speed times as
+// fast as the system clock, for replaying recorded data faster than it
+// happened. Its timers and tickers fire at the scaled rate, but ticks
+// carry the system time.
+func Scaled(start time.Time, speed float64) Clock {
+	return &scaledClock{start: start, origin: time.Now(), speed: speed}
+}
+
+func (c *scaledClock) Now() time.Time {
+	return c.start.Add(c.scale(time.Since(c.origin)))
+}
+
+func (c *scaledClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *scaledClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	time.AfterFunc(c.unscale(d), func() { ch <- c.Now() })
+	return ch
+}
+
+func (c *scaledClock) NewTicker(d time.Duration) Ticker {
+	return &scaledTicker{realTicker: realTicker{t: time.NewTicker(c.unscale(d))}, clock: c}
+}
+
+func (c *scaledClock) scale(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * c.speed)
+}
+
+// unscale converts a duration of the scaled clock to system time. It
+// never returns less than a nanosecond, which tickers would refuse.
+func (c *scaledClock) unscale(d time.Duration) time.Duration {
+	return max(time.Duration(float64(d)/c.speed), 1)
+}
+
+type scaledTicker struct {
+	realTicker
+	clock *scaledClock
+}
+
+func (t *scaledTicker) Reset(d time.Duration) {
+	t.t.Reset(t.clock.unscale(d))
+}
+
+// Fake is a clock that only moves when told to. Timers and tickers fire
+// from Set and Advance when their time comes. It is safe for concurrent
+// use.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+	// changed is closed and replaced whenever a waiter is added.
+	changed chan struct{}
+}
+
+// fakeWaiter is a pending After or an active ticker.
+type fakeWaiter struct {
+	at     time.Time
+	period time.Duration // 0 for After
+	ch     chan time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, changed: make(chan struct{})}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &fakeWaiter{at: f.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.ch <- f.now
+		return w.ch
+	}
+
+	f.addWaiter(w)
+	return w.ch
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &fakeWaiter{at: f.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	f.addWaiter(w)
+	return &fakeTicker{clock: f, w: w}
+}
+
+// Set moves the clock to now, which may be in the past, and fires the
+// timers and tickers that are due.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = now
+	f.fire()
+}
+
+// Advance moves the clock forward by d and fires the timers and tickers
+// that are due. Like a time.Ticker, a ticker that falls behind delivers
+// one tick and drops the rest.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	f.fire()
+}
+
+// BlockUntil waits until at least n timers and tickers wait on the clock,
+// so a test can advance it knowing the code under test is waiting.
+func (f *Fake) BlockUntil(n int) {
+	for {
+		f.mu.Lock()
+		waiting, changed := len(f.waiters), f.changed
+		f.mu.Unlock()
+
+		if waiting >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+func (f *Fake) addWaiter(w *fakeWaiter) {
+	f.waiters = append(f.waiters, w)
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *Fake) removeWaiter(w *fakeWaiter) {
+	for i, other := range f.waiters {
+		if other == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// fire delivers the ticks that are due and drops the timers that fired.
+func (f *Fake) fire() {
+	pending := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			pending = append(pending, w)
+			continue
+		}
+
+		select {
+		case w.ch <- f.now:
+		default:
+		}
+
+		if w.period > 0 {
+			missed := f.now.Sub(w.at) / w.period
+			w.at = w.at.Add((missed + 1) * w.period)
+			pending = append(pending, w)
+		}
+	}
+	f.waiters = pending
+}
+
+type fakeTicker struct {
+	clock *Fake
+	w     *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.w.at, t.w.period = t.clock.now.Add(d), d
+	t.clock.removeWaiter(t.w)
+	t.clock.addWaiter(t.w)
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.clock.removeWaiter(t.w)
+}
@@ -0,0 +1,121 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+
+	f.Advance(90 * time.Second)
+	if got := f.Now(); !got.Equal(start.Add(90 * time.Second)) {
+		t.Errorf("Now() after Advance = %s", got)
+	}
+
+	f.Set(start)
+	if got := f.Now(); !got.Equal(start) {
+		t.Errorf("Now() after Set = %s", got)
+	}
+}
+
+func TestScaled(t *testing.T) {
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	c := Scaled(start, 3600)
+
+	time.Sleep(10 * time.Millisecond)
+	if elapsed := c.Now().Sub(start); elapsed < 36*time.Second || elapsed > time.Hour {
+		t.Errorf("Scaled clock advanced %s in 10ms at 3600x", elapsed)
+	}
+}
+
+func TestFake_After(t *testing.T) {
+	f := NewFake(time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC))
+	ch := f.After(time.Minute)
+
+	f.Advance(59 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("After fired early")
+	default:
+	}
+
+	f.Advance(time.Second)
+	select {
+	case at := <-ch:
+		if !at.Equal(f.Now()) {
+			t.Errorf("After sent %s, want %s", at, f.Now())
+		}
+	default:
+		t.Fatal("After did not fire when due")
+	}
+
+	select {
+	case <-f.After(0):
+	default:
+		t.Error("After(0) did not fire at once")
+	}
+}
+
+func TestFake_Ticker(t *testing.T) {
+	f := NewFake(time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC))
+	ticker := f.NewTicker(10 * time.Second)
+
+	ticks := func() int {
+		n := 0
+		for {
+			select {
+			case <-ticker.C():
+				n++
+			default:
+				return n
+			}
+		}
+	}
+
+	f.Advance(10 * time.Second)
+	if n := ticks(); n != 1 {
+		t.Errorf("Ticks after one interval = %d, want 1", n)
+	}
+
+	// A ticker that falls behind drops the ticks it missed.
+	f.Advance(35 * time.Second)
+	if n := ticks(); n != 1 {
+		t.Errorf("Ticks after three intervals unread = %d, want 1", n)
+	}
+	f.Advance(5 * time.Second)
+	if n := ticks(); n != 1 {
+		t.Errorf("Ticks on the next interval = %d, want 1", n)
+	}
+
+	ticker.Reset(time.Minute)
+	f.Advance(30 * time.Second)
+	if n := ticks(); n != 0 {
+		t.Errorf("Ticks before the reset interval = %d, want 0", n)
+	}
+	f.Advance(30 * time.Second)
+	if n := ticks(); n != 1 {
+		t.Errorf("Ticks after the reset interval = %d, want 1", n)
+	}
+
+	ticker.Stop()
+	f.Advance(time.Hour)
+	if n := ticks(); n != 0 {
+		t.Errorf("Ticks after Stop = %d, want 0", n)
+	}
+}
+
+func TestFake_BlockUntil(t *testing.T) {
+	f := NewFake(time.Now())
+	done := make(chan struct{})
+
+	go func() {
+		<-f.After(time.Second)
+		close(done)
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Second)
+	<-done
+}
@@ -1,39 +1,301 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Config holds every setting of the bot. Values are resolved in order from
+// the defaults, an optional YAML or TOML file and environment variables, so
+// the environment always wins.
+//
+// The env tag names the environment variable; the same name in lower case
+// is the key in a config file. Fields tagged reload:"hot" are re-applied on
+// SIGHUP, all others need a restart.
 type Config struct {
-	HTTPPort    string
-	DatabaseURL string
-	TgToken     string
-	LogLevel    string
-	APIUrl      string
-	WebhookURL  string
+	HTTPPort      string   `env:"HTTP_PORT"`
+	DatabaseURL   string   `env:"DATABASE_URL"`
+	TgToken       string   `env:"TG_TOKEN"`
+	TgTokenFile   string   `env:"TG_TOKEN_FILE"`
+	TgAPIURL      string   `env:"TG_API_URL"`
+	LogLevel      string   `env:"LOG_LEVEL" reload:"hot"`
+	LogFormat     string   `env:"LOG_FORMAT"`
+	APIUrl        string   `env:"API_URL"`
+	KlineURL      string   `env:"KLINE_URL"`
+	WebhookURL    string   `env:"WEBHOOK_URL"`
+	CORSOrigins   []string `env:"CORS_ALLOWED_ORIGINS"`
+	AdminChatIDs  []int64  `env:"ADMIN_CHAT_IDS"`
+	TraceExporter string   `env:"TRACE_EXPORTER"`
+
+	NotifyInterval        time.Duration `env:"NOTIFY_INTERVAL" reload:"hot"`
+	CacheRefreshInterval  time.Duration `env:"CACHE_REFRESH_INTERVAL" reload:"hot"`
+	DigestCheckInterval   time.Duration `env:"DIGEST_CHECK_INTERVAL" reload:"hot"`
+	PriceHistoryRetention time.Duration `env:"PRICE_HISTORY_RETENTION" reload:"hot"`
+	CacheTTL              time.Duration `env:"CACHE_TTL"`
+	CacheMaxStale         time.Duration `env:"CACHE_MAX_STALE"`
+	CacheBackend          string        `env:"CACHE_BACKEND"`
+	RedisURL              string        `env:"REDIS_URL"`
+
+	DBMaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS"`
+	DBMaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS"`
+	DBConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME"`
+
+	PriceFetchRetries      int           `env:"PRICE_FETCH_RETRIES"`
+	PriceRetryInitialDelay time.Duration `env:"PRICE_RETRY_INITIAL_DELAY"`
+	PriceRetryMaxDelay     time.Duration `env:"PRICE_RETRY_MAX_DELAY"`
+
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT"`
+
+	UpdateWorkers   int           `env:"UPDATE_WORKERS"`
+	UpdateQueueSize int           `env:"UPDATE_QUEUE_SIZE"`
+	UpdateQueueWait time.Duration `env:"UPDATE_QUEUE_WAIT"`
+	UpdateOverflow  string        `env:"UPDATE_OVERFLOW"`
+
+	RateLimitChat     string        `env:"RATE_LIMIT_CHAT"`
+	RateLimitCommands []string      `env:"RATE_LIMIT_COMMANDS"`
+	RateLimitGlobal   string        `env:"RATE_LIMIT_GLOBAL"`
+	AbuseThreshold    int           `env:"ABUSE_THRESHOLD"`
+	AbuseWindow       time.Duration `env:"ABUSE_WINDOW"`
+	AbuseBanDuration  time.Duration `env:"ABUSE_BAN_DURATION"`
+
+	// InstanceID names this replica in logs and health checks; empty means
+	// the hostname.
+	InstanceID          string        `env:"INSTANCE_ID"`
+	LeaderLockKey       int           `env:"LEADER_LOCK_KEY"`
+	LeaderCheckInterval time.Duration `env:"LEADER_CHECK_INTERVAL"`
+
+	// Demo runs the bot without external services: data is kept in memory,
+	// prices are simulated and messages are logged instead of sent.
+	// DATABASE_URL and TG_TOKEN are not needed.
+	Demo bool `env:"DEMO"`
+}
+
+// Default returns the configuration used when nothing overrides it.
+func Default() *Config {
+	return &Config{
+		HTTPPort:      "8080",
+		LogLevel:      "info",
+		LogFormat:     "json",
+		TgAPIURL:      "https://api.telegram.org",
+		APIUrl:        "https://api.bybit.com/v5/market/tickers",
+		KlineURL:      "https://api.bybit.com/v5/market/kline",
+		TraceExporter: "none",
+
+		NotifyInterval:        15 * time.Minute,
+		CacheRefreshInterval:  30 * time.Second,
+		DigestCheckInterval:   5 * time.Minute,
+		PriceHistoryRetention: 8 * 24 * time.Hour,
+		CacheTTL:              time.Minute,
+		CacheMaxStale:         5 * time.Minute,
+		CacheBackend:          "memory",
+
+		DBMaxOpenConns:    25,
+		DBMaxIdleConns:    25,
+		DBConnMaxLifetime: 5 * time.Minute,
+
+		PriceFetchRetries:      5,
+		PriceRetryInitialDelay: 100 * time.Millisecond,
+		PriceRetryMaxDelay:     10 * time.Minute,
+
+		ShutdownTimeout: 30 * time.Second,
+
+		UpdateWorkers:   16,
+		UpdateQueueSize: 32,
+		UpdateQueueWait: 2 * time.Second,
+		UpdateOverflow:  "reject",
+
+		RateLimitChat:     "20/1m",
+		RateLimitCommands: []string{"/price=5/1m", "/prices=5/1m"},
+		RateLimitGlobal:   "1200/1m",
+		AbuseThreshold:    10,
+		AbuseWindow:       10 * time.Minute,
+		AbuseBanDuration:  time.Hour,
+
+		LeaderLockKey:       7283946,
+		LeaderCheckInterval: 5 * time.Second,
+	}
 }
 
-func MustLoadConfig() *Config {
+// Load builds the configuration from the defaults, the file at path (if
+// not empty) and the environment, then validates it. The returned error
+// lists every invalid setting.
+func Load(path string) (*Config, error) {
 	_ = godotenv.Load(".env")
 
 	_ = godotenv.Load("/app/.env")
 	_ = godotenv.Load("/root/.env")
 
-	return &Config{
-		HTTPPort:    getEnv("HTTP_PORT", ""),
-		DatabaseURL: getEnv("DATABASE_URL", ""),
-		TgToken:     getEnv("TG_TOKEN", ""),
-		LogLevel:    getEnv("LOG_LEVEL", "Debug"),
-		APIUrl:      getEnv("API_URL", ""),
-		WebhookURL:  getEnv("WEBHOOK_URL", ""),
+	cfg := Default()
+	var errs []error
+
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, cfg.apply(values, path))
+	}
+
+	errs = append(errs, cfg.apply(envValues(), "environment"))
+
+	if cfg.TgTokenFile != "" {
+		token, err := os.ReadFile(cfg.TgTokenFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("TG_TOKEN_FILE: %w", err))
+		} else {
+			cfg.TgToken = strings.TrimSpace(string(token))
+		}
+	}
+
+	cfg.normalize()
+	errs = append(errs, cfg.Validate())
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// normalize lowercases the settings that name one of a fixed set of
+// values, so the code using them can compare exactly.
+func (c *Config) normalize() {
+	for _, value := range []*string{&c.LogFormat, &c.TraceExporter, &c.CacheBackend, &c.UpdateOverflow} {
+		*value = strings.ToLower(*value)
+	}
+}
+
+// ColdChanges lists the settings that differ between c and next and only
+// take effect after a restart.
+func (c *Config) ColdChanges(next *Config) []string {
+	var changed []string
+
+	cur, nxt := reflect.ValueOf(c).Elem(), reflect.ValueOf(next).Elem()
+	for i := range cur.NumField() {
+		field := cur.Type().Field(i)
+		if field.Tag.Get("reload") == "hot" {
+			continue
+		}
+		if !reflect.DeepEqual(cur.Field(i).Interface(), nxt.Field(i).Interface()) {
+			changed = append(changed, field.Tag.Get("env"))
+		}
+	}
+
+	return changed
+}
+
+// envValues collects the environment variables that map to config fields.
+func envValues() map[string]string {
+	values := make(map[string]string)
+
+	t := reflect.TypeOf(Config{})
+	for i := range t.NumField() {
+		name := t.Field(i).Tag.Get("env")
+		if value := os.Getenv(name); value != "" {
+			values[name] = value
+		}
+	}
+
+	return values
+}
+
+// apply sets the fields named in values, keyed by env name. Unknown keys
+// and unparsable values are reported together.
+func (c *Config) apply(values map[string]string, source string) error {
+	var errs []error
+
+	v := reflect.ValueOf(c).Elem()
+	known := make(map[string]bool, v.NumField())
+
+	for i := range v.NumField() {
+		name := v.Type().Field(i).Tag.Get("env")
+		known[name] = true
+
+		raw, ok := values[name]
+		if !ok {
+			continue
+		}
+		if err := setField(v.Field(i), raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", name, source, err))
+		}
+	}
+
+	for name := range values {
+		if !known[name] {
+			errs = append(errs, fmt.Errorf("%s (%s): unknown setting", strings.ToLower(name), source))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setField(field reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.String:
+		field.SetString(raw)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		field.SetInt(int64(n))
+	case field.Type() == reflect.TypeOf([]string(nil)):
+		field.Set(reflect.ValueOf(splitList(raw)))
+	case field.Type() == reflect.TypeOf([]int64(nil)):
+		ids, err := parseChatIDs(raw)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(ids))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// parseChatIDs parses a comma-separated list of chat IDs.
+func parseChatIDs(value string) ([]int64, error) {
+	var ids []int64
+	for _, item := range splitList(value) {
+		id, err := strconv.ParseInt(item, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chat id %q", item)
+		}
+		ids = append(ids, id)
 	}
-	return defaultValue
+	return ids, nil
 }
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearEnv unsets every config variable for the duration of the test.
+func clearEnv(t *testing.T) {
+	typ := reflect.TypeOf(Config{})
+	for i := range typ.NumField() {
+		name := typ.Field(i).Tag.Get("env")
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Env(t *testing.T) {
+	clearEnv(t)
+
+	t.Setenv("DATABASE_URL", "test_db_url")
+	t.Setenv("TG_TOKEN", "test_token")
+	t.Setenv("LOG_LEVEL", "Debug")
+	t.Setenv("HTTP_PORT", "9090")
+	t.Setenv("API_URL", "https://test-api.com")
+	t.Setenv("WEBHOOK_URL", "https://test-webhook.com")
+	t.Setenv("NOTIFY_INTERVAL", "90s")
+	t.Setenv("ADMIN_CHAT_IDS", "1, 2")
+	t.Setenv("TRACE_EXPORTER", "OTLP")
+
+	cfg, err := Load("")
+	require.NoError(t, err)
+
+	assert.Equal(t, "test_db_url", cfg.DatabaseURL)
+	assert.Equal(t, "test_token", cfg.TgToken)
+	assert.Equal(t, "Debug", cfg.LogLevel)
+	assert.Equal(t, "9090", cfg.HTTPPort)
+	assert.Equal(t, "https://test-api.com", cfg.APIUrl)
+	assert.Equal(t, "https://test-webhook.com", cfg.WebhookURL)
+	assert.Equal(t, 90*time.Second, cfg.NotifyInterval)
+	assert.Equal(t, []int64{1, 2}, cfg.AdminChatIDs)
+	assert.Equal(t, "otlp", cfg.TraceExporter)
+
+	os.Unsetenv("HTTP_PORT")
+	os.Unsetenv("LOG_LEVEL")
+	os.Unsetenv("API_URL")
+
+	cfg, err = Load("")
+	require.NoError(t, err)
+	assert.Equal(t, "8080", cfg.HTTPPort)
+	assert.Equal(t, "info", cfg.LogLevel)
+	assert.Equal(t, "https://api.bybit.com/v5/market/tickers", cfg.APIUrl)
+	assert.Equal(t, "https://api.bybit.com/v5/market/kline", cfg.KlineURL)
+	assert.Equal(t, "https://api.telegram.org", cfg.TgAPIURL)
+}
+
+func TestLoad_Files(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+http_port: 9091
+database_url: postgres://localhost/bot
+tg_token: file_token
+cors_allowed_origins:
+  - https://a.example.com
+  - https://b.example.com
+notify_interval: 5m
+db_max_open_conns: 10
+`,
+		"config.toml": `
+http_port = 9091
+database_url = "postgres://localhost/bot"
+tg_token = "file_token"
+cors_allowed_origins = ["https://a.example.com", "https://b.example.com"]
+notify_interval = "5m"
+db_max_open_conns = 10
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("DB_MAX_OPEN_CONNS", "20")
+
+			cfg, err := Load(writeFile(t, name, content))
+			require.NoError(t, err)
+
+			assert.Equal(t, "9091", cfg.HTTPPort)
+			assert.Equal(t, "file_token", cfg.TgToken)
+			assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.CORSOrigins)
+			assert.Equal(t, 5*time.Minute, cfg.NotifyInterval)
+			assert.Equal(t, 20, cfg.DBMaxOpenConns, "environment overrides the file")
+		})
+	}
+}
+
+func TestLoad_TokenFile(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DATABASE_URL", "postgres://localhost/bot")
+	t.Setenv("TG_TOKEN_FILE", writeFile(t, "token", "secret_token\n"))
+
+	cfg, err := Load("")
+	require.NoError(t, err)
+	assert.Equal(t, "secret_token", cfg.TgToken)
+
+	t.Setenv("TG_TOKEN_FILE", filepath.Join(t.TempDir(), "missing"))
+	_, err = Load("")
+	assert.ErrorContains(t, err, "TG_TOKEN_FILE")
+}
+
+func TestLoad_ReportsEveryError(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("HTTP_PORT", "http")
+	t.Setenv("LOG_LEVEL", "loud")
+	t.Setenv("WEBHOOK_URL", "http://insecure.example.com")
+	t.Setenv("NOTIFY_INTERVAL", "0s")
+
+	_, err := Load("")
+	require.Error(t, err)
+
+	for _, name := range []string{"HTTP_PORT", "DATABASE_URL", "TG_TOKEN", "LOG_LEVEL", "WEBHOOK_URL", "NOTIFY_INTERVAL"} {
+		assert.Contains(t, err.Error(), name)
+	}
+
+	t.Setenv("CACHE_TTL", "soon")
+	t.Setenv("ADMIN_CHAT_IDS", "1,x")
+	_, err = Load("")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `CACHE_TTL (environment): invalid duration "soon"`)
+	assert.Contains(t, err.Error(), `ADMIN_CHAT_IDS (environment): invalid chat id "x"`)
+
+	_, err = Load(writeFile(t, "config.yaml", "tg_tokn: typo\n"))
+	assert.ErrorContains(t, err, "tg_tokn")
+}
+
+func TestColdChanges(t *testing.T) {
+	cur := Default()
+	next := Default()
+
+	next.LogLevel = "debug"
+	next.NotifyInterval = time.Minute
+	assert.Empty(t, cur.ColdChanges(next))
+
+	next.HTTPPort = "9999"
+	next.CORSOrigins = []string{"https://example.com"}
+	assert.Equal(t, "HTTP_PORT,CORS_ALLOWED_ORIGINS", strings.Join(cur.ColdChanges(next), ","))
+}
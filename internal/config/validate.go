@@ -0,0 +1,91 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/logger"
+)
+
+// Validate checks every setting and reports all problems at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, name, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", name, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	port, err := strconv.Atoi(c.HTTPPort)
+	check(err == nil && port > 0 && port <= 65535, "HTTP_PORT", "must be a port number, got %q", c.HTTPPort)
+
+	check(c.Demo || c.DatabaseURL != "", "DATABASE_URL", "is required")
+	check(c.Demo || c.TgToken != "", "TG_TOKEN", "is required (or set TG_TOKEN_FILE)")
+
+	check(isURL(c.TgAPIURL, "http", "https"), "TG_API_URL", "must be an http(s) URL, got %q", c.TgAPIURL)
+	check(isURL(c.APIUrl, "http", "https"), "API_URL", "must be an http(s) URL, got %q", c.APIUrl)
+	check(isURL(c.KlineURL, "http", "https"), "KLINE_URL", "must be an http(s) URL, got %q", c.KlineURL)
+	check(c.WebhookURL == "" || isURL(c.WebhookURL, "https"), "WEBHOOK_URL", "must be an https URL, got %q", c.WebhookURL)
+
+	_, err = logger.ParseLevel(c.LogLevel)
+	check(err == nil, "LOG_LEVEL", "must be one of debug, info, warn, error, got %q", c.LogLevel)
+	check(oneOf(c.LogFormat, "json", "text"), "LOG_FORMAT", "must be json or text, got %q", c.LogFormat)
+	check(oneOf(c.TraceExporter, "none", "stdout", "otlp"), "TRACE_EXPORTER", "must be none, stdout or otlp, got %q", c.TraceExporter)
+
+	check(oneOf(c.CacheBackend, "memory", "redis"), "CACHE_BACKEND", "must be memory or redis, got %q", c.CacheBackend)
+	check(c.CacheBackend != "redis" || isURL(c.RedisURL, "redis", "rediss"),
+		"REDIS_URL", "must be a redis:// or rediss:// URL when CACHE_BACKEND is redis, got %q", c.RedisURL)
+
+	positive := func(d time.Duration, name string) {
+		check(d > 0, name, "must be positive, got %s", d)
+	}
+	positive(c.NotifyInterval, "NOTIFY_INTERVAL")
+	positive(c.CacheRefreshInterval, "CACHE_REFRESH_INTERVAL")
+	positive(c.DigestCheckInterval, "DIGEST_CHECK_INTERVAL")
+	positive(c.CacheTTL, "CACHE_TTL")
+	check(c.PriceHistoryRetention >= entity.DigestWeekly.Duration(), "PRICE_HISTORY_RETENTION",
+		"must cover the weekly digest window (%s), got %s", entity.DigestWeekly.Duration(), c.PriceHistoryRetention)
+	positive(c.PriceRetryInitialDelay, "PRICE_RETRY_INITIAL_DELAY")
+	positive(c.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+	positive(c.LeaderCheckInterval, "LEADER_CHECK_INTERVAL")
+
+	check(c.PriceRetryMaxDelay >= c.PriceRetryInitialDelay, "PRICE_RETRY_MAX_DELAY",
+		"must not be less than PRICE_RETRY_INITIAL_DELAY (%s), got %s", c.PriceRetryInitialDelay, c.PriceRetryMaxDelay)
+	check(c.PriceFetchRetries >= 1, "PRICE_FETCH_RETRIES", "must be at least 1, got %d", c.PriceFetchRetries)
+
+	check(c.CacheMaxStale >= 0, "CACHE_MAX_STALE", "must not be negative, got %s", c.CacheMaxStale)
+	check(c.DBMaxOpenConns >= 0, "DB_MAX_OPEN_CONNS", "must not be negative, got %d", c.DBMaxOpenConns)
+	check(c.DBMaxIdleConns >= 0, "DB_MAX_IDLE_CONNS", "must not be negative, got %d", c.DBMaxIdleConns)
+	check(c.UpdateWorkers >= 1, "UPDATE_WORKERS", "must be at least 1, got %d", c.UpdateWorkers)
+	check(c.UpdateQueueSize >= 1, "UPDATE_QUEUE_SIZE", "must be at least 1, got %d", c.UpdateQueueSize)
+	check(c.UpdateQueueWait >= 0, "UPDATE_QUEUE_WAIT", "must not be negative, got %s", c.UpdateQueueWait)
+	check(oneOf(c.UpdateOverflow, "reject", "drop"), "UPDATE_OVERFLOW", "must be reject or drop, got %q", c.UpdateOverflow)
+	_, err = entity.ParseRateLimit(c.RateLimitChat)
+	check(err == nil, "RATE_LIMIT_CHAT", "%v", err)
+	_, err = entity.ParseRateLimit(c.RateLimitGlobal)
+	check(err == nil, "RATE_LIMIT_GLOBAL", "%v", err)
+	_, err = entity.ParseCommandRateLimits(c.RateLimitCommands)
+	check(err == nil, "RATE_LIMIT_COMMANDS", "%v", err)
+	check(c.AbuseThreshold >= 0, "ABUSE_THRESHOLD", "must not be negative, got %d", c.AbuseThreshold)
+	if c.AbuseThreshold > 0 {
+		positive(c.AbuseWindow, "ABUSE_WINDOW")
+		positive(c.AbuseBanDuration, "ABUSE_BAN_DURATION")
+	}
+	check(c.DBConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME", "must not be negative, got %s", c.DBConnMaxLifetime)
+
+	return errors.Join(errs...)
+}
+
+func isURL(raw string, schemes ...string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.Host != "" && slices.Contains(schemes, u.Scheme)
+}
+
+func oneOf(value string, allowed ...string) bool {
+	return slices.Contains(allowed, value)
+}
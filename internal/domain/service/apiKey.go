@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"tgBotFinal/internal/entity"
+)
+
+const apiKeyPrefix = "tgb_"
+
+// lastUsedPrecision bounds how stale an API key's last use may be; a key
+// used more often is written to the database at most once per period.
+const lastUsedPrecision = time.Minute
+
+type APIKeyService struct {
+	repo   APIKeyRepository
+	logger *slog.Logger
+}
+
+func NewAPIKeyService(repo APIKeyRepository, logger *slog.Logger) *APIKeyService {
+	return &APIKeyService{
+		repo:   repo,
+		logger: logger.With(slog.String("component", "APIKeyService")),
+	}
+}
+
+// Create generates a new key and stores only its hash. The plaintext key is
+// returned once and cannot be recovered afterwards.
+func (s *APIKeyService) Create(ctx context.Context, name string, scopes []entity.Scope) (string, *entity.APIKey, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", nil, fmt.Errorf("api key name is required")
+	}
+
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("at least one scope is required")
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(entity.KnownScopes, scope) {
+			return "", nil, fmt.Errorf("unknown scope: %q", scope)
+		}
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("generate api key: %w", err)
+	}
+
+	plain := apiKeyPrefix + hex.EncodeToString(secret)
+	key := &entity.APIKey{
+		Name:   name,
+		Prefix: plain[:len(apiKeyPrefix)+8],
+		Scopes: scopes,
+	}
+
+	if err := s.repo.Create(ctx, key, HashAPIKey(plain)); err != nil {
+		return "", nil, fmt.Errorf("store api key: %w", err)
+	}
+
+	s.logger.Info("api key created", "id", key.ID, "name", key.Name, "scopes", key.Scopes)
+	return plain, key, nil
+}
+
+// Authenticate resolves a plaintext key to a non-revoked API key.
+func (s *APIKeyService) Authenticate(ctx context.Context, plain string) (*entity.APIKey, error) {
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetByHash(ctx, HashAPIKey(plain))
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lookup api key: %w", err)
+	}
+
+	if key.Revoked() {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedPrecision {
+		if err := s.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			s.logger.Warn("failed to update api key last use", "id", key.ID, "error", err)
+		}
+	}
+
+	return key, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, id int64) error {
+	if err := s.repo.Revoke(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("api key revoked", "id", id)
+	return nil
+}
+
+func (s *APIKeyService) List(ctx context.Context) ([]*entity.APIKey, error) {
+	return s.repo.List(ctx)
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 of a plaintext key. Keys carry
+// 192 bits of entropy, so a fast hash is sufficient.
+func HashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseScopes splits a comma-separated scope list.
+func ParseScopes(s string) []entity.Scope {
+	var scopes []entity.Scope
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			scopes = append(scopes, entity.Scope(part))
+		}
+	}
+	return scopes
+}
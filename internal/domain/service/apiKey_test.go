@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"tgBotFinal/internal/entity"
+	"time"
+)
+
+type fakeAPIKeyRepo struct {
+	keys    map[string]*entity.APIKey
+	nextID  int64
+	touches int
+}
+
+func newFakeAPIKeyRepo() *fakeAPIKeyRepo {
+	return &fakeAPIKeyRepo{keys: make(map[string]*entity.APIKey)}
+}
+
+func (f *fakeAPIKeyRepo) Create(ctx context.Context, key *entity.APIKey, hash string) error {
+	f.nextID++
+	key.ID = f.nextID
+	f.keys[hash] = key
+	return nil
+}
+
+func (f *fakeAPIKeyRepo) GetByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	key, ok := f.keys[hash]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (f *fakeAPIKeyRepo) List(ctx context.Context) ([]*entity.APIKey, error) {
+	var keys []*entity.APIKey
+	for _, key := range f.keys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (f *fakeAPIKeyRepo) Revoke(ctx context.Context, id int64) error {
+	for _, key := range f.keys {
+		if key.ID == id {
+			now := time.Now()
+			key.RevokedAt = &now
+			return nil
+		}
+	}
+	return ErrAPIKeyNotFound
+}
+
+func (f *fakeAPIKeyRepo) TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error {
+	f.touches++
+	for _, key := range f.keys {
+		if key.ID == id {
+			key.LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	repo := newFakeAPIKeyRepo()
+	keys := NewAPIKeyService(repo, slog.Default())
+	ctx := context.Background()
+
+	plain, key, err := keys.Create(ctx, "grafana", []entity.Scope{entity.ScopeReadPrices})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if !strings.HasPrefix(plain, apiKeyPrefix) || !strings.HasPrefix(plain, key.Prefix) {
+		t.Errorf("Key %q should start with prefix %q", plain, key.Prefix)
+	}
+
+	if _, ok := repo.keys[plain]; ok {
+		t.Errorf("Plaintext key must not be stored")
+	}
+
+	got, err := keys.Authenticate(ctx, plain)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if !got.HasScope(entity.ScopeReadPrices) || got.HasScope(entity.ScopeAdminUsers) {
+		t.Errorf("Scopes = %v", got.Scopes)
+	}
+
+	if _, err := keys.Authenticate(ctx, plain); err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if repo.touches != 1 {
+		t.Errorf("Last use written %d times, want once within %s", repo.touches, lastUsedPrecision)
+	}
+
+	if _, err := keys.Authenticate(ctx, plain+"x"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Authenticate(wrong) error = %v, want %v", err, ErrInvalidAPIKey)
+	}
+
+	if err := keys.Revoke(ctx, key.ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if _, err := keys.Authenticate(ctx, plain); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Authenticate(revoked) error = %v, want %v", err, ErrInvalidAPIKey)
+	}
+}
+
+func TestAPIKeyService_CreateValidation(t *testing.T) {
+	keys := NewAPIKeyService(newFakeAPIKeyRepo(), slog.Default())
+	ctx := context.Background()
+
+	if _, _, err := keys.Create(ctx, "", []entity.Scope{entity.ScopeReadPrices}); err == nil {
+		t.Errorf("Expected error for empty name")
+	}
+	if _, _, err := keys.Create(ctx, "ops", nil); err == nil {
+		t.Errorf("Expected error for missing scopes")
+	}
+	if _, _, err := keys.Create(ctx, "ops", ParseScopes("read:prices, write:everything")); err == nil {
+		t.Errorf("Expected error for unknown scope")
+	}
+}
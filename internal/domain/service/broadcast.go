@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"tgBotFinal/internal/entity"
+)
+
+// maxBroadcastLength is Telegram's limit for a single text message.
+const maxBroadcastLength = 4096
+
+const (
+	// broadcastRetention is how long finished broadcasts can still be
+	// looked up.
+	broadcastRetention = 24 * time.Hour
+	// draftRetention is how long a draft waits for confirmation before it
+	// is considered abandoned and discarded.
+	draftRetention = time.Hour
+)
+
+type broadcastRun struct {
+	state  entity.Broadcast
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// BroadcastService sends announcements to every active subscriber. A
+// broadcast is first prepared as a draft (a dry run that only counts
+// recipients) and is sent only after an explicit confirmation. Broadcasts
+// are kept in memory and are lost on restart; finished ones and abandoned
+// drafts are dropped after broadcastRetention and draftRetention.
+type BroadcastService struct {
+	users        UserRepository
+	notification Notification
+	logger       *slog.Logger
+
+	mu         sync.Mutex
+	broadcasts map[string]*broadcastRun
+	closed     bool
+}
+
+func NewBroadcastService(users UserRepository, notification Notification, logger *slog.Logger) *BroadcastService {
+	return &BroadcastService{
+		users:        users,
+		notification: notification,
+		logger:       logger.With(slog.String("component", "BroadcastService")),
+		broadcasts:   make(map[string]*broadcastRun),
+	}
+}
+
+// Prepare creates a draft broadcast and reports how many users it would reach.
+func (s *BroadcastService) Prepare(ctx context.Context, text string, createdBy int64) (*entity.Broadcast, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("broadcast text is empty")
+	}
+	if utf8.RuneCountInString(text) > maxBroadcastLength {
+		return nil, fmt.Errorf("broadcast text exceeds %d characters", maxBroadcastLength)
+	}
+
+	active := true
+	recipients, err := s.users.Count(ctx, entity.UserFilter{Active: &active})
+	if err != nil {
+		return nil, fmt.Errorf("count recipients: %w", err)
+	}
+
+	id, err := newBroadcastID()
+	if err != nil {
+		return nil, err
+	}
+
+	run := &broadcastRun{
+		state: entity.Broadcast{
+			ID:         id,
+			Text:       text,
+			Status:     entity.BroadcastDraft,
+			CreatedBy:  createdBy,
+			Recipients: recipients,
+			CreatedAt:  time.Now(),
+		},
+		done: make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	s.prune(run.state.CreatedAt)
+	s.broadcasts[id] = run
+	s.mu.Unlock()
+
+	s.logger.Info("broadcast prepared", "id", id, "created_by", createdBy, "recipients", recipients)
+	return s.snapshot(run), nil
+}
+
+// Confirm starts sending a draft in the background. onProgress, if set, is
+// called periodically and once when the broadcast finishes.
+func (s *BroadcastService) Confirm(ctx context.Context, id string, onProgress func(entity.Broadcast)) (*entity.Broadcast, error) {
+	s.mu.Lock()
+	run, ok := s.broadcasts[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, ErrBroadcastNotFound
+	}
+	if run.state.Status != entity.BroadcastDraft {
+		s.mu.Unlock()
+		return nil, ErrBroadcastState
+	}
+	if s.closed {
+		s.mu.Unlock()
+		return nil, ErrShuttingDown
+	}
+
+	// The broadcast outlives the request or command that confirmed it.
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	now := time.Now()
+	run.cancel = cancel
+	run.state.Status = entity.BroadcastRunning
+	run.state.StartedAt = &now
+	s.mu.Unlock()
+
+	s.logger.Info("broadcast started", "id", id)
+
+	go s.run(runCtx, run, onProgress)
+
+	return s.snapshot(run), nil
+}
+
+func (s *BroadcastService) run(ctx context.Context, run *broadcastRun, onProgress func(entity.Broadcast)) {
+	defer close(run.done)
+	defer run.cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("panic in broadcast", "id", run.state.ID, "recover", r)
+		}
+	}()
+
+	report := func() {
+		if onProgress != nil {
+			onProgress(*s.snapshot(run))
+		}
+	}
+
+	recipients, err := s.users.GetAllActive(ctx)
+	if err != nil {
+		s.logger.Error("failed to load broadcast recipients", "id", run.state.ID, "error", err)
+
+		s.mu.Lock()
+		finished := time.Now()
+		run.state.Status = entity.BroadcastFailed
+		run.state.FinishedAt = &finished
+		s.mu.Unlock()
+
+		report()
+		return
+	}
+
+	s.mu.Lock()
+	run.state.Recipients = len(recipients)
+	step := max(1, len(recipients)/10)
+	s.mu.Unlock()
+
+	_, err = fanOut(ctx, recipients,
+		func(ctx context.Context, chatID int64) error {
+			return s.notification.SendInfoMessage(ctx, chatID, run.state.Text)
+		},
+		func(chatID int64, err error) {
+			s.mu.Lock()
+			switch {
+			case err == nil:
+				run.state.Sent++
+			case isBlocked(err):
+				run.state.Blocked++
+			default:
+				run.state.Failed++
+			}
+			processed := run.state.Processed()
+			s.mu.Unlock()
+
+			if isBlocked(err) {
+				if err := s.users.SetActive(ctx, chatID, false); err != nil {
+					s.logger.Warn("failed to deactivate blocked chat", "chat_id", chatID, "error", err)
+				}
+			} else if err != nil {
+				s.logger.Warn("broadcast send failed", "id", run.state.ID, "chat_id", chatID, "error", err)
+			}
+
+			if processed%step == 0 && processed < len(recipients) {
+				report()
+			}
+		})
+
+	s.mu.Lock()
+	finished := time.Now()
+	run.state.FinishedAt = &finished
+	if err != nil {
+		run.state.Status = entity.BroadcastCancelled
+	} else {
+		run.state.Status = entity.BroadcastDone
+	}
+	state := run.state
+	s.mu.Unlock()
+
+	s.logger.Info("broadcast finished",
+		"id", state.ID,
+		"status", state.Status,
+		"sent", state.Sent,
+		"failed", state.Failed,
+		"blocked", state.Blocked)
+
+	report()
+}
+
+// Cancel discards a draft or stops a running broadcast. Messages already
+// sent are not recalled.
+func (s *BroadcastService) Cancel(id string) (*entity.Broadcast, error) {
+	s.mu.Lock()
+	run, ok := s.broadcasts[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, ErrBroadcastNotFound
+	}
+
+	switch run.state.Status {
+	case entity.BroadcastDraft:
+		now := time.Now()
+		run.state.Status = entity.BroadcastCancelled
+		run.state.FinishedAt = &now
+		close(run.done)
+		s.mu.Unlock()
+	case entity.BroadcastRunning:
+		cancel := run.cancel
+		s.mu.Unlock()
+		cancel()
+		<-run.done
+	default:
+		s.mu.Unlock()
+		return nil, ErrBroadcastState
+	}
+
+	s.logger.Info("broadcast cancelled", "id", id)
+	return s.snapshot(run), nil
+}
+
+// Shutdown refuses new broadcasts and waits for the running ones to finish.
+// Broadcasts still running when ctx is done are cancelled, and how far each
+// got is logged so the remaining recipients can be reached after a restart.
+func (s *BroadcastService) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	var running []*broadcastRun
+	for _, run := range s.broadcasts {
+		if run.state.Status == entity.BroadcastRunning {
+			running = append(running, run)
+		}
+	}
+	s.mu.Unlock()
+
+	var interrupted int
+	for _, run := range running {
+		select {
+		case <-run.done:
+			continue
+		case <-ctx.Done():
+		}
+
+		s.mu.Lock()
+		cancel := run.cancel
+		s.mu.Unlock()
+		cancel()
+		<-run.done
+
+		state := s.snapshot(run)
+		s.logger.Warn("broadcast interrupted by shutdown",
+			"id", state.ID,
+			"sent", state.Sent,
+			"failed", state.Failed,
+			"blocked", state.Blocked,
+			"remaining", state.Recipients-state.Processed())
+		interrupted++
+	}
+
+	if interrupted > 0 {
+		return fmt.Errorf("%d broadcasts interrupted: %w", interrupted, ctx.Err())
+	}
+	return nil
+}
+
+func (s *BroadcastService) Get(id string) (*entity.Broadcast, error) {
+	s.mu.Lock()
+	run, ok := s.broadcasts[id]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, ErrBroadcastNotFound
+	}
+
+	return s.snapshot(run), nil
+}
+
+// Latest returns the most recent draft or running broadcast created by the
+// given admin chat.
+func (s *BroadcastService) Latest(createdBy int64) (*entity.Broadcast, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var latest *broadcastRun
+	for _, run := range s.broadcasts {
+		if run.state.CreatedBy != createdBy {
+			continue
+		}
+		if run.state.Status != entity.BroadcastDraft && run.state.Status != entity.BroadcastRunning {
+			continue
+		}
+		if latest == nil || run.state.CreatedAt.After(latest.state.CreatedAt) {
+			latest = run
+		}
+	}
+
+	if latest == nil {
+		return nil, ErrBroadcastNotFound
+	}
+
+	state := latest.state
+	return &state, nil
+}
+
+// prune forgets the broadcasts finished more than broadcastRetention ago
+// and the drafts older than draftRetention. s.mu must be held.
+func (s *BroadcastService) prune(now time.Time) {
+	for id, run := range s.broadcasts {
+		state := run.state
+		abandoned := state.Status == entity.BroadcastDraft && now.Sub(state.CreatedAt) > draftRetention
+		expired := state.FinishedAt != nil && now.Sub(*state.FinishedAt) > broadcastRetention
+		if abandoned || expired {
+			delete(s.broadcasts, id)
+		}
+	}
+}
+
+func (s *BroadcastService) snapshot(run *broadcastRun) *entity.Broadcast {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := run.state
+	return &state
+}
+
+func isBlocked(err error) bool {
+	return errors.Is(err, ErrChatBlocked)
+}
+
+func newBroadcastID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate broadcast id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,332 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"tgBotFinal/internal/entity"
+	"time"
+)
+
+func newBroadcastTestUsers(n int) *MockUserRepository {
+	repo := NewMockUserRepository()
+
+	users := make([]*entity.User, n)
+	for i := range users {
+		users[i] = &entity.User{ChatID: int64(i + 1), Active: true}
+	}
+
+	repo.CountFunc = func(ctx context.Context, filter entity.UserFilter) (int, error) {
+		return n, nil
+	}
+	repo.GetAllActiveFunc = func(ctx context.Context) ([]*entity.User, error) {
+		return users, nil
+	}
+	return repo
+}
+
+func waitBroadcast(t *testing.T, s *BroadcastService, id string) {
+	t.Helper()
+
+	s.mu.Lock()
+	done := s.broadcasts[id].done
+	s.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("broadcast %s did not finish", id)
+	}
+}
+
+func TestBroadcastService_ConfirmSendsToAll(t *testing.T) {
+	users := newBroadcastTestUsers(10)
+	notifier := NewMockNotification()
+
+	var deactivated []int64
+	var mu sync.Mutex
+	users.SetActiveFunc = func(ctx context.Context, chatID int64, active bool) error {
+		mu.Lock()
+		deactivated = append(deactivated, chatID)
+		mu.Unlock()
+		return nil
+	}
+
+	notifier.SendInfoMessageFunc = func(ctx context.Context, chatID int64, text string) error {
+		switch chatID {
+		case 3:
+			return fmt.Errorf("send: %w", ErrChatBlocked)
+		case 7:
+			return errors.New("network error")
+		}
+		return nil
+	}
+
+	s := NewBroadcastService(users, notifier, slog.Default())
+	ctx := context.Background()
+
+	draft, err := s.Prepare(ctx, "  Maintenance tonight  ", 99)
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if draft.Status != entity.BroadcastDraft || draft.Recipients != 10 || draft.Text != "Maintenance tonight" {
+		t.Errorf("Draft = %+v", draft)
+	}
+
+	var reports []entity.Broadcast
+	var reportsMu sync.Mutex
+	if _, err := s.Confirm(ctx, draft.ID, func(b entity.Broadcast) {
+		reportsMu.Lock()
+		reports = append(reports, b)
+		reportsMu.Unlock()
+	}); err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+
+	waitBroadcast(t, s, draft.ID)
+
+	final, _ := s.Get(draft.ID)
+	if final.Status != entity.BroadcastDone || final.Sent != 8 || final.Failed != 1 || final.Blocked != 1 {
+		t.Errorf("Final = %+v", final)
+	}
+
+	if len(deactivated) != 1 || deactivated[0] != 3 {
+		t.Errorf("Deactivated = %v, want [3]", deactivated)
+	}
+
+	reportsMu.Lock()
+	defer reportsMu.Unlock()
+	if len(reports) == 0 || reports[len(reports)-1].Status != entity.BroadcastDone {
+		t.Errorf("Last progress report should be final, got %+v", reports)
+	}
+
+	if _, err := s.Confirm(ctx, draft.ID, nil); !errors.Is(err, ErrBroadcastState) {
+		t.Errorf("Second confirm error = %v, want %v", err, ErrBroadcastState)
+	}
+}
+
+func TestBroadcastService_CancelRunning(t *testing.T) {
+	users := newBroadcastTestUsers(100)
+	notifier := NewMockNotification()
+
+	started := make(chan struct{}, 100)
+	notifier.SendInfoMessageFunc = func(ctx context.Context, chatID int64, text string) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	s := NewBroadcastService(users, notifier, slog.Default())
+	ctx := context.Background()
+
+	draft, err := s.Prepare(ctx, "hello", 1)
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if _, err := s.Confirm(ctx, draft.ID, nil); err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+
+	<-started
+
+	cancelled, err := s.Cancel(draft.ID)
+	if err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+
+	if cancelled.Status != entity.BroadcastCancelled {
+		t.Errorf("Status = %v, want %v", cancelled.Status, entity.BroadcastCancelled)
+	}
+	if cancelled.Processed() >= 100 {
+		t.Errorf("Cancelled broadcast should not reach every recipient, processed %d", cancelled.Processed())
+	}
+}
+
+func TestFanOut_CancelMidRun(t *testing.T) {
+	users, _ := newBroadcastTestUsers(50).GetAllActive(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	// Sends ignore ctx and succeed, so only the recipients never started
+	// show the cancellation.
+	res, err := fanOut(ctx, users, func(ctx context.Context, chatID int64) error {
+		if calls.Add(1) == 3 {
+			cancel()
+		}
+		return nil
+	}, nil)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("fanOut error = %v, want %v", err, context.Canceled)
+	}
+	if res.Sent >= len(users) || res.Sent != int(calls.Load()) {
+		t.Errorf("Sent = %d after %d calls, want fewer than %d", res.Sent, calls.Load(), len(users))
+	}
+}
+
+func TestBroadcastService_DraftLifecycle(t *testing.T) {
+	s := NewBroadcastService(newBroadcastTestUsers(1), NewMockNotification(), slog.Default())
+	ctx := context.Background()
+
+	if _, err := s.Prepare(ctx, "   ", 1); err == nil {
+		t.Errorf("Expected error for empty text")
+	}
+
+	draft, err := s.Prepare(ctx, "hello", 5)
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+
+	latest, err := s.Latest(5)
+	if err != nil || latest.ID != draft.ID {
+		t.Errorf("Latest = %v, %v; want %s", latest, err, draft.ID)
+	}
+
+	if _, err := s.Latest(6); !errors.Is(err, ErrBroadcastNotFound) {
+		t.Errorf("Latest for other admin error = %v, want %v", err, ErrBroadcastNotFound)
+	}
+
+	if _, err := s.Cancel(draft.ID); err != nil {
+		t.Fatalf("Cancel draft failed: %v", err)
+	}
+
+	if _, err := s.Confirm(ctx, draft.ID, nil); !errors.Is(err, ErrBroadcastState) {
+		t.Errorf("Confirm cancelled draft error = %v, want %v", err, ErrBroadcastState)
+	}
+}
+
+func TestBroadcastService_ShutdownWaitsForRunning(t *testing.T) {
+	users := newBroadcastTestUsers(20)
+	notifier := NewMockNotification()
+
+	release := make(chan struct{})
+	notifier.SendInfoMessageFunc = func(ctx context.Context, chatID int64, text string) error {
+		<-release
+		return nil
+	}
+
+	s := NewBroadcastService(users, notifier, slog.Default())
+	ctx := context.Background()
+
+	draft, err := s.Prepare(ctx, "hello", 1)
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if _, err := s.Confirm(ctx, draft.ID, nil); err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Shutdown(ctx) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown returned %v before the broadcast finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	b, _ := s.Get(draft.ID)
+	if b.Status != entity.BroadcastDone || b.Sent != 20 {
+		t.Errorf("Broadcast = %+v, want done with 20 sent", b)
+	}
+
+	next, err := s.Prepare(ctx, "again", 1)
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if _, err := s.Confirm(ctx, next.ID, nil); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Confirm after shutdown error = %v, want %v", err, ErrShuttingDown)
+	}
+}
+
+func TestBroadcastService_ShutdownCancelsAtDeadline(t *testing.T) {
+	users := newBroadcastTestUsers(100)
+	notifier := NewMockNotification()
+
+	started := make(chan struct{}, 100)
+	notifier.SendInfoMessageFunc = func(ctx context.Context, chatID int64, text string) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	s := NewBroadcastService(users, notifier, slog.Default())
+
+	draft, err := s.Prepare(context.Background(), "hello", 1)
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if _, err := s.Confirm(context.Background(), draft.ID, nil); err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown error = %v, want deadline exceeded", err)
+	}
+
+	b, _ := s.Get(draft.ID)
+	if b.Status != entity.BroadcastCancelled {
+		t.Errorf("Status = %v, want %v", b.Status, entity.BroadcastCancelled)
+	}
+	if b.Processed() >= 100 {
+		t.Errorf("Interrupted broadcast should not reach every recipient, processed %d", b.Processed())
+	}
+}
+
+func TestBroadcastService_PrunesOldBroadcasts(t *testing.T) {
+	s := NewBroadcastService(newBroadcastTestUsers(1), NewMockNotification(), slog.Default())
+	ctx := context.Background()
+
+	abandoned, err := s.Prepare(ctx, "never confirmed", 1)
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	finished, err := s.Prepare(ctx, "sent yesterday", 1)
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	recent, err := s.Prepare(ctx, "sent just now", 1)
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	for _, b := range []*entity.Broadcast{finished, recent} {
+		if _, err := s.Confirm(ctx, b.ID, nil); err != nil {
+			t.Fatalf("Confirm failed: %v", err)
+		}
+		waitBroadcast(t, s, b.ID)
+	}
+
+	s.mu.Lock()
+	s.broadcasts[abandoned.ID].state.CreatedAt = time.Now().Add(-draftRetention - time.Minute)
+	longAgo := time.Now().Add(-broadcastRetention - time.Minute)
+	s.broadcasts[finished.ID].state.FinishedAt = &longAgo
+	s.mu.Unlock()
+
+	if _, err := s.Prepare(ctx, "next", 1); err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+
+	for _, id := range []string{abandoned.ID, finished.ID} {
+		if _, err := s.Get(id); !errors.Is(err, ErrBroadcastNotFound) {
+			t.Errorf("Get(%s) error = %v, want %v", id, err, ErrBroadcastNotFound)
+		}
+	}
+	if _, err := s.Get(recent.ID); err != nil {
+		t.Errorf("Get(recent) failed: %v", err)
+	}
+}
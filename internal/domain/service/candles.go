@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"tgBotFinal/internal/entity"
+)
+
+// CandleService copies the exchange's candles into storage.
+type CandleService struct {
+	provider KlineProvider
+	repo     CandleRepository
+	logger   *slog.Logger
+}
+
+func NewCandleService(provider KlineProvider, repo CandleRepository, logger *slog.Logger) *CandleService {
+	return &CandleService{
+		provider: provider,
+		repo:     repo,
+		logger:   logger.With(slog.String("component", "CandleService")),
+	}
+}
+
+// candleGap is a range of open times with no stored candle.
+type candleGap struct {
+	from, to time.Time
+}
+
+// Backfill fetches the candles of symbol that are missing from storage
+// between from and now and stores them, so a restart after downtime only
+// asks the exchange for what was missed. The candle still open at now is
+// left out; it is stored by the first backfill after it closes. It returns
+// the number of candles stored.
+func (s *CandleService) Backfill(ctx context.Context, symbol entity.CurrencyName, interval entity.CandleInterval, from, now time.Time) (int, error) {
+	step := interval.Duration()
+	if step == 0 {
+		return 0, fmt.Errorf("unsupported candle interval: %q", interval)
+	}
+
+	from, to := interval.Truncate(from), interval.Truncate(now)
+	if !from.Before(to) {
+		return 0, nil
+	}
+
+	stored, err := s.repo.GetRange(ctx, symbol, interval, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("get stored candles: %w", err)
+	}
+
+	total := 0
+	for _, gap := range candleGaps(stored, from, to, step) {
+		s.logger.Debug("Filling candle gap", "symbol", symbol, "interval", interval, "from", gap.from, "to", gap.to)
+
+		candles, err := s.provider.GetKlines(ctx, symbol, interval, gap.from, gap.to)
+		if err != nil {
+			return total, fmt.Errorf("get klines from %v: %w", gap.from, err)
+		}
+		if len(candles) == 0 {
+			// Before the symbol was listed, for example.
+			continue
+		}
+		if err := s.repo.Upsert(ctx, candles); err != nil {
+			return total, fmt.Errorf("store candles: %w", err)
+		}
+		total += len(candles)
+	}
+
+	s.logger.Info("Backfilled candles", "symbol", symbol, "interval", interval, "stored", total)
+	return total, nil
+}
+
+// candleGaps returns the ranges of [from, to) that no candle opens in.
+// candles must be ordered by open time and aligned to step.
+func candleGaps(candles []*entity.Candle, from, to time.Time, step time.Duration) []candleGap {
+	var gaps []candleGap
+
+	next := from
+	for _, candle := range candles {
+		if candle.OpenTime.After(next) {
+			gaps = append(gaps, candleGap{from: next, to: candle.OpenTime})
+		}
+		next = candle.OpenTime.Add(step)
+	}
+	if next.Before(to) {
+		gaps = append(gaps, candleGap{from: next, to: to})
+	}
+
+	return gaps
+}
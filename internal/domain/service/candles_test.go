@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/entity"
+)
+
+// fakeCandleRepo keeps candles ordered by open time; all tests use one
+// symbol and interval.
+type fakeCandleRepo struct {
+	candles []*entity.Candle
+}
+
+func (f *fakeCandleRepo) Upsert(ctx context.Context, candles []*entity.Candle) error {
+	for _, candle := range candles {
+		i, found := slices.BinarySearchFunc(f.candles, candle.OpenTime, func(c *entity.Candle, t time.Time) int {
+			return c.OpenTime.Compare(t)
+		})
+		if found {
+			f.candles[i] = candle
+		} else {
+			f.candles = slices.Insert(f.candles, i, candle)
+		}
+	}
+	return nil
+}
+
+func (f *fakeCandleRepo) GetRange(ctx context.Context, symbol entity.CurrencyName, interval entity.CandleInterval, from, to time.Time) ([]*entity.Candle, error) {
+	var candles []*entity.Candle
+	for _, candle := range f.candles {
+		if !candle.OpenTime.Before(from) && candle.OpenTime.Before(to) {
+			candles = append(candles, candle)
+		}
+	}
+	return candles, nil
+}
+
+// fakeKlines serves a minute candle for every open time and records the
+// requested ranges.
+type fakeKlines struct {
+	requests [][2]time.Time
+	err      error
+}
+
+func (f *fakeKlines) GetKlines(ctx context.Context, symbol entity.CurrencyName, interval entity.CandleInterval, from, to time.Time) ([]*entity.Candle, error) {
+	f.requests = append(f.requests, [2]time.Time{from, to})
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var candles []*entity.Candle
+	for at := from; at.Before(to); at = at.Add(time.Minute) {
+		candles = append(candles, &entity.Candle{Symbol: symbol, Interval: interval, OpenTime: at, Close: "1"})
+	}
+	return candles, nil
+}
+
+func TestCandleService_Backfill(t *testing.T) {
+	start := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minute int) time.Time { return start.Add(time.Duration(minute) * time.Minute) }
+
+	repo := &fakeCandleRepo{}
+	for _, minute := range []int{2, 3, 6} {
+		repo.Upsert(context.Background(), []*entity.Candle{{Symbol: entity.BTC, Interval: entity.Interval1m, OpenTime: at(minute)}})
+	}
+	klines := &fakeKlines{}
+	svc := NewCandleService(klines, repo, slog.Default())
+
+	// The candle opening at minute 10 is still open.
+	now := at(10).Add(30 * time.Second)
+	stored, err := svc.Backfill(context.Background(), entity.BTC, entity.Interval1m, start.Add(10*time.Second), now)
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+
+	want := [][2]time.Time{{at(0), at(2)}, {at(4), at(6)}, {at(7), at(10)}}
+	if !slices.Equal(klines.requests, want) {
+		t.Errorf("Requested %v, want the gaps %v", klines.requests, want)
+	}
+	if stored != 7 {
+		t.Errorf("Stored %d candles, want 7", stored)
+	}
+	if len(repo.candles) != 10 {
+		t.Errorf("Repository has %d candles, want 10", len(repo.candles))
+	}
+
+	klines.requests = nil
+	if _, err := svc.Backfill(context.Background(), entity.BTC, entity.Interval1m, start, now); err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	if len(klines.requests) != 0 {
+		t.Errorf("Requested %v with no gaps, want nothing", klines.requests)
+	}
+}
+
+func TestCandleService_Backfill_Error(t *testing.T) {
+	start := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	klines := &fakeKlines{err: errors.New("exchange down")}
+	svc := NewCandleService(klines, &fakeCandleRepo{}, slog.Default())
+
+	_, err := svc.Backfill(context.Background(), entity.BTC, entity.Interval1m, start, start.Add(time.Hour))
+	if !errors.Is(err, klines.err) {
+		t.Errorf("Backfill error = %v, want %v", err, klines.err)
+	}
+
+	if _, err := svc.Backfill(context.Background(), entity.BTC, "2h", start, start.Add(time.Hour)); err == nil {
+		t.Error("Backfill with an unsupported interval succeeded")
+	}
+}
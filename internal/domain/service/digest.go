@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	"tgBotFinal/internal/entity"
+)
+
+const (
+	// digestHour is the local hour at which digests are delivered.
+	digestHour = 9
+	// digestWindow bounds how late a digest may still be sent for a slot.
+	digestWindow = time.Hour
+	// digestMovers is the number of symbols listed as biggest movers.
+	digestMovers = 3
+	// historyPruneInterval is how often prices older than the retention
+	// are deleted.
+	historyPruneInterval = time.Hour
+)
+
+type DigestBuilder struct {
+	history PriceHistoryRepository
+	logger  *slog.Logger
+}
+
+func NewDigestBuilder(history PriceHistoryRepository, logger *slog.Logger) *DigestBuilder {
+	return &DigestBuilder{
+		history: history,
+		logger:  logger.With(slog.String("component", "DigestBuilder")),
+	}
+}
+
+// Build aggregates stored price history for every tracked symbol over the
+// period ending at now.
+//
+// TODO: list the user's alert hits for the period once price alerts are
+// recorded. The bot has no alerts yet, so digests leave that section out.
+func (b *DigestBuilder) Build(ctx context.Context, period entity.DigestPeriod, now time.Time) (*entity.Digest, error) {
+	window := period.Duration()
+	if window == 0 {
+		return nil, fmt.Errorf("unsupported digest period: %q", period)
+	}
+
+	digest := &entity.Digest{
+		Period: period,
+		From:   now.Add(-window),
+		To:     now,
+	}
+
+	for _, symbol := range trackedSymbols() {
+		ohlc, err := b.history.GetOHLC(ctx, symbol, digest.From, digest.To)
+		if errors.Is(err, ErrNoPriceHistory) {
+			b.logger.Debug("no history for symbol", "symbol", symbol, "period", period)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get OHLC for %s: %w", symbol, err)
+		}
+
+		if ohlc.Open != 0 {
+			ohlc.ChangePct = (ohlc.Close - ohlc.Open) / ohlc.Open * 100
+		}
+		digest.Symbols = append(digest.Symbols, ohlc)
+	}
+
+	movers := make([]*entity.OHLC, len(digest.Symbols))
+	copy(movers, digest.Symbols)
+	sort.SliceStable(movers, func(i, j int) bool {
+		return math.Abs(movers[i].ChangePct) > math.Abs(movers[j].ChangePct)
+	})
+	if len(movers) > digestMovers {
+		movers = movers[:digestMovers]
+	}
+	digest.Movers = movers
+
+	return digest, nil
+}
+
+// trackedSymbols returns entity.TokenList in a stable order.
+func trackedSymbols() []entity.CurrencyName {
+	keys := make([]int, 0, len(entity.TokenList))
+	for k := range entity.TokenList {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	symbols := make([]entity.CurrencyName, 0, len(keys))
+	for _, k := range keys {
+		symbols = append(symbols, entity.TokenList[k])
+	}
+	return symbols
+}
+
+// digestSlot returns the most recent scheduled delivery time not after now,
+// evaluated in the user's local time zone.
+func digestSlot(period entity.DigestPeriod, now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	slot := time.Date(local.Year(), local.Month(), local.Day(), digestHour, 0, 0, 0, loc)
+
+	if period == entity.DigestWeekly {
+		offset := (int(slot.Weekday()) + 6) % 7 // days since Monday
+		slot = slot.AddDate(0, 0, -offset)
+	}
+
+	if slot.After(local) {
+		if period == entity.DigestWeekly {
+			slot = slot.AddDate(0, 0, -7)
+		} else {
+			slot = slot.AddDate(0, 0, -1)
+		}
+	}
+
+	return slot
+}
+
+// digestDue reports whether the user should receive a digest at now.
+func digestDue(user *entity.User, now time.Time) bool {
+	if user.Digest.Duration() == 0 {
+		return false
+	}
+
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil || user.Timezone == "" {
+		loc = time.UTC
+	}
+
+	slot := digestSlot(user.Digest, now, loc)
+	if now.Sub(slot) > digestWindow {
+		return false
+	}
+
+	return user.LastDigestAt == nil || user.LastDigestAt.Before(slot)
+}
+
+func (s *CryptService) runDigestWorker(ctx context.Context) error {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("panic in digest worker", "recover", r)
+		}
+	}()
+
+	pick := func(w WorkerIntervals) time.Duration { return w.Digest }
+	interval, changed := s.workerInterval(pick)
+	ticker := s.clock.NewTicker(interval)
+	defer ticker.Stop()
+	s.logger.Debug("Run Digest Worker", "interval", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Digest worker stopped")
+			return nil
+		case <-changed:
+			interval, changed = s.workerInterval(pick)
+			ticker.Reset(interval)
+			s.logger.Info("Digest check interval changed", "interval", interval)
+		case <-ticker.C():
+			start, now := time.Now(), s.clock.Now()
+			err := s.runRound(ctx, func(ctx context.Context) error {
+				return s.sendDueDigests(ctx, now)
+			})
+			if err != nil {
+				s.logger.Error("failed to send digests", "error", err)
+			}
+			s.observer.WorkerTick("digest", time.Since(start))
+		}
+	}
+}
+
+func (s *CryptService) runHistoryPruneWorker(ctx context.Context) error {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("panic in history prune worker", "recover", r)
+		}
+	}()
+
+	ticker := s.clock.NewTicker(historyPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("History prune worker stopped")
+			return nil
+		case <-ticker.C():
+			start := time.Now()
+			if err := s.pruneHistory(ctx); err != nil {
+				s.logger.Error("failed to prune price history", "error", err)
+			}
+			s.observer.WorkerTick("history_prune", time.Since(start))
+		}
+	}
+}
+
+// pruneHistory deletes the prices recorded before the retention period.
+func (s *CryptService) pruneHistory(ctx context.Context) error {
+	if s.PriceHistory == nil {
+		return nil
+	}
+
+	retention, _ := s.workerInterval(func(w WorkerIntervals) time.Duration { return w.HistoryRetention })
+	pruned, err := s.PriceHistory.Prune(ctx, s.clock.Now().Add(-retention))
+	if err != nil {
+		return err
+	}
+
+	s.logger.Debug("Pruned price history", "pruned", pruned, "retention", retention)
+	return nil
+}
+
+func (s *CryptService) sendDueDigests(ctx context.Context, now time.Time) error {
+	users, err := s.UserRepo.GetDigestSubscribers(ctx)
+	if err != nil {
+		return fmt.Errorf("get digest subscribers: %w", err)
+	}
+
+	var due []*entity.User
+	for _, user := range users {
+		if digestDue(user, now) {
+			due = append(due, user)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	digests := make(map[entity.DigestPeriod]*entity.Digest)
+	period := make(map[int64]entity.DigestPeriod, len(due))
+	for _, user := range due {
+		period[user.ChatID] = user.Digest
+		if _, ok := digests[user.Digest]; ok {
+			continue
+		}
+		digest, err := s.DigestBuilder.Build(ctx, user.Digest, now)
+		if err != nil {
+			return fmt.Errorf("build %s digest: %w", user.Digest, err)
+		}
+		digests[user.Digest] = digest
+	}
+
+	_, err = fanOut(ctx, due,
+		func(ctx context.Context, chatID int64) error {
+			return s.Notification.SendDigest(ctx, chatID, digests[period[chatID]])
+		},
+		func(chatID int64, err error) {
+			if err != nil {
+				s.logger.Warn("send digest failed", "chat_id", chatID, "error", err)
+				s.deactivateIfBlocked(ctx, chatID, err)
+				return
+			}
+			if err := s.UserRepo.MarkDigestSent(ctx, chatID, now); err != nil {
+				s.logger.Warn("mark digest sent failed", "chat_id", chatID, "error", err)
+			}
+		})
+	return err
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/entity"
+	"time"
+)
+
+func TestDigestBuilder_Build(t *testing.T) {
+	history := NewMockPriceHistoryRepository()
+	history.GetOHLCFunc = func(ctx context.Context, symbol entity.CurrencyName, from, to time.Time) (*entity.OHLC, error) {
+		switch symbol {
+		case entity.BTC:
+			return &entity.OHLC{Symbol: symbol, Open: 100, High: 110, Low: 95, Close: 105, Samples: 10}, nil
+		case entity.ETH:
+			return &entity.OHLC{Symbol: symbol, Open: 100, High: 100, Low: 80, Close: 80, Samples: 10}, nil
+		}
+		return nil, ErrNoPriceHistory
+	}
+
+	builder := NewDigestBuilder(history, slog.Default())
+	now := time.Date(2025, 11, 10, 9, 0, 0, 0, time.UTC)
+
+	digest, err := builder.Build(context.Background(), entity.DigestDaily, now)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if !digest.From.Equal(now.Add(-24 * time.Hour)) {
+		t.Errorf("From = %v, want %v", digest.From, now.Add(-24*time.Hour))
+	}
+
+	if len(digest.Symbols) != 2 {
+		t.Fatalf("Symbols = %d, want 2", len(digest.Symbols))
+	}
+
+	if digest.Symbols[0].ChangePct != 5 {
+		t.Errorf("BTC change = %v, want 5", digest.Symbols[0].ChangePct)
+	}
+
+	if digest.Movers[0].Symbol != entity.ETH {
+		t.Errorf("Top mover = %v, want %v", digest.Movers[0].Symbol, entity.ETH)
+	}
+}
+
+func TestDigestBuilder_BuildOff(t *testing.T) {
+	builder := NewDigestBuilder(NewMockPriceHistoryRepository(), slog.Default())
+
+	if _, err := builder.Build(context.Background(), entity.DigestOff, time.Now()); err == nil {
+		t.Errorf("Expected error for disabled digest period")
+	}
+}
+
+func TestDigestDue(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skipf("tzdata not available: %v", err)
+	}
+
+	// Monday 06:30 UTC == 09:30 in Moscow.
+	now := time.Date(2025, 11, 10, 6, 30, 0, 0, time.UTC)
+	yesterday := now.Add(-24 * time.Hour)
+	justSent := now.Add(-10 * time.Minute)
+	tuesday := now.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name string
+		user *entity.User
+		at   time.Time
+		want bool
+	}{
+		{"Daily in window", &entity.User{Digest: entity.DigestDaily, Timezone: moscow.String()}, now, true},
+		{"Daily already sent", &entity.User{Digest: entity.DigestDaily, Timezone: moscow.String(), LastDigestAt: &justSent}, now, false},
+		{"Daily sent yesterday", &entity.User{Digest: entity.DigestDaily, Timezone: moscow.String(), LastDigestAt: &yesterday}, now, true},
+		{"Daily outside window", &entity.User{Digest: entity.DigestDaily, Timezone: "UTC"}, now, false},
+		{"Weekly on Monday", &entity.User{Digest: entity.DigestWeekly, Timezone: moscow.String()}, now, true},
+		{"Weekly not Monday", &entity.User{Digest: entity.DigestWeekly, Timezone: moscow.String()}, tuesday, false},
+		{"Off", &entity.User{Digest: entity.DigestOff, Timezone: moscow.String()}, now, false},
+		{"Unknown timezone falls back to UTC", &entity.User{Digest: entity.DigestDaily, Timezone: "Mars/Olympus"}, now, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := digestDue(tt.user, tt.at); got != tt.want {
+				t.Errorf("digestDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCryptService_SendDueDigests(t *testing.T) {
+	mockNotifier := NewMockNotification()
+	mockUserRepo := NewMockUserRepository()
+	history := NewMockPriceHistoryRepository()
+
+	now := time.Date(2025, 11, 10, 9, 15, 0, 0, time.UTC)
+
+	mockUserRepo.GetDigestSubscribersFunc = func(ctx context.Context) ([]*entity.User, error) {
+		return []*entity.User{
+			{ChatID: 1, Active: true, Digest: entity.DigestDaily, Timezone: "UTC"},
+			{ChatID: 2, Active: true, Digest: entity.DigestDaily, Timezone: "UTC", LastDigestAt: &now},
+		}, nil
+	}
+
+	var marked []int64
+	mockUserRepo.MarkDigestSentFunc = func(ctx context.Context, chatID int64, sentAt time.Time) error {
+		marked = append(marked, chatID)
+		return nil
+	}
+
+	var sent []int64
+	mockNotifier.SendDigestFunc = func(ctx context.Context, chatID int64, digest *entity.Digest) error {
+		sent = append(sent, chatID)
+		return nil
+	}
+
+	service := &CryptService{
+		UserRepo:      mockUserRepo,
+		Notification:  mockNotifier,
+		DigestBuilder: NewDigestBuilder(history, slog.Default()),
+		logger:        slog.Default(),
+	}
+
+	if err := service.sendDueDigests(context.Background(), now); err != nil {
+		t.Fatalf("sendDueDigests failed: %v", err)
+	}
+
+	if len(sent) != 1 || sent[0] != 1 {
+		t.Errorf("Digest sent to %v, want [1]", sent)
+	}
+
+	if len(marked) != 1 || marked[0] != 1 {
+		t.Errorf("Digest marked for %v, want [1]", marked)
+	}
+}
+
+func TestCryptService_PruneHistory(t *testing.T) {
+	var cutoff time.Time
+	history := NewMockPriceHistoryRepository()
+	history.PruneFunc = func(ctx context.Context, before time.Time) (int64, error) {
+		cutoff = before
+		return 3, nil
+	}
+
+	now := time.Date(2025, 11, 10, 9, 0, 0, 0, time.UTC)
+	service := NewCryptService(NewMockCurrencyRepository(), NewMockUserRepository(), history, NewMockCryptoClient(),
+		NewMockNotification(), nil, "", slog.Default(), "8080",
+		WorkerIntervals{}, RetryPolicy{}, nil, nil, clock.NewFake(now), nil)
+
+	if err := service.pruneHistory(context.Background()); err != nil {
+		t.Fatalf("pruneHistory failed: %v", err)
+	}
+	if want := now.Add(-8 * 24 * time.Hour); !cutoff.Equal(want) {
+		t.Errorf("Pruned before %v, want %v by default", cutoff, want)
+	}
+
+	service.UpdateIntervals(WorkerIntervals{HistoryRetention: 30 * 24 * time.Hour})
+	if err := service.pruneHistory(context.Background()); err != nil {
+		t.Fatalf("pruneHistory failed: %v", err)
+	}
+	if want := now.Add(-30 * 24 * time.Hour); !cutoff.Equal(want) {
+		t.Errorf("Pruned before %v, want %v after the retention changed", cutoff, want)
+	}
+}
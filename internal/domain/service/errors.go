@@ -0,0 +1,24 @@
+package service
+
+import "errors"
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrCurrencyNotFound = errors.New("currency not found")
+	ErrAPIKeyNotFound   = errors.New("api key not found")
+	ErrInvalidAPIKey    = errors.New("invalid api key")
+
+	// ErrNoPriceHistory is returned when no price was recorded in a period.
+	ErrNoPriceHistory = errors.New("no price history")
+
+	// ErrChatBlocked is returned by Notification when the recipient blocked
+	// the bot or the chat no longer exists.
+	ErrChatBlocked = errors.New("chat blocked the bot")
+
+	ErrBroadcastNotFound = errors.New("broadcast not found")
+	ErrBroadcastState    = errors.New("broadcast is not in a valid state for this action")
+
+	// ErrShuttingDown is returned for work refused because the application
+	// is stopping.
+	ErrShuttingDown = errors.New("shutting down")
+)
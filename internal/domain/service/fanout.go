@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"tgBotFinal/internal/entity"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// notifyConcurrency bounds the number of Telegram sends in flight.
+const notifyConcurrency = 5
+
+type fanOutResult struct {
+	Sent    int
+	Failed  int
+	Blocked int
+}
+
+// fanOut calls send for every recipient with bounded concurrency and reports
+// each outcome to onResult, which must be safe for concurrent use. Recipients
+// not yet started when ctx is cancelled are skipped and ctx.Err() is returned.
+func fanOut(
+	ctx context.Context,
+	recipients []*entity.User,
+	send func(ctx context.Context, chatID int64) error,
+	onResult func(chatID int64, err error),
+) (fanOutResult, error) {
+	var (
+		mu      sync.Mutex
+		res     fanOutResult
+		started int
+	)
+
+	g := new(errgroup.Group)
+	g.SetLimit(notifyConcurrency)
+
+	for _, user := range recipients {
+		if ctx.Err() != nil {
+			break
+		}
+
+		g.Go(func() error {
+			mu.Lock()
+			if ctx.Err() != nil {
+				mu.Unlock()
+				return nil
+			}
+			started++
+			mu.Unlock()
+
+			err := send(ctx, user.ChatID)
+
+			mu.Lock()
+			switch {
+			case err == nil:
+				res.Sent++
+			case errors.Is(err, ErrChatBlocked):
+				res.Blocked++
+			default:
+				res.Failed++
+			}
+			mu.Unlock()
+
+			if onResult != nil {
+				onResult(user.ChatID, err)
+			}
+			return nil
+		})
+	}
+
+	g.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if started < len(recipients) {
+		return res, ctx.Err()
+	}
+	return res, nil
+}
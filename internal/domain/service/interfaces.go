@@ -2,10 +2,9 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"tgBotFinal/internal/entity"
-
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 type Notification interface {
@@ -13,13 +12,25 @@ type Notification interface {
 	ActivateUser(ctx context.Context, chatID int64) error
 	DeactivateUser(ctx context.Context, chatID int64) error
 	SendInfoMessage(ctx context.Context, chatID int64, text string) error
+	SendDigest(ctx context.Context, chatID int64, digest *entity.Digest) error
 	CheckAPI(ctx context.Context) error
-	GetBotAPI() *tgbotapi.BotAPI
+	// SetWebhook registers url as the address Telegram delivers updates to.
+	SetWebhook(ctx context.Context, url string) error
 }
 
 type CryptoClient interface {
 	GetPriceBySymbol(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error)
 	GetAllPrices(ctx context.Context) (*entity.PriceResponse, error)
+	// GetTickers returns the price and 24 hour market data of every
+	// tracked symbol, ordered by symbol.
+	GetTickers(ctx context.Context) ([]*entity.Price, error)
+}
+
+// KlineProvider serves the candlestick history of the exchange.
+type KlineProvider interface {
+	// GetKlines returns the candles of symbol that open in [from, to),
+	// oldest first.
+	GetKlines(ctx context.Context, symbol entity.CurrencyName, interval entity.CandleInterval, from, to time.Time) ([]*entity.Candle, error)
 }
 
 type CurrencyRepository interface {
@@ -33,6 +44,53 @@ type UserRepository interface {
 	GetByChatID(ctx context.Context, chatID int64) (*entity.User, error)
 	GetAll(ctx context.Context) ([]*entity.User, error)
 	GetAllActive(ctx context.Context) ([]*entity.User, error)
+	SetDigest(ctx context.Context, chatID int64, period entity.DigestPeriod) error
+	SetTimezone(ctx context.Context, chatID int64, timezone string) error
+	GetDigestSubscribers(ctx context.Context) ([]*entity.User, error)
+	MarkDigestSent(ctx context.Context, chatID int64, sentAt time.Time) error
+	List(ctx context.Context, filter entity.UserFilter) ([]*entity.User, error)
+	Count(ctx context.Context, filter entity.UserFilter) (int, error)
+	SetActive(ctx context.Context, chatID int64, active bool) error
+	SetBannedUntil(ctx context.Context, chatID int64, until *time.Time) error
+	Delete(ctx context.Context, chatID int64) error
+	GrowthStats(ctx context.Context, from, to time.Time, bucket entity.GrowthBucket) ([]*entity.UserGrowthPoint, error)
+}
+
+type PriceHistoryRepository interface {
+	Append(ctx context.Context, price *entity.Price, recordedAt time.Time) error
+	GetOHLC(ctx context.Context, symbol entity.CurrencyName, from, to time.Time) (*entity.OHLC, error)
+	// Prune deletes the prices recorded before the given time and returns
+	// how many were deleted.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+// CandleRepository stores candles keyed by symbol, interval and open time.
+type CandleRepository interface {
+	// Upsert saves candles, replacing the stored ones with the same key.
+	Upsert(ctx context.Context, candles []*entity.Candle) error
+	// GetRange returns the stored candles that open in [from, to), oldest
+	// first.
+	GetRange(ctx context.Context, symbol entity.CurrencyName, interval entity.CandleInterval, from, to time.Time) ([]*entity.Candle, error)
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *entity.APIKey, hash string) error
+	GetByHash(ctx context.Context, hash string) (*entity.APIKey, error)
+	List(ctx context.Context) ([]*entity.APIKey, error)
+	Revoke(ctx context.Context, id int64) error
+	TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error
+}
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, plain string) (*entity.APIKey, error)
+}
+
+type Broadcaster interface {
+	Prepare(ctx context.Context, text string, createdBy int64) (*entity.Broadcast, error)
+	Confirm(ctx context.Context, id string, onProgress func(entity.Broadcast)) (*entity.Broadcast, error)
+	Cancel(id string) (*entity.Broadcast, error)
+	Get(id string) (*entity.Broadcast, error)
+	Latest(createdBy int64) (*entity.Broadcast, error)
 }
 
 type BotHandler interface {
@@ -53,4 +111,48 @@ type HealthChecker interface {
 	CheckDB(ctx context.Context) error
 	CheckByBitAPI(ctx context.Context) error
 	CheckTelegramAPI(ctx context.Context) error
+	LeaderStatus() entity.LeaderStatus
+}
+
+// LeaderElector picks the single replica that runs the scheduled workers.
+type LeaderElector interface {
+	// Lead blocks until ctx is done. Each time this replica becomes the
+	// leader it calls work with a context that is cancelled when leadership
+	// is lost, and waits for work to return before campaigning again.
+	Lead(ctx context.Context, work func(ctx context.Context)) error
+	Status() entity.LeaderStatus
+}
+
+type UpdateQueue interface {
+	// Submit runs task in the background after the tasks submitted earlier
+	// with the same key. It returns an error when the task is refused, e.g.
+	// the queue is full or the application is shutting down. The task's
+	// context is cancelled only if it outlives the shutdown deadline.
+	Submit(key int64, task func(ctx context.Context)) error
+}
+
+type RateLimiter interface {
+	Allow(ctx context.Context, chatID int64, command string) entity.RateDecision
+	Reset(chatID int64)
+}
+
+// Observer records measurements of the domain services, for example as
+// metrics. A nil Observer passed to a constructor discards them.
+type Observer interface {
+	// WorkerTick records how long one tick of a background worker took.
+	WorkerTick(worker string, elapsed time.Duration)
+	// Leading records whether this replica runs the scheduled workers.
+	Leading(leader bool)
+	// RateLimited records a command refused by the chat, command or
+	// global rate limit.
+	RateLimited(limit string)
+	// Banned records a chat banned for exceeding its rate limits.
+	Banned()
+}
+
+type UnitOfWork interface {
+	// WithTx runs fn in a transaction. Repository calls made with the
+	// context passed to fn are committed together if fn returns nil, and
+	// rolled back otherwise.
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"tgBotFinal/internal/entity"
+)
+
+// soloLeader is the elector of a single-replica deployment: it leads as
+// soon as Lead is called and keeps leading until its context is done.
+type soloLeader struct {
+	instanceID string
+	since      time.Time
+	observer   Observer
+}
+
+// NewSoloLeader returns the elector of a single replica. observer may be
+// nil.
+func NewSoloLeader(instanceID string, observer Observer) LeaderElector {
+	if observer == nil {
+		observer = noObserver{}
+	}
+	return &soloLeader{instanceID: instanceID, since: time.Now(), observer: observer}
+}
+
+func (l *soloLeader) Lead(ctx context.Context, work func(ctx context.Context)) error {
+	l.observer.Leading(true)
+	defer l.observer.Leading(false)
+
+	work(ctx)
+	return nil
+}
+
+func (l *soloLeader) Status() entity.LeaderStatus {
+	since := l.since
+	return entity.LeaderStatus{InstanceID: l.instanceID, Leader: true, Since: &since}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/entity"
+)
+
+// termLeader leads once until end is closed, then stays a follower.
+type termLeader struct {
+	end     chan struct{}
+	stepped chan struct{}
+}
+
+func (l *termLeader) Lead(ctx context.Context, work func(ctx context.Context)) error {
+	termCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-l.end:
+			cancel()
+		case <-termCtx.Done():
+		}
+	}()
+	work(termCtx)
+	cancel()
+	close(l.stepped)
+
+	<-ctx.Done()
+	return nil
+}
+
+func (l *termLeader) Status() entity.LeaderStatus {
+	return entity.LeaderStatus{}
+}
+
+func TestCryptService_WorkersRunOnlyWhileLeading(t *testing.T) {
+	var fetches atomic.Int32
+
+	mockCrypto := NewMockCryptoClient()
+	mockCrypto.GetAllPricesFunc = func(ctx context.Context) (*entity.PriceResponse, error) {
+		fetches.Add(1)
+		return &entity.PriceResponse{}, nil
+	}
+
+	clk := clock.NewFake(time.Now())
+	elector := &termLeader{end: make(chan struct{}), stepped: make(chan struct{})}
+	service := NewCryptService(NewMockCurrencyRepository(), NewMockUserRepository(), nil, mockCrypto,
+		NewMockNotification(), nil, "", slog.Default(), "8080",
+		WorkerIntervals{Notify: time.Hour, CacheRefresh: time.Minute, Digest: time.Hour}, RetryPolicy{}, elector, nil, clk, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go service.leader.Lead(ctx, service.runScheduledWorkers)
+
+	// One ticker per worker.
+	clk.BlockUntil(3)
+	clk.Advance(time.Minute)
+	waitUntil(t, func() bool { return fetches.Load() == 2 })
+
+	close(elector.end)
+	select {
+	case <-elector.stepped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("scheduled workers did not stop when leadership ended")
+	}
+
+	clk.Advance(time.Hour)
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("fetches after stepping down = %d, want %d", got-2, 0)
+	}
+}
+
+// waitUntil polls cond until it holds, for effects of goroutines woken by
+// the fake clock.
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSoloLeader(t *testing.T) {
+	var leading []bool
+	observer := &MockObserver{LeadingFunc: func(leader bool) { leading = append(leading, leader) }}
+	leader := NewSoloLeader("bot-1", observer)
+
+	status := leader.Status()
+	if !status.Leader || status.InstanceID != "bot-1" || status.Since == nil {
+		t.Errorf("Status() = %+v, want leader bot-1 with a start time", status)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	if err := leader.Lead(ctx, func(ctx context.Context) { called = ctx.Err() != nil }); err != nil || !called {
+		t.Errorf("Lead() = %v, work called with the lead context = %t", err, called)
+	}
+	if !slices.Equal(leading, []bool{true, false}) {
+		t.Errorf("Observed leadership %v, want gained then lost", leading)
+	}
+}
+
+func TestCryptService_RoundOutlivesStopUntilAbort(t *testing.T) {
+	service := NewCryptService(NewMockCurrencyRepository(), NewMockUserRepository(), nil, NewMockCryptoClient(),
+		NewMockNotification(), nil, "", slog.Default(), "8080", WorkerIntervals{}, RetryPolicy{}, nil, nil, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	result := make(chan error, 1)
+
+	go func() {
+		result <- service.runRound(ctx, func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+	<-started
+
+	cancel()
+	select {
+	case err := <-result:
+		t.Fatalf("Round stopped with the workers' context: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	service.Abort()
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Round error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Round did not stop on Abort")
+	}
+}
@@ -3,13 +3,13 @@ package service
 import (
 	"context"
 	"tgBotFinal/internal/entity"
-
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"time"
 )
 
 type MockCryptoClient struct {
 	GetPriceBySymbolFunc func(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error)
 	GetAllPricesFunc     func(ctx context.Context) (*entity.PriceResponse, error)
+	GetTickersFunc       func(ctx context.Context) ([]*entity.Price, error)
 }
 
 func (m *MockCryptoClient) GetPriceBySymbol(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error) {
@@ -20,13 +20,18 @@ func (m *MockCryptoClient) GetAllPrices(ctx context.Context) (*entity.PriceRespo
 	return m.GetAllPricesFunc(ctx)
 }
 
+func (m *MockCryptoClient) GetTickers(ctx context.Context) ([]*entity.Price, error) {
+	return m.GetTickersFunc(ctx)
+}
+
 type MockNotification struct {
 	SendAllPricesFunc   func(ctx context.Context, chatID int64, prices *entity.PriceResponse) error
 	ActivateUserFunc    func(ctx context.Context, chatID int64) error
 	DeactivateUserFunc  func(ctx context.Context, chatID int64) error
 	SendInfoMessageFunc func(ctx context.Context, chatID int64, text string) error
+	SendDigestFunc      func(ctx context.Context, chatID int64, digest *entity.Digest) error
 	CheckAPIFunc        func(ctx context.Context) error
-	GetBotAPIFunc       func() *tgbotapi.BotAPI
+	SetWebhookFunc      func(ctx context.Context, url string) error
 }
 
 func (m *MockNotification) SendAllPrices(ctx context.Context, chatID int64, prices *entity.PriceResponse) error {
@@ -45,19 +50,33 @@ func (m *MockNotification) SendInfoMessage(ctx context.Context, chatID int64, te
 	return m.SendInfoMessageFunc(ctx, chatID, text)
 }
 
+func (m *MockNotification) SendDigest(ctx context.Context, chatID int64, digest *entity.Digest) error {
+	return m.SendDigestFunc(ctx, chatID, digest)
+}
+
 func (m *MockNotification) CheckAPI(ctx context.Context) error {
 	return m.CheckAPIFunc(ctx)
 }
 
-func (m *MockNotification) GetBotAPI() *tgbotapi.BotAPI {
-	return m.GetBotAPIFunc()
+func (m *MockNotification) SetWebhook(ctx context.Context, url string) error {
+	return m.SetWebhookFunc(ctx, url)
 }
 
 type MockUserRepository struct {
-	SaveOrUpdateUserFunc func(ctx context.Context, user *entity.User) error
-	GetByChatIDFunc      func(ctx context.Context, chatID int64) (*entity.User, error)
-	GetAllFunc           func(ctx context.Context) ([]*entity.User, error)
-	GetAllActiveFunc     func(ctx context.Context) ([]*entity.User, error)
+	SaveOrUpdateUserFunc     func(ctx context.Context, user *entity.User) error
+	GetByChatIDFunc          func(ctx context.Context, chatID int64) (*entity.User, error)
+	GetAllFunc               func(ctx context.Context) ([]*entity.User, error)
+	GetAllActiveFunc         func(ctx context.Context) ([]*entity.User, error)
+	SetDigestFunc            func(ctx context.Context, chatID int64, period entity.DigestPeriod) error
+	SetTimezoneFunc          func(ctx context.Context, chatID int64, timezone string) error
+	GetDigestSubscribersFunc func(ctx context.Context) ([]*entity.User, error)
+	MarkDigestSentFunc       func(ctx context.Context, chatID int64, sentAt time.Time) error
+	ListFunc                 func(ctx context.Context, filter entity.UserFilter) ([]*entity.User, error)
+	CountFunc                func(ctx context.Context, filter entity.UserFilter) (int, error)
+	SetActiveFunc            func(ctx context.Context, chatID int64, active bool) error
+	SetBannedUntilFunc       func(ctx context.Context, chatID int64, until *time.Time) error
+	DeleteFunc               func(ctx context.Context, chatID int64) error
+	GrowthStatsFunc          func(ctx context.Context, from, to time.Time, bucket entity.GrowthBucket) ([]*entity.UserGrowthPoint, error)
 }
 
 func (m *MockUserRepository) SaveOrUpdate(ctx context.Context, user *entity.User) error {
@@ -76,6 +95,64 @@ func (m *MockUserRepository) GetAllActive(ctx context.Context) ([]*entity.User,
 	return m.GetAllActiveFunc(ctx)
 }
 
+func (m *MockUserRepository) SetDigest(ctx context.Context, chatID int64, period entity.DigestPeriod) error {
+	return m.SetDigestFunc(ctx, chatID, period)
+}
+
+func (m *MockUserRepository) SetTimezone(ctx context.Context, chatID int64, timezone string) error {
+	return m.SetTimezoneFunc(ctx, chatID, timezone)
+}
+
+func (m *MockUserRepository) GetDigestSubscribers(ctx context.Context) ([]*entity.User, error) {
+	return m.GetDigestSubscribersFunc(ctx)
+}
+
+func (m *MockUserRepository) MarkDigestSent(ctx context.Context, chatID int64, sentAt time.Time) error {
+	return m.MarkDigestSentFunc(ctx, chatID, sentAt)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, filter entity.UserFilter) ([]*entity.User, error) {
+	return m.ListFunc(ctx, filter)
+}
+
+func (m *MockUserRepository) Count(ctx context.Context, filter entity.UserFilter) (int, error) {
+	return m.CountFunc(ctx, filter)
+}
+
+func (m *MockUserRepository) SetActive(ctx context.Context, chatID int64, active bool) error {
+	return m.SetActiveFunc(ctx, chatID, active)
+}
+
+func (m *MockUserRepository) SetBannedUntil(ctx context.Context, chatID int64, until *time.Time) error {
+	return m.SetBannedUntilFunc(ctx, chatID, until)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, chatID int64) error {
+	return m.DeleteFunc(ctx, chatID)
+}
+
+func (m *MockUserRepository) GrowthStats(ctx context.Context, from, to time.Time, bucket entity.GrowthBucket) ([]*entity.UserGrowthPoint, error) {
+	return m.GrowthStatsFunc(ctx, from, to, bucket)
+}
+
+type MockPriceHistoryRepository struct {
+	AppendFunc  func(ctx context.Context, price *entity.Price, recordedAt time.Time) error
+	GetOHLCFunc func(ctx context.Context, symbol entity.CurrencyName, from, to time.Time) (*entity.OHLC, error)
+	PruneFunc   func(ctx context.Context, before time.Time) (int64, error)
+}
+
+func (m *MockPriceHistoryRepository) Append(ctx context.Context, price *entity.Price, recordedAt time.Time) error {
+	return m.AppendFunc(ctx, price, recordedAt)
+}
+
+func (m *MockPriceHistoryRepository) GetOHLC(ctx context.Context, symbol entity.CurrencyName, from, to time.Time) (*entity.OHLC, error) {
+	return m.GetOHLCFunc(ctx, symbol, from, to)
+}
+
+func (m *MockPriceHistoryRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	return m.PruneFunc(ctx, before)
+}
+
 type MockCurrencyRepository struct {
 	SaveOrUpdateFunc func(ctx context.Context, currency *entity.Price) error
 	GetBySymbolFunc  func(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error)
@@ -108,6 +185,12 @@ func NewMockCryptoClient() *MockCryptoClient {
 				ETH: &entity.Price{Symbol: entity.ETH, Price: "3000.00"},
 			}, nil
 		},
+		GetTickersFunc: func(ctx context.Context) ([]*entity.Price, error) {
+			return []*entity.Price{
+				{Symbol: entity.BTC, Price: "50000.00"},
+				{Symbol: entity.ETH, Price: "3000.00"},
+			}, nil
+		},
 	}
 }
 
@@ -125,10 +208,13 @@ func NewMockNotification() *MockNotification {
 		SendInfoMessageFunc: func(ctx context.Context, chatID int64, text string) error {
 			return nil
 		},
+		SendDigestFunc: func(ctx context.Context, chatID int64, digest *entity.Digest) error {
+			return nil
+		},
 		CheckAPIFunc: func(ctx context.Context) error {
 			return nil
 		},
-		GetBotAPIFunc: func() *tgbotapi.BotAPI {
+		SetWebhookFunc: func(ctx context.Context, url string) error {
 			return nil
 		},
 	}
@@ -152,6 +238,47 @@ func NewMockUserRepository() *MockUserRepository {
 		GetAllFunc: func(ctx context.Context) ([]*entity.User, error) {
 			return []*entity.User{}, nil
 		},
+		SetDigestFunc: func(ctx context.Context, chatID int64, period entity.DigestPeriod) error {
+			return nil
+		},
+		SetTimezoneFunc: func(ctx context.Context, chatID int64, timezone string) error {
+			return nil
+		},
+		GetDigestSubscribersFunc: func(ctx context.Context) ([]*entity.User, error) {
+			return []*entity.User{}, nil
+		},
+		MarkDigestSentFunc: func(ctx context.Context, chatID int64, sentAt time.Time) error {
+			return nil
+		},
+		ListFunc: func(ctx context.Context, filter entity.UserFilter) ([]*entity.User, error) {
+			return []*entity.User{}, nil
+		},
+		CountFunc: func(ctx context.Context, filter entity.UserFilter) (int, error) {
+			return 0, nil
+		},
+		SetActiveFunc: func(ctx context.Context, chatID int64, active bool) error {
+			return nil
+		},
+		DeleteFunc: func(ctx context.Context, chatID int64) error {
+			return nil
+		},
+		GrowthStatsFunc: func(ctx context.Context, from, to time.Time, bucket entity.GrowthBucket) ([]*entity.UserGrowthPoint, error) {
+			return []*entity.UserGrowthPoint{}, nil
+		},
+	}
+}
+
+func NewMockPriceHistoryRepository() *MockPriceHistoryRepository {
+	return &MockPriceHistoryRepository{
+		AppendFunc: func(ctx context.Context, price *entity.Price, recordedAt time.Time) error {
+			return nil
+		},
+		GetOHLCFunc: func(ctx context.Context, symbol entity.CurrencyName, from, to time.Time) (*entity.OHLC, error) {
+			return nil, ErrNoPriceHistory
+		},
+		PruneFunc: func(ctx context.Context, before time.Time) (int64, error) {
+			return 0, nil
+		},
 	}
 }
 
@@ -168,3 +295,34 @@ func NewMockCurrencyRepository() *MockCurrencyRepository {
 		},
 	}
 }
+
+type MockObserver struct {
+	WorkerTickFunc  func(worker string, elapsed time.Duration)
+	LeadingFunc     func(leader bool)
+	RateLimitedFunc func(limit string)
+	BannedFunc      func()
+}
+
+func (m *MockObserver) WorkerTick(worker string, elapsed time.Duration) {
+	if m.WorkerTickFunc != nil {
+		m.WorkerTickFunc(worker, elapsed)
+	}
+}
+
+func (m *MockObserver) Leading(leader bool) {
+	if m.LeadingFunc != nil {
+		m.LeadingFunc(leader)
+	}
+}
+
+func (m *MockObserver) RateLimited(limit string) {
+	if m.RateLimitedFunc != nil {
+		m.RateLimitedFunc(limit)
+	}
+}
+
+func (m *MockObserver) Banned() {
+	if m.BannedFunc != nil {
+		m.BannedFunc()
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"tgBotFinal/internal/entity"
+)
+
+// RateLimits configures CommandLimiter. Zero limits are disabled.
+type RateLimits struct {
+	// Chat limits all commands of a chat together.
+	Chat entity.RateLimit
+	// Commands limits single commands of a chat, on top of Chat.
+	Commands map[string]entity.RateLimit
+	// Global limits the commands of all chats together.
+	Global entity.RateLimit
+
+	// A chat refused AbuseThreshold times within AbuseWindow is banned for
+	// BanDuration. A zero threshold disables bans.
+	AbuseThreshold int
+	AbuseWindow    time.Duration
+	BanDuration    time.Duration
+}
+
+// window counts events in a fixed time window.
+type window struct {
+	start  time.Time
+	count  int
+	warned bool
+}
+
+// hit counts an event and reports whether it is within limit.
+func (w *window) hit(now time.Time, limit entity.RateLimit) bool {
+	if now.Sub(w.start) >= limit.Window {
+		*w = window{start: now}
+	}
+	w.count++
+	return w.count <= limit.Limit
+}
+
+// warnOnce reports true the first time it is called in the window.
+func (w *window) warnOnce() bool {
+	if w.warned {
+		return false
+	}
+	w.warned = true
+	return true
+}
+
+type chatCommand struct {
+	chatID  int64
+	command string
+}
+
+// CommandLimiter rate limits bot commands per chat, per command and
+// globally, and bans chats that keep hitting their limits. Counters are
+// kept in memory by each replica; bans are only stored with the user, so
+// they apply and are lifted everywhere at once. Callers check the stored
+// ban before Allow.
+type CommandLimiter struct {
+	users    UserRepository
+	limits   RateLimits
+	observer Observer
+	logger   *slog.Logger
+
+	mu        sync.Mutex
+	global    window
+	chats     map[chatCommand]*window
+	strikes   map[int64]*window
+	lastSweep time.Time
+}
+
+func NewCommandLimiter(users UserRepository, limits RateLimits, logger *slog.Logger, observer Observer) *CommandLimiter {
+	if observer == nil {
+		observer = noObserver{}
+	}
+
+	return &CommandLimiter{
+		users:    users,
+		limits:   limits,
+		observer: observer,
+		logger:   logger.With(slog.String("component", "CommandLimiter")),
+		chats:    make(map[chatCommand]*window),
+		strikes:  make(map[int64]*window),
+	}
+}
+
+// Allow counts a command from chatID and decides whether to handle it.
+func (l *CommandLimiter) Allow(ctx context.Context, chatID int64, command string) entity.RateDecision {
+	now := time.Now()
+
+	l.mu.Lock()
+	l.sweep(now)
+
+	refused, warn := false, false
+	if limit, ok := l.limits.Commands[command]; ok && limit.Enabled() {
+		refused, warn = l.hit(chatCommand{chatID, command}, now, limit)
+		if refused {
+			l.observer.RateLimited("command")
+		}
+	}
+	if !refused && l.limits.Chat.Enabled() {
+		refused, warn = l.hit(chatCommand{chatID: chatID}, now, l.limits.Chat)
+		if refused {
+			l.observer.RateLimited("chat")
+		}
+	}
+
+	if refused {
+		ban := l.strike(chatID, now)
+		l.mu.Unlock()
+
+		if ban {
+			return l.ban(ctx, chatID, now)
+		}
+		return entity.RateDecision{Warn: warn}
+	}
+
+	// A busy bot is not the chat's fault: no reply and no strike.
+	if l.limits.Global.Enabled() && !l.global.hit(now, l.limits.Global) {
+		l.mu.Unlock()
+		l.observer.RateLimited("global")
+		return entity.RateDecision{}
+	}
+	l.mu.Unlock()
+
+	return entity.RateDecision{Allowed: true}
+}
+
+// Reset forgets the recent commands and strikes of chatID, e.g. after an
+// admin lifted its ban. The ban itself is stored with the user.
+func (l *CommandLimiter) Reset(chatID int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key := range l.chats {
+		if key.chatID == chatID {
+			delete(l.chats, key)
+		}
+	}
+	delete(l.strikes, chatID)
+}
+
+// hit counts a command in the window of key and reports whether it was
+// refused and whether that deserves a warning.
+func (l *CommandLimiter) hit(key chatCommand, now time.Time, limit entity.RateLimit) (refused, warn bool) {
+	w, ok := l.chats[key]
+	if !ok {
+		w = &window{start: now}
+		l.chats[key] = w
+	}
+
+	if w.hit(now, limit) {
+		return false, false
+	}
+	return true, w.warnOnce()
+}
+
+// strike records a refused command and reports whether the chat crossed the
+// abuse threshold.
+func (l *CommandLimiter) strike(chatID int64, now time.Time) bool {
+	if l.limits.AbuseThreshold <= 0 {
+		return false
+	}
+
+	w, ok := l.strikes[chatID]
+	if !ok {
+		w = &window{start: now}
+		l.strikes[chatID] = w
+	}
+
+	limit := entity.RateLimit{Limit: l.limits.AbuseThreshold - 1, Window: l.limits.AbuseWindow}
+	if w.hit(now, limit) {
+		return false
+	}
+
+	delete(l.strikes, chatID)
+	return true
+}
+
+func (l *CommandLimiter) ban(ctx context.Context, chatID int64, now time.Time) entity.RateDecision {
+	until := now.Add(l.limits.BanDuration)
+
+	if err := l.users.SetBannedUntil(ctx, chatID, &until); err != nil {
+		l.logger.ErrorContext(ctx, "failed to ban chat", "chat_id", chatID, "error", err)
+		return entity.RateDecision{}
+	}
+
+	l.observer.Banned()
+	l.logger.WarnContext(ctx, "Chat banned for abuse", "chat_id", chatID, "until", until)
+	return entity.RateDecision{BannedUntil: &until}
+}
+
+// sweep drops the windows that have expired so idle chats don't accumulate.
+func (l *CommandLimiter) sweep(now time.Time) {
+	longest := max(l.limits.Chat.Window, l.limits.AbuseWindow)
+	for _, limit := range l.limits.Commands {
+		longest = max(longest, limit.Window)
+	}
+
+	if now.Sub(l.lastSweep) < longest {
+		return
+	}
+	l.lastSweep = now
+
+	for key, w := range l.chats {
+		if now.Sub(w.start) >= longest {
+			delete(l.chats, key)
+		}
+	}
+	for chatID, w := range l.strikes {
+		if now.Sub(w.start) >= longest {
+			delete(l.strikes, chatID)
+		}
+	}
+}
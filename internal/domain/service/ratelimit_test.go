@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/entity"
+)
+
+func newTestLimiter(limits RateLimits) (*CommandLimiter, *[]int64) {
+	var banned []int64
+	users := NewMockUserRepository()
+	users.SetBannedUntilFunc = func(ctx context.Context, chatID int64, until *time.Time) error {
+		banned = append(banned, chatID)
+		return nil
+	}
+	return NewCommandLimiter(users, limits, slog.Default(), nil), &banned
+}
+
+func TestCommandLimiter_ChatLimitWarnsOnce(t *testing.T) {
+	l, _ := newTestLimiter(RateLimits{Chat: entity.RateLimit{Limit: 3, Window: time.Hour}})
+	ctx := context.Background()
+
+	for i := range 3 {
+		if d := l.Allow(ctx, 1, "/help"); !d.Allowed {
+			t.Fatalf("Command %d refused within the limit", i+1)
+		}
+	}
+
+	if d := l.Allow(ctx, 1, "/help"); d.Allowed || !d.Warn {
+		t.Errorf("First command over the limit = %+v, want refused with a warning", d)
+	}
+	if d := l.Allow(ctx, 1, "/start"); d.Allowed || d.Warn {
+		t.Errorf("Second command over the limit = %+v, want refused silently", d)
+	}
+	if d := l.Allow(ctx, 2, "/help"); !d.Allowed {
+		t.Error("Another chat was limited")
+	}
+}
+
+func TestCommandLimiter_CommandLimit(t *testing.T) {
+	l, _ := newTestLimiter(RateLimits{
+		Chat:     entity.RateLimit{Limit: 10, Window: time.Hour},
+		Commands: map[string]entity.RateLimit{"/price": {Limit: 1, Window: time.Hour}},
+	})
+	ctx := context.Background()
+
+	if d := l.Allow(ctx, 1, "/price"); !d.Allowed {
+		t.Fatal("First /price refused")
+	}
+	if d := l.Allow(ctx, 1, "/price"); d.Allowed {
+		t.Error("Second /price allowed over its limit")
+	}
+	if d := l.Allow(ctx, 1, "/help"); !d.Allowed {
+		t.Error("/help limited by the /price limit")
+	}
+}
+
+func TestCommandLimiter_GlobalLimitDoesNotStrike(t *testing.T) {
+	l, banned := newTestLimiter(RateLimits{
+		Global:         entity.RateLimit{Limit: 1, Window: time.Hour},
+		AbuseThreshold: 1,
+		AbuseWindow:    time.Hour,
+		BanDuration:    time.Hour,
+	})
+	ctx := context.Background()
+
+	l.Allow(ctx, 1, "/help")
+	for range 5 {
+		if d := l.Allow(ctx, 2, "/help"); d.Allowed || d.Warn || d.BannedUntil != nil {
+			t.Fatalf("Command over the global limit = %+v, want refused silently", d)
+		}
+	}
+	if len(*banned) != 0 {
+		t.Errorf("Banned %v for the global limit", *banned)
+	}
+}
+
+func TestCommandLimiter_BansAbusiveChat(t *testing.T) {
+	l, banned := newTestLimiter(RateLimits{
+		Chat:           entity.RateLimit{Limit: 1, Window: time.Hour},
+		AbuseThreshold: 3,
+		AbuseWindow:    time.Hour,
+		BanDuration:    time.Hour,
+	})
+	var limited []string
+	bans := 0
+	l.observer = &MockObserver{
+		RateLimitedFunc: func(limit string) { limited = append(limited, limit) },
+		BannedFunc:      func() { bans++ },
+	}
+	ctx := context.Background()
+
+	l.Allow(ctx, 1, "/help")
+	for i := range 2 {
+		if d := l.Allow(ctx, 1, "/help"); d.BannedUntil != nil {
+			t.Fatalf("Banned after %d refused commands", i+1)
+		}
+	}
+
+	d := l.Allow(ctx, 1, "/help")
+	if d.BannedUntil == nil || time.Until(*d.BannedUntil) < 59*time.Minute {
+		t.Fatalf("Third refused command = %+v, want a one hour ban", d)
+	}
+
+	if len(*banned) != 1 {
+		t.Errorf("Ban stored %d times, want once", len(*banned))
+	}
+	if want := []string{"chat", "chat", "chat"}; !slices.Equal(limited, want) || bans != 1 {
+		t.Errorf("Observed %v limited and %d bans, want %v and 1", limited, bans, want)
+	}
+
+	l.Reset(1)
+	if d := l.Allow(ctx, 1, "/help"); !d.Allowed {
+		t.Error("Command refused after Reset")
+	}
+}
@@ -2,48 +2,93 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"net/url"
-
+	"sync"
 	"time"
 
+	"tgBotFinal/internal/clock"
 	"tgBotFinal/internal/entity"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"golang.org/x/sync/errgroup"
 )
 
 type CryptService struct {
-	CurrencyRepo CurrencyRepository
-	UserRepo     UserRepository
-	CryptClient  CryptoClient
-	Notification Notification
-	ChiRouter    Router
-	webhookURL   string
-	logger       *slog.Logger
-	port         string
+	CurrencyRepo  CurrencyRepository
+	UserRepo      UserRepository
+	PriceHistory  PriceHistoryRepository
+	DigestBuilder *DigestBuilder
+	CryptClient   CryptoClient
+	Notification  Notification
+	ChiRouter     Router
+	webhookURL    string
+	logger        *slog.Logger
+	port          string
+	retry         RetryPolicy
+	leader        LeaderElector
+	uow           UnitOfWork
+	clock         clock.Clock
+	observer      Observer
+
+	// abortCtx is cancelled by Abort to stop rounds that are still sending.
+	abortCtx context.Context
+	abort    context.CancelFunc
+
+	mu               sync.Mutex
+	intervals        WorkerIntervals
+	intervalsChanged chan struct{}
 }
 
 func NewCryptService(CurrencyRepo CurrencyRepository,
 	UserRepo UserRepository,
+	PriceHistory PriceHistoryRepository,
 	CryptoClient CryptoClient,
 	Notification Notification,
 	ChiRouter Router,
 	webhookURL string,
 	logger *slog.Logger,
 	port string,
+	intervals WorkerIntervals,
+	retry RetryPolicy,
+	leader LeaderElector,
+	uow UnitOfWork,
+	clk clock.Clock,
+	observer Observer,
 ) *CryptService {
+	if observer == nil {
+		observer = noObserver{}
+	}
+	if leader == nil {
+		leader = NewSoloLeader("", observer)
+	}
+	if uow == nil {
+		uow = noTransactions{}
+	}
+	if clk == nil {
+		clk = clock.Real()
+	}
+	abortCtx, abort := context.WithCancel(context.Background())
 
 	return &CryptService{
-		CurrencyRepo: CurrencyRepo,
-		UserRepo:     UserRepo,
-		CryptClient:  CryptoClient,
-		Notification: Notification,
-		ChiRouter:    ChiRouter,
-		webhookURL:   webhookURL,
-		logger:       logger.With(slog.String("component", "CryptoService")),
-		port:         port,
+		CurrencyRepo:  CurrencyRepo,
+		UserRepo:      UserRepo,
+		PriceHistory:  PriceHistory,
+		DigestBuilder: NewDigestBuilder(PriceHistory, logger),
+		CryptClient:   CryptoClient,
+		Notification:  Notification,
+		ChiRouter:     ChiRouter,
+		webhookURL:    webhookURL,
+		logger:        logger.With(slog.String("component", "CryptoService")),
+		port:          port,
+		retry:         retry,
+		leader:        leader,
+		uow:           uow,
+		clock:         clk,
+		observer:      observer,
+		abortCtx:      abortCtx,
+		abort:         abort,
+		intervals:     intervals.withDefaults(),
 	}
 }
 
@@ -54,31 +99,26 @@ func (s *CryptService) Run(ctx context.Context) error {
 		s.logger.Error("Failed to setup Telegram webhook", "error", err)
 	}
 
-	prices, err := s.getPricesWithRetry(ctx)
-	if err != nil {
+	// Fails fast when the exchange is unreachable and warms the price cache
+	// on every replica.
+	if _, err := s.getPricesWithRetry(ctx); err != nil {
 		return fmt.Errorf("failed to get prices: %w", err)
 	}
 
-	if err := s.savePrices(ctx, prices); err != nil {
-		s.logger.Warn("Failed to save prices", "err", err)
-	}
-
 	g, ctx := errgroup.WithContext(ctx)
 
+	// Webhook handling runs on every replica, the scheduled workers only on
+	// the leader so subscribers are not notified once per replica.
 	g.Go(func() error {
-		return s.runNotificationWorker(ctx)
-	})
-
-	g.Go(func() error {
-		return s.runCacheRefreshWorker(ctx)
+		return s.leader.Lead(ctx, s.runScheduledWorkers)
 	})
 
 	g.Go(func() error {
 		s.ChiRouter.SetupMiddleware()
 		s.ChiRouter.SetupRoutes()
 
-		s.logger.Info("Starting HTTP server on port 8080")
-		if err := s.ChiRouter.Start("8080"); err != nil {
+		s.logger.Info("Starting HTTP server", "port", s.port)
+		if err := s.ChiRouter.Start(s.port); err != nil {
 			return fmt.Errorf("HTTP server failed: %w", err)
 		}
 		return nil
@@ -91,6 +131,56 @@ func (s *CryptService) Run(ctx context.Context) error {
 	return nil
 }
 
+// runScheduledWorkers runs the periodic workers until ctx is done, which
+// happens on shutdown or when this replica stops being the leader.
+func (s *CryptService) runScheduledWorkers(ctx context.Context) {
+	s.logger.Info("Leadership acquired, starting scheduled workers")
+
+	if err := s.refreshCache(ctx); err != nil {
+		s.logger.Warn("Failed to refresh prices", "err", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, worker := range []func(context.Context) error{
+		s.runNotificationWorker,
+		s.runCacheRefreshWorker,
+		s.runDigestWorker,
+		s.runHistoryPruneWorker,
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = worker(ctx)
+		}()
+	}
+	wg.Wait()
+
+	s.logger.Info("Scheduled workers stopped")
+}
+
+// Abort cancels the worker rounds still running after Run's context was
+// cancelled. It is meant for the shutdown deadline.
+func (s *CryptService) Abort() {
+	s.abort()
+}
+
+// runRound runs one round of a worker that sends messages. The round keeps
+// going when ctx is cancelled so subscribers are not left half notified;
+// only Abort cuts it short.
+func (s *CryptService) runRound(ctx context.Context, round func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	stop := context.AfterFunc(s.abortCtx, cancel)
+	defer stop()
+
+	return round(ctx)
+}
+
+// LeaderStatus reports whether this replica runs the scheduled workers.
+func (s *CryptService) LeaderStatus() entity.LeaderStatus {
+	return s.leader.Status()
+}
+
 func (s *CryptService) runCacheRefreshWorker(ctx context.Context) error {
 	defer func() {
 		if r := recover(); r != nil {
@@ -98,7 +188,9 @@ func (s *CryptService) runCacheRefreshWorker(ctx context.Context) error {
 		}
 	}()
 
-	ticker := time.NewTicker(30 * time.Second)
+	pick := func(w WorkerIntervals) time.Duration { return w.CacheRefresh }
+	interval, changed := s.workerInterval(pick)
+	ticker := s.clock.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -106,10 +198,16 @@ func (s *CryptService) runCacheRefreshWorker(ctx context.Context) error {
 		case <-ctx.Done():
 			s.logger.Info("Stopping cache refresh worker")
 			return nil
-		case <-ticker.C:
+		case <-changed:
+			interval, changed = s.workerInterval(pick)
+			ticker.Reset(interval)
+			s.logger.Info("Cache refresh interval changed", "interval", interval)
+		case <-ticker.C():
+			start := time.Now()
 			if err := s.refreshCache(ctx); err != nil {
 				s.logger.Error("Failed to refresh cache", "err", err)
 			}
+			s.observer.WorkerTick("cache_refresh", time.Since(start))
 		}
 	}
 }
@@ -129,11 +227,14 @@ func (s *CryptService) refreshCache(ctx context.Context) error {
 }
 
 func (s *CryptService) getPricesWithRetry(ctx context.Context) (*entity.PriceResponse, error) {
-	maxRetries := 5
-	initialDelay := 100 * time.Millisecond
-	maxDelay := 10 * time.Minute
+	policy := s.retry
+	if policy.Attempts <= 0 {
+		policy = DefaultRetryPolicy()
+	}
 
-	delay := initialDelay
+	maxRetries := policy.Attempts
+	maxDelay := policy.MaxDelay
+	delay := policy.InitialDelay
 
 	for i := 0; i < maxRetries; i++ {
 		prices, err := s.CryptClient.GetAllPrices(ctx)
@@ -150,7 +251,7 @@ func (s *CryptService) getPricesWithRetry(ctx context.Context) (*entity.PriceRes
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case <-time.After(delay):
+		case <-s.clock.After(delay):
 		}
 
 		delay *= 2
@@ -165,16 +266,28 @@ func (s *CryptService) getPricesWithRetry(ctx context.Context) (*entity.PriceRes
 func (s *CryptService) savePrices(ctx context.Context, prices *entity.PriceResponse) error {
 
 	var errs []error
+	now := s.clock.Now()
 
-	if prices.BTC != nil {
-		if err := s.CurrencyRepo.SaveOrUpdate(ctx, prices.BTC); err != nil {
-			errs = append(errs, fmt.Errorf("failed to save BTC price: %w", err))
+	for _, price := range []*entity.Price{prices.BTC, prices.ETH} {
+		if price == nil {
+			continue
 		}
-	}
 
-	if prices.ETH != nil {
-		if err := s.CurrencyRepo.SaveOrUpdate(ctx, prices.ETH); err != nil {
-			errs = append(errs, fmt.Errorf("failed to save ETH price: %w", err))
+		// The current price and its history entry are saved together.
+		err := s.uow.WithTx(ctx, func(ctx context.Context) error {
+			if err := s.CurrencyRepo.SaveOrUpdate(ctx, price); err != nil {
+				return fmt.Errorf("failed to save %s price: %w", price.Symbol, err)
+			}
+
+			if s.PriceHistory != nil {
+				if err := s.PriceHistory.Append(ctx, price, now); err != nil {
+					return fmt.Errorf("failed to append %s price history: %w", price.Symbol, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
 		}
 	}
 
@@ -193,19 +306,27 @@ func (s *CryptService) runNotificationWorker(ctx context.Context) error {
 		}
 	}()
 
-	ticker := time.NewTicker(15 * time.Minute)
+	pick := func(w WorkerIntervals) time.Duration { return w.Notify }
+	interval, changed := s.workerInterval(pick)
+	ticker := s.clock.NewTicker(interval)
 	defer ticker.Stop()
-	s.logger.Debug("Run Notification Worker")
+	s.logger.Debug("Run Notification Worker", "interval", interval)
 
 	for {
 		select {
 		case <-ctx.Done():
 			s.logger.Info("Notification worker stopped")
 			return nil
-		case <-ticker.C:
-			if err := s.sendNotificationsToActive(ctx); err != nil {
+		case <-changed:
+			interval, changed = s.workerInterval(pick)
+			ticker.Reset(interval)
+			s.logger.Info("Notification interval changed", "interval", interval)
+		case <-ticker.C():
+			start := time.Now()
+			if err := s.runRound(ctx, s.sendNotificationsToActive); err != nil {
 				s.logger.Error("failed to send notifications", "error", err)
 			}
+			s.observer.WorkerTick("notification", time.Since(start))
 		}
 	}
 }
@@ -223,24 +344,32 @@ func (s *CryptService) sendNotificationsToActive(ctx context.Context) error {
 		return fmt.Errorf("get prices: %w", err)
 	}
 
-	g, ctx := errgroup.WithContext(ctx)
-	g.SetLimit(5)
-
-	for _, user := range users {
-		g.Go(func() error {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-				if err := s.Notification.SendAllPrices(ctx, user.ChatID, prices); err != nil {
-					s.logger.Warn("send prices failed", "chatID", user.ChatID, "error", err)
-				}
-				return nil
+	_, err = fanOut(ctx, users,
+		func(ctx context.Context, chatID int64) error {
+			return s.Notification.SendAllPrices(ctx, chatID, prices)
+		},
+		func(chatID int64, err error) {
+			if err != nil {
+				s.logger.Warn("send prices failed", "chat_id", chatID, "error", err)
 			}
+			s.deactivateIfBlocked(ctx, chatID, err)
 		})
+	return err
+}
+
+// deactivateIfBlocked unsubscribes chats that blocked the bot so they are
+// skipped by later sends.
+func (s *CryptService) deactivateIfBlocked(ctx context.Context, chatID int64, err error) {
+	if !errors.Is(err, ErrChatBlocked) {
+		return
+	}
 
+	if err := s.UserRepo.SetActive(ctx, chatID, false); err != nil {
+		s.logger.Warn("failed to deactivate blocked chat", "chat_id", chatID, "error", err)
+		return
 	}
-	return g.Wait()
+
+	s.logger.Info("deactivated chat that blocked the bot", "chat_id", chatID)
 }
 
 func (s *CryptService) CheckDB(ctx context.Context) error {
@@ -282,28 +411,32 @@ func (s *CryptService) setupTelegramWebhook(ctx context.Context, webhookURL stri
 		return nil
 	}
 
-	botAPI := s.Notification.GetBotAPI()
-	if botAPI == nil {
-		return fmt.Errorf("Telegram API is not available")
-	}
-
 	fullWebhookURL := webhookURL + "/webhook/telegram"
 
-	parsedURL, err := url.Parse(fullWebhookURL)
-	if err != nil {
-		return fmt.Errorf("failed to parse webhook URL: %w", err)
-	}
-
 	s.logger.Info("Setting up Telegram webhook", "url", webhookURL)
 
-	_, err = botAPI.Request(tgbotapi.WebhookConfig{
-		URL: parsedURL,
-	})
-
-	if err != nil {
+	if err := s.Notification.SetWebhook(ctx, fullWebhookURL); err != nil {
 		return fmt.Errorf("failed to set webhook: %w", err)
 	}
 
 	s.logger.Info("Telegram webhook setup successfully")
 	return nil
 }
+
+// noObserver discards measurements.
+type noObserver struct{}
+
+func (noObserver) WorkerTick(string, time.Duration) {}
+
+func (noObserver) Leading(bool) {}
+
+func (noObserver) RateLimited(string) {}
+
+func (noObserver) Banned() {}
+
+// noTransactions runs work directly, for repositories without transactions.
+type noTransactions struct{}
+
+func (noTransactions) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
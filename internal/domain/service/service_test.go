@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"sync"
 	"testing"
+	"time"
+
+	"tgBotFinal/internal/clock"
 	"tgBotFinal/internal/entity"
 )
 
@@ -14,9 +18,16 @@ func TestCryptService_SendNotifications(t *testing.T) {
 	mockUserRepo := NewMockUserRepository()
 	mockCurrencyRepo := NewMockCurrencyRepository()
 
-	var sentPrices *entity.PriceResponse
+	var (
+		mu         sync.Mutex
+		sentPrices *entity.PriceResponse
+	)
 
+	// Users are notified concurrently.
 	mockNotifier.SendAllPricesFunc = func(ctx context.Context, chatID int64, prices *entity.PriceResponse) error {
+		mu.Lock()
+		defer mu.Unlock()
+
 		sentPrices = prices
 		return nil
 	}
@@ -74,7 +85,7 @@ func TestCryptService_GetPricesWithRetry(t *testing.T) {
 	mockCrypto := &MockCryptoClient{
 		GetAllPricesFunc: func(ctx context.Context) (*entity.PriceResponse, error) {
 			callCount++
-			if callCount < 2 {
+			if callCount < 4 {
 				return nil, errors.New("temporary error")
 			}
 			return &entity.PriceResponse{
@@ -83,21 +94,47 @@ func TestCryptService_GetPricesWithRetry(t *testing.T) {
 		},
 	}
 
+	clk := clock.NewFake(time.Now())
 	service := &CryptService{
 		CryptClient: mockCrypto,
 		logger:      slog.Default(),
+		retry:       RetryPolicy{Attempts: 5, InitialDelay: time.Second, MaxDelay: 3 * time.Second},
+		clock:       clk,
 	}
 
-	prices, err := service.getPricesWithRetry(context.Background())
-	if err != nil {
-		t.Fatalf("getPricesWithRetry failed: %v", err)
+	type result struct {
+		prices *entity.PriceResponse
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		prices, err := service.getPricesWithRetry(context.Background())
+		done <- result{prices, err}
+	}()
+
+	// The delay doubles up to MaxDelay. A wait one step short of it must
+	// not retry.
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		clk.BlockUntil(1)
+		clk.Advance(delay - time.Millisecond)
+		select {
+		case res := <-done:
+			t.Fatalf("getPricesWithRetry returned %+v before the %s delay", res, delay)
+		default:
+		}
+		clk.Advance(time.Millisecond)
+	}
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("getPricesWithRetry failed: %v", res.err)
 	}
 
-	if prices.BTC.Price != "50000.00" {
-		t.Errorf("Price = %v, want %v", prices.BTC.Price, "50000.00")
+	if res.prices.BTC.Price != "50000.00" {
+		t.Errorf("Price = %v, want %v", res.prices.BTC.Price, "50000.00")
 	}
 
-	if callCount != 2 {
-		t.Errorf("Retry count = %v, want %v", callCount, 2)
+	if callCount != 4 {
+		t.Errorf("Retry count = %v, want %v", callCount, 4)
 	}
 }
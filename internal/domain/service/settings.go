@@ -0,0 +1,85 @@
+package service
+
+import "time"
+
+// WorkerIntervals sets how often the background workers run and how long
+// the price history is kept. Zero fields fall back to
+// DefaultWorkerIntervals.
+type WorkerIntervals struct {
+	Notify       time.Duration
+	CacheRefresh time.Duration
+	Digest       time.Duration
+	// HistoryRetention is how long recorded prices are kept; older ones
+	// are pruned every historyPruneInterval.
+	HistoryRetention time.Duration
+}
+
+func DefaultWorkerIntervals() WorkerIntervals {
+	return WorkerIntervals{
+		Notify:       15 * time.Minute,
+		CacheRefresh: 30 * time.Second,
+		Digest:       5 * time.Minute,
+		// A day beyond the weekly digest window.
+		HistoryRetention: 8 * 24 * time.Hour,
+	}
+}
+
+func (w WorkerIntervals) withDefaults() WorkerIntervals {
+	def := DefaultWorkerIntervals()
+	if w.Notify <= 0 {
+		w.Notify = def.Notify
+	}
+	if w.CacheRefresh <= 0 {
+		w.CacheRefresh = def.CacheRefresh
+	}
+	if w.Digest <= 0 {
+		w.Digest = def.Digest
+	}
+	if w.HistoryRetention <= 0 {
+		w.HistoryRetention = def.HistoryRetention
+	}
+	return w
+}
+
+// RetryPolicy controls how fetching the initial prices is retried. The
+// delay doubles after every failed attempt up to MaxDelay. A zero policy
+// means DefaultRetryPolicy.
+type RetryPolicy struct {
+	Attempts     int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Attempts:     5,
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     10 * time.Minute,
+	}
+}
+
+// UpdateIntervals applies new worker intervals. Running workers switch to
+// them without waiting for their current tick.
+func (s *CryptService) UpdateIntervals(intervals WorkerIntervals) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.intervals = intervals.withDefaults()
+	if s.intervalsChanged != nil {
+		close(s.intervalsChanged)
+	}
+	s.intervalsChanged = make(chan struct{})
+}
+
+// workerInterval returns the current interval picked from the worker
+// intervals and a channel that is closed when they change.
+func (s *CryptService) workerInterval(pick func(WorkerIntervals) time.Duration) (time.Duration, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.intervalsChanged == nil {
+		s.intervalsChanged = make(chan struct{})
+	}
+
+	return pick(s.intervals.withDefaults()), s.intervalsChanged
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/entity"
+)
+
+func TestCryptService_UpdateIntervals(t *testing.T) {
+	refreshed := make(chan struct{}, 10)
+
+	mockCrypto := NewMockCryptoClient()
+	mockCrypto.GetAllPricesFunc = func(ctx context.Context) (*entity.PriceResponse, error) {
+		refreshed <- struct{}{}
+		return &entity.PriceResponse{}, nil
+	}
+
+	ticks := make(chan string, 10)
+	observer := &MockObserver{WorkerTickFunc: func(worker string, elapsed time.Duration) {
+		ticks <- worker
+	}}
+
+	clk := clock.NewFake(time.Now())
+	service := NewCryptService(NewMockCurrencyRepository(), NewMockUserRepository(), nil, mockCrypto,
+		NewMockNotification(), nil, "", slog.Default(), "8080",
+		WorkerIntervals{CacheRefresh: time.Hour}, RetryPolicy{}, nil, nil, clk, observer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go service.runCacheRefreshWorker(ctx)
+	clk.BlockUntil(1)
+	start := clk.Now()
+
+	clk.Advance(30 * time.Minute)
+	service.UpdateIntervals(WorkerIntervals{CacheRefresh: time.Minute})
+
+	// The worker switches asynchronously, so time moves in small steps
+	// until it refreshes.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		clk.Advance(time.Second)
+		select {
+		case <-refreshed:
+			if elapsed := clk.Since(start); elapsed >= time.Hour {
+				t.Fatalf("worker refreshed after %s, on the old interval", elapsed)
+			}
+			if worker := <-ticks; worker != "cache_refresh" {
+				t.Errorf("Observed a tick of %q, want cache_refresh", worker)
+			}
+			return
+		case <-time.After(time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("worker did not pick up the new interval")
+		}
+	}
+}
+
+func TestWorkerIntervals_Defaults(t *testing.T) {
+	got := WorkerIntervals{Notify: time.Minute}.withDefaults()
+	want := DefaultWorkerIntervals()
+	want.Notify = time.Minute
+
+	if got != want {
+		t.Errorf("withDefaults() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"slices"
+	"time"
+)
+
+type Scope string
+
+const (
+	ScopeReadPrices     Scope = "read:prices"
+	ScopeAdminUsers     Scope = "admin:users"
+	ScopeAdminBroadcast Scope = "admin:broadcast"
+	ScopeAdminSystem    Scope = "admin:system"
+)
+
+var KnownScopes = []Scope{ScopeReadPrices, ScopeAdminUsers, ScopeAdminBroadcast, ScopeAdminSystem}
+
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []Scope    `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (k *APIKey) HasScope(scope Scope) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
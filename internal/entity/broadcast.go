@@ -0,0 +1,32 @@
+package entity
+
+import "time"
+
+type BroadcastStatus string
+
+const (
+	BroadcastDraft     BroadcastStatus = "draft"
+	BroadcastRunning   BroadcastStatus = "running"
+	BroadcastDone      BroadcastStatus = "done"
+	BroadcastCancelled BroadcastStatus = "cancelled"
+	BroadcastFailed    BroadcastStatus = "failed"
+)
+
+type Broadcast struct {
+	ID         string          `json:"id"`
+	Text       string          `json:"text"`
+	Status     BroadcastStatus `json:"status"`
+	CreatedBy  int64           `json:"created_by,omitempty"`
+	Recipients int             `json:"recipients"`
+	Sent       int             `json:"sent"`
+	Failed     int             `json:"failed"`
+	Blocked    int             `json:"blocked"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Processed returns the number of recipients already handled.
+func (b Broadcast) Processed() int {
+	return b.Sent + b.Failed + b.Blocked
+}
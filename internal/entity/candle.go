@@ -0,0 +1,75 @@
+package entity
+
+import "time"
+
+// CandleInterval is the period one candle covers.
+type CandleInterval string
+
+const (
+	Interval1m  CandleInterval = "1m"
+	Interval5m  CandleInterval = "5m"
+	Interval15m CandleInterval = "15m"
+	Interval30m CandleInterval = "30m"
+	Interval1h  CandleInterval = "1h"
+	Interval4h  CandleInterval = "4h"
+	Interval1d  CandleInterval = "1d"
+	Interval1w  CandleInterval = "1w"
+)
+
+// CandleIntervals lists the supported intervals, shortest first.
+var CandleIntervals = []CandleInterval{
+	Interval1m, Interval5m, Interval15m, Interval30m, Interval1h, Interval4h, Interval1d, Interval1w,
+}
+
+func ParseCandleInterval(s string) (CandleInterval, bool) {
+	for _, interval := range CandleIntervals {
+		if string(interval) == s {
+			return interval, true
+		}
+	}
+	return "", false
+}
+
+func (i CandleInterval) Duration() time.Duration {
+	switch i {
+	case Interval1m:
+		return time.Minute
+	case Interval5m:
+		return 5 * time.Minute
+	case Interval15m:
+		return 15 * time.Minute
+	case Interval30m:
+		return 30 * time.Minute
+	case Interval1h:
+		return time.Hour
+	case Interval4h:
+		return 4 * time.Hour
+	case Interval1d:
+		return 24 * time.Hour
+	case Interval1w:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// Truncate returns the open time of the candle that contains t. Candles
+// are aligned in UTC and weekly ones open on Monday, as on the exchange.
+func (i CandleInterval) Truncate(t time.Time) time.Time {
+	// Durations are counted from January 1 of year 1, a Monday.
+	return t.UTC().Truncate(i.Duration())
+}
+
+// Candle is the open, high, low and close price and the traded volume of a
+// symbol over one interval starting at OpenTime. Like Price, the values
+// are decimal strings exactly as the exchange reported them.
+type Candle struct {
+	Symbol   CurrencyName   `json:"symbol"`
+	Interval CandleInterval `json:"interval"`
+	OpenTime time.Time      `json:"open_time"`
+	Open     string         `json:"open"`
+	High     string         `json:"high"`
+	Low      string         `json:"low"`
+	Close    string         `json:"close"`
+	Volume   string         `json:"volume"`
+}
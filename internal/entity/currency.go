@@ -1,5 +1,10 @@
 package entity
 
+import (
+	"strconv"
+	"strings"
+)
+
 type CurrencyName string
 
 const (
@@ -16,9 +21,95 @@ type Price struct {
 	Symbol  CurrencyName `json:"symbol"`
 	Price   string       `json:"price"`
 	Updated string       `json:"timestamp"`
+	// Stale is set when the price comes from the cache after it should have
+	// been refreshed, so it may be outdated.
+	Stale bool `json:"stale,omitempty"`
+	Ticker
+}
+
+// Ticker is the market data the exchange reports along with a price. Values
+// are decimal strings as the exchange sends them; an empty one is unknown.
+type Ticker struct {
+	// Change24h is the relative change over 24 hours: 0.0123 is +1.23%.
+	Change24h string `json:"change_24h,omitempty"`
+	High24h   string `json:"high_24h,omitempty"`
+	Low24h    string `json:"low_24h,omitempty"`
+	Volume24h string `json:"volume_24h,omitempty"`
+	// Bid and Ask are the best prices on the order book.
+	Bid string `json:"bid,omitempty"`
+	Ask string `json:"ask,omitempty"`
+}
+
+// ChangePercent returns the 24 hour change in percent.
+func (t Ticker) ChangePercent() (float64, bool) {
+	change, err := strconv.ParseFloat(t.Change24h, 64)
+	if err != nil {
+		return 0, false
+	}
+	return change * 100, true
+}
+
+// Spread returns the difference between the ask and the bid with the
+// precision of the quotes.
+func (t Ticker) Spread() (string, bool) {
+	bid, err := strconv.ParseFloat(t.Bid, 64)
+	if err != nil {
+		return "", false
+	}
+	ask, err := strconv.ParseFloat(t.Ask, 64)
+	if err != nil {
+		return "", false
+	}
+
+	return strconv.FormatFloat(ask-bid, 'f', max(decimals(t.Bid), decimals(t.Ask)), 64), true
+}
+
+// decimals counts the digits after the decimal point of a decimal string.
+func decimals(s string) int {
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
 }
 
 type PriceResponse struct {
 	BTC *Price `json:"BTC"`
 	ETH *Price `json:"ETH"`
 }
+
+// Set stores price in the field of its symbol. Unknown symbols are ignored.
+func (r *PriceResponse) Set(price *Price) {
+	switch price.Symbol {
+	case BTC:
+		r.BTC = price
+	case ETH:
+		r.ETH = price
+	}
+}
+
+// Get returns the price of symbol, or nil when there is none.
+func (r *PriceResponse) Get(symbol CurrencyName) *Price {
+	switch symbol {
+	case BTC:
+		return r.BTC
+	case ETH:
+		return r.ETH
+	}
+	return nil
+}
+
+// List returns the prices present, ordered by symbol.
+func (r *PriceResponse) List() []*Price {
+	var list []*Price
+	for _, price := range []*Price{r.BTC, r.ETH} {
+		if price != nil {
+			list = append(list, price)
+		}
+	}
+	return list
+}
+
+// Stale reports whether any of the prices may be outdated.
+func (r *PriceResponse) Stale() bool {
+	return (r.BTC != nil && r.BTC.Stale) || (r.ETH != nil && r.ETH.Stale)
+}
@@ -0,0 +1,52 @@
+package entity
+
+import "time"
+
+type DigestPeriod string
+
+const (
+	DigestOff    DigestPeriod = "off"
+	DigestDaily  DigestPeriod = "daily"
+	DigestWeekly DigestPeriod = "weekly"
+)
+
+func ParseDigestPeriod(s string) (DigestPeriod, bool) {
+	switch DigestPeriod(s) {
+	case DigestOff, DigestDaily, DigestWeekly:
+		return DigestPeriod(s), true
+	default:
+		return "", false
+	}
+}
+
+// Duration returns the window covered by a digest of this period.
+func (p DigestPeriod) Duration() time.Duration {
+	switch p {
+	case DigestDaily:
+		return 24 * time.Hour
+	case DigestWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+type OHLC struct {
+	Symbol    CurrencyName `json:"symbol"`
+	Open      float64      `json:"open"`
+	High      float64      `json:"high"`
+	Low       float64      `json:"low"`
+	Close     float64      `json:"close"`
+	ChangePct float64      `json:"change_pct"`
+	Samples   int          `json:"samples"`
+}
+
+// Digest summarizes the market over a period. It has no alert hits
+// section yet: the bot does not record price alerts.
+type Digest struct {
+	Period  DigestPeriod `json:"period"`
+	From    time.Time    `json:"from"`
+	To      time.Time    `json:"to"`
+	Symbols []*OHLC      `json:"symbols"`
+	Movers  []*OHLC      `json:"movers"`
+}
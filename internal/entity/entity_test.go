@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"math"
 	"testing"
 	"time"
 )
@@ -59,6 +60,36 @@ func TestPriceValidation(t *testing.T) {
 	}
 }
 
+func TestTicker(t *testing.T) {
+	tests := []struct {
+		name       string
+		ticker     Ticker
+		wantChange float64
+		changeOK   bool
+		wantSpread string
+		spreadOK   bool
+	}{
+		{"Full", Ticker{Change24h: "0.0123", Bid: "60000.1", Ask: "60000.25"}, 1.23, true, "0.15", true},
+		{"Falling", Ticker{Change24h: "-0.05", Bid: "3000", Ask: "3001"}, -5, true, "1", true},
+		{"Unknown", Ticker{}, 0, false, "", false},
+		{"No ask", Ticker{Bid: "1.5"}, 0, false, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, ok := tt.ticker.ChangePercent()
+			if ok != tt.changeOK || math.Abs(change-tt.wantChange) > 1e-9 {
+				t.Errorf("ChangePercent() = %v, %t, want %v, %t", change, ok, tt.wantChange, tt.changeOK)
+			}
+
+			spread, ok := tt.ticker.Spread()
+			if ok != tt.spreadOK || spread != tt.wantSpread {
+				t.Errorf("Spread() = %q, %t, want %q, %t", spread, ok, tt.wantSpread, tt.spreadOK)
+			}
+		})
+	}
+}
+
 func TestUserActivation(t *testing.T) {
 	user := &User{
 		ChatID:   12345,
@@ -76,3 +107,117 @@ func TestUserActivation(t *testing.T) {
 		t.Errorf("User should be inactive")
 	}
 }
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    RateLimit
+		wantErr bool
+	}{
+		{"5/1m", RateLimit{Limit: 5, Window: time.Minute}, false},
+		{" 20/30s ", RateLimit{Limit: 20, Window: 30 * time.Second}, false},
+		{"off", RateLimit{}, false},
+		{"", RateLimit{}, false},
+		{"5", RateLimit{}, true},
+		{"0/1m", RateLimit{}, true},
+		{"5/soon", RateLimit{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRateLimit(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRateLimit(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRateLimit(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCommandRateLimits(t *testing.T) {
+	got, err := ParseCommandRateLimits([]string{"/Price=5/1m", "/help=off"})
+	if err != nil {
+		t.Fatalf("ParseCommandRateLimits failed: %v", err)
+	}
+	if got["/price"] != (RateLimit{Limit: 5, Window: time.Minute}) || got["/help"].Enabled() {
+		t.Errorf("ParseCommandRateLimits = %v", got)
+	}
+
+	if _, err := ParseCommandRateLimits([]string{"price=5/1m"}); err == nil {
+		t.Error("Expected an error for a command without a slash")
+	}
+}
+
+func TestGrowthBucketTruncate(t *testing.T) {
+	// Thursday, 13 November 2025.
+	at := time.Date(2025, 11, 13, 1, 30, 0, 0, time.FixedZone("UTC+3", 3*3600))
+
+	tests := []struct {
+		bucket GrowthBucket
+		want   time.Time
+	}{
+		{GrowthDay, time.Date(2025, 11, 12, 0, 0, 0, 0, time.UTC)},
+		{GrowthWeek, time.Date(2025, 11, 10, 0, 0, 0, 0, time.UTC)},
+		{GrowthMonth, time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.bucket.Truncate(at); !got.Equal(tt.want) {
+			t.Errorf("%s.Truncate(%v) = %v, want %v", tt.bucket, at, got, tt.want)
+		}
+	}
+}
+
+func TestCandleIntervalTruncate(t *testing.T) {
+	// Thursday, 13 November 2025.
+	at := time.Date(2025, 11, 13, 1, 37, 20, 0, time.FixedZone("UTC+3", 3*3600))
+
+	tests := []struct {
+		interval CandleInterval
+		want     time.Time
+	}{
+		{Interval1m, time.Date(2025, 11, 12, 22, 37, 0, 0, time.UTC)},
+		{Interval15m, time.Date(2025, 11, 12, 22, 30, 0, 0, time.UTC)},
+		{Interval4h, time.Date(2025, 11, 12, 20, 0, 0, 0, time.UTC)},
+		{Interval1d, time.Date(2025, 11, 12, 0, 0, 0, 0, time.UTC)},
+		{Interval1w, time.Date(2025, 11, 10, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.interval.Truncate(at); !got.Equal(tt.want) {
+			t.Errorf("%s.Truncate(%v) = %v, want %v", tt.interval, at, got, tt.want)
+		}
+	}
+
+	if _, ok := ParseCandleInterval("2h"); ok {
+		t.Error("ParseCandleInterval(2h) succeeded, want unsupported")
+	}
+}
+
+func TestGrowthPoints(t *testing.T) {
+	day := time.Date(2025, 11, 10, 0, 0, 0, 0, time.UTC)
+	signups := []time.Time{
+		day.Add(-time.Hour),
+		day.Add(time.Hour),
+		day.Add(2 * time.Hour),
+		day.Add(26 * time.Hour),
+	}
+
+	points := GrowthPoints(day.Add(12*time.Hour), day.Add(50*time.Hour), GrowthDay, signups)
+
+	want := []UserGrowthPoint{
+		{PeriodStart: day, NewUsers: 2, TotalUsers: 3},
+		{PeriodStart: day.AddDate(0, 0, 1), NewUsers: 1, TotalUsers: 4},
+		{PeriodStart: day.AddDate(0, 0, 2), NewUsers: 0, TotalUsers: 4},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("GrowthPoints returned %d points, want %d", len(points), len(want))
+	}
+	for i, point := range points {
+		if !point.PeriodStart.Equal(want[i].PeriodStart) || point.NewUsers != want[i].NewUsers || point.TotalUsers != want[i].TotalUsers {
+			t.Errorf("point %d = %+v, want %+v", i, *point, want[i])
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+// LeaderStatus tells whether this replica is the one running the scheduled
+// workers.
+type LeaderStatus struct {
+	InstanceID string     `json:"instance_id"`
+	Leader     bool       `json:"leader"`
+	Since      *time.Time `json:"since,omitempty"`
+}
@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit allows Limit events per Window. The zero value allows
+// everything.
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Enabled reports whether the limit restricts anything.
+func (r RateLimit) Enabled() bool {
+	return r.Limit > 0 && r.Window > 0
+}
+
+func (r RateLimit) String() string {
+	if !r.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", r.Limit, r.Window)
+}
+
+// ParseRateLimit parses "LIMIT/WINDOW", e.g. "5/1m", or "off".
+func ParseRateLimit(s string) (RateLimit, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "off") || s == "" {
+		return RateLimit{}, nil
+	}
+
+	limit, window, ok := strings.Cut(s, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("rate limit %q must look like 5/1m", s)
+	}
+
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 {
+		return RateLimit{}, fmt.Errorf("rate limit %q: limit must be a positive number", s)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q: window must be a positive duration", s)
+	}
+
+	return RateLimit{Limit: n, Window: d}, nil
+}
+
+// ParseCommandRateLimits parses "COMMAND=LIMIT/WINDOW" entries, e.g.
+// "/price=5/1m".
+func ParseCommandRateLimits(entries []string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit, len(entries))
+
+	for _, entry := range entries {
+		command, raw, ok := strings.Cut(entry, "=")
+		command = strings.ToLower(strings.TrimSpace(command))
+		if !ok || !strings.HasPrefix(command, "/") {
+			return nil, fmt.Errorf("command rate limit %q must look like /price=5/1m", entry)
+		}
+
+		limit, err := ParseRateLimit(raw)
+		if err != nil {
+			return nil, err
+		}
+		limits[command] = limit
+	}
+
+	return limits, nil
+}
+
+// RateDecision is the outcome of rate limiting a command.
+type RateDecision struct {
+	Allowed bool
+	// Warn is set on the first refused command of a window; only that one
+	// deserves a reply.
+	Warn bool
+	// BannedUntil is set when this command got the chat banned.
+	BannedUntil *time.Time
+}
@@ -8,7 +8,7 @@ type TelegramUpdate struct {
 type TelegramMessage struct {
 	MessageID int          `json:"message_id"`
 	From      TelegramUser `json:"from"`
-	ChatID    TelegramChat `json:"chat_id"`
+	ChatID    TelegramChat `json:"chat"`
 	Date      int          `json:"date"`
 	Text      string       `json:"text"`
 }
@@ -1,7 +1,99 @@
 package entity
 
+import "time"
+
 type User struct {
-	ChatID   int64  `json:"chat_id"`
-	Username string `json:"username"`
-	Active   bool   `json:"active"`
+	ChatID       int64        `json:"chat_id"`
+	Username     string       `json:"username"`
+	Active       bool         `json:"active"`
+	Digest       DigestPeriod `json:"digest"`
+	Timezone     string       `json:"timezone"`
+	LastDigestAt *time.Time   `json:"last_digest_at,omitempty"`
+	BannedUntil  *time.Time   `json:"banned_until,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// IsBanned reports whether the bot ignores the user at now.
+func (u *User) IsBanned(now time.Time) bool {
+	return u.BannedUntil != nil && now.Before(*u.BannedUntil)
+}
+
+// UserFilter narrows user listings. Zero values mean "no filter".
+type UserFilter struct {
+	Active   *bool
+	Digest   DigestPeriod
+	Username string
+	Limit    int
+	Offset   int
+}
+
+type GrowthBucket string
+
+const (
+	GrowthDay   GrowthBucket = "day"
+	GrowthWeek  GrowthBucket = "week"
+	GrowthMonth GrowthBucket = "month"
+)
+
+// Valid reports whether b is one of the known buckets.
+func (b GrowthBucket) Valid() bool {
+	return b == GrowthDay || b == GrowthWeek || b == GrowthMonth
+}
+
+// Truncate returns the start of the bucket holding t. Buckets start at
+// midnight UTC and weeks on Monday, like Postgres' date_trunc.
+func (b GrowthBucket) Truncate(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+
+	switch b {
+	case GrowthWeek:
+		day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case GrowthMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Next returns the start of the bucket following the one starting at start.
+func (b GrowthBucket) Next(start time.Time) time.Time {
+	switch b {
+	case GrowthWeek:
+		return start.AddDate(0, 0, 7)
+	case GrowthMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+type UserGrowthPoint struct {
+	PeriodStart time.Time `json:"period_start"`
+	NewUsers    int       `json:"new_users"`
+	TotalUsers  int       `json:"total_users"`
+}
+
+// GrowthPoints returns one point per bucket from the one holding from to
+// the one holding to. signups are the users' creation times in ascending
+// order; those before from only count towards the totals.
+func GrowthPoints(from, to time.Time, bucket GrowthBucket, signups []time.Time) []*UserGrowthPoint {
+	points := make([]*UserGrowthPoint, 0)
+	for start := bucket.Truncate(from); !start.After(to); start = bucket.Next(start) {
+		points = append(points, &UserGrowthPoint{PeriodStart: start})
+	}
+
+	i := 0
+	for _, point := range points {
+		end := bucket.Next(point.PeriodStart)
+		for ; i < len(signups) && signups[i].Before(end); i++ {
+			if !signups[i].Before(point.PeriodStart) {
+				point.NewUsers++
+			}
+		}
+		point.TotalUsers = i
+	}
+
+	return points
 }
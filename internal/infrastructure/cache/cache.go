@@ -0,0 +1,47 @@
+// Package cache stores the latest price of each symbol so the exchange is
+// not queried on every request. PriceCache keeps them in process memory;
+// RedisCache shares them between replicas.
+package cache
+
+import (
+	"context"
+	"time"
+
+	"tgBotFinal/internal/entity"
+)
+
+// Entry is a cached price and the time it was stored.
+type Entry struct {
+	Price    *entity.Price `json:"price"`
+	CachedAt time.Time     `json:"cached_at"`
+}
+
+// Age reports how long before now the entry was stored.
+func (e *Entry) Age(now time.Time) time.Duration {
+	return now.Sub(e.CachedAt)
+}
+
+// Store is the price cache used by cryptoClient.CachedClient. A store keeps
+// each price for its retention period; deciding whether an entry is fresh
+// or stale is up to the caller.
+type Store interface {
+	// Get returns the cached price of symbol, or nil when there is none or
+	// it is older than the retention period.
+	Get(ctx context.Context, symbol entity.CurrencyName) (*Entry, error)
+	Set(ctx context.Context, price *entity.Price) error
+	// Invalidate drops the cached price of symbol so the next Get misses.
+	Invalidate(ctx context.Context, symbol entity.CurrencyName) error
+
+	// StartRefresh claims the right to refresh symbol. It reports false when
+	// someone else is already refreshing it; that caller should WaitRefresh
+	// and read the result instead of querying the exchange.
+	StartRefresh(ctx context.Context, symbol entity.CurrencyName) (bool, error)
+	EndRefresh(ctx context.Context, symbol entity.CurrencyName)
+	// WaitRefresh blocks until the refresh of symbol in progress ends or ctx
+	// is done.
+	WaitRefresh(ctx context.Context, symbol entity.CurrencyName) error
+
+	// GetCacheAge reports the age of the oldest cached price, 0 when there
+	// are none.
+	GetCacheAge() time.Duration
+}
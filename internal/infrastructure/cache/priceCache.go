@@ -1,81 +1,114 @@
 package cache
 
 import (
+	"context"
 	"sync"
-	"tgBotFinal/internal/entity"
 	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/entity"
 )
 
+// PriceCache is the in-memory Store of a single replica.
 type PriceCache struct {
-	mu           sync.RWMutex
-	prices       *entity.PriceResponse
-	cacheAt      time.Time
-	ttl          time.Duration
-	isRefreshing bool
-	refreshMux   sync.Mutex
+	mu        sync.RWMutex
+	entries   map[entity.CurrencyName]*Entry
+	retention time.Duration
+	clock     clock.Clock
+
+	refreshMux sync.Mutex
+	// refreshing holds a channel per symbol being refreshed that is closed
+	// when the refresh ends.
+	refreshing map[entity.CurrencyName]chan struct{}
 }
 
-func NewPriceCache(ttl time.Duration) *PriceCache {
+func NewPriceCache(retention time.Duration, clk clock.Clock) *PriceCache {
 	return &PriceCache{
-		ttl:    ttl,
-		prices: &entity.PriceResponse{},
+		entries:    make(map[entity.CurrencyName]*Entry),
+		retention:  retention,
+		clock:      clk,
+		refreshing: make(map[entity.CurrencyName]chan struct{}),
 	}
 }
 
-func (c *PriceCache) Get() *entity.PriceResponse {
+func (c *PriceCache) Get(ctx context.Context, symbol entity.CurrencyName) (*Entry, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	if c.isExpired() {
-		return nil
+	entry, ok := c.entries[symbol]
+	if !ok || entry.Age(c.clock.Now()) > c.retention {
+		return nil, nil
 	}
 
-	return c.prices
+	return entry, nil
 }
 
-func (c *PriceCache) Set(prices *entity.PriceResponse) {
+func (c *PriceCache) Set(ctx context.Context, price *entity.Price) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.prices = prices
-	c.cacheAt = time.Now()
+	c.entries[price.Symbol] = &Entry{Price: price, CachedAt: c.clock.Now()}
+	return nil
 }
 
-func (c *PriceCache) IsExpired() bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.isExpired()
-}
+func (c *PriceCache) Invalidate(ctx context.Context, symbol entity.CurrencyName) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-func (c *PriceCache) isExpired() bool {
-	return time.Since(c.cacheAt) > c.ttl || c.prices == nil
+	delete(c.entries, symbol)
+	return nil
 }
 
-func (c *PriceCache) StartRefresh() bool {
+func (c *PriceCache) StartRefresh(ctx context.Context, symbol entity.CurrencyName) (bool, error) {
 	c.refreshMux.Lock()
 	defer c.refreshMux.Unlock()
 
-	if c.isRefreshing {
-		return false
+	if _, ok := c.refreshing[symbol]; ok {
+		return false, nil
 	}
 
-	c.isRefreshing = true
-	return true
+	c.refreshing[symbol] = make(chan struct{})
+	return true, nil
 }
 
-func (c *PriceCache) EndRefresh() {
+func (c *PriceCache) EndRefresh(ctx context.Context, symbol entity.CurrencyName) {
 	c.refreshMux.Lock()
 	defer c.refreshMux.Unlock()
-	c.isRefreshing = false
+
+	if done, ok := c.refreshing[symbol]; ok {
+		close(done)
+		delete(c.refreshing, symbol)
+	}
+}
+
+func (c *PriceCache) WaitRefresh(ctx context.Context, symbol entity.CurrencyName) error {
+	c.refreshMux.Lock()
+	done, ok := c.refreshing[symbol]
+	c.refreshMux.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (c *PriceCache) GetCacheAge() time.Duration {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	if c.cacheAt.IsZero() {
-		return 0
+	now := c.clock.Now()
+	var oldest time.Duration
+	for _, entry := range c.entries {
+		if age := entry.Age(now); age <= c.retention && age > oldest {
+			oldest = age
+		}
 	}
 
-	return time.Since(c.cacheAt)
+	return oldest
 }
@@ -1,81 +1,95 @@
 package cache
 
 import (
+	"context"
 	"testing"
-	"tgBotFinal/internal/entity"
 	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/entity"
 )
 
 func TestPriceCache(t *testing.T) {
-	cache := NewPriceCache(1 * time.Minute)
+	cache := NewPriceCache(1*time.Minute, clock.Real())
 
-	if cached := cache.Get(); cached != nil {
+	if cached := get(t, cache, entity.BTC); cached != nil {
 		t.Errorf("Empty cache should return nil")
 	}
 
-	prices := &entity.PriceResponse{
-		BTC: &entity.Price{
-			Symbol:  entity.BTC,
-			Price:   "50000.00",
-			Updated: time.Now().Format("2006-01-02 15:04:05"),
-		},
+	price := &entity.Price{
+		Symbol:  entity.BTC,
+		Price:   "50000.00",
+		Updated: time.Now().Format("2006-01-02 15:04:05"),
 	}
 
-	cache.Set(prices)
-	cached := cache.Get()
+	cache.Set(context.Background(), price)
+	cached := get(t, cache, entity.BTC)
 	if cached == nil {
-		t.Errorf("Cache should return prices after setting")
+		t.Fatalf("Cache should return prices after setting")
+	}
+
+	if cached.Price.Price != "50000.00" {
+		t.Errorf("Cached price = %v, want %v", cached.Price.Price, "50000.00")
+	}
+
+	if other := get(t, cache, entity.ETH); other != nil {
+		t.Errorf("Symbols should be cached independently, got %+v for ETH", other)
 	}
 
-	if cached.BTC.Price != "50000.00" {
-		t.Errorf("Cached price = %v, want %v", cached.BTC.Price, "50000.00")
+	cache.Invalidate(context.Background(), entity.BTC)
+	if get(t, cache, entity.BTC) != nil {
+		t.Errorf("Invalidated price should not be returned")
 	}
 }
 
 func TestPriceCacheExpiration(t *testing.T) {
-	cache := NewPriceCache(1 * time.Millisecond)
+	clk := clock.NewFake(time.Now())
+	cache := NewPriceCache(time.Minute, clk)
 
-	prices := &entity.PriceResponse{
-		BTC: &entity.Price{
-			Symbol: entity.BTC,
-			Price:  "50000.00",
-		},
+	price := &entity.Price{
+		Symbol: entity.BTC,
+		Price:  "50000.00",
 	}
 
-	cache.Set(prices)
+	cache.Set(context.Background(), price)
 
-	if cache.Get() == nil {
-		t.Errorf("Prices should be available before TTL")
+	clk.Advance(time.Minute)
+	if get(t, cache, entity.BTC) == nil {
+		t.Errorf("Prices should be available until the TTL")
+	}
+	if age := cache.GetCacheAge(); age != time.Minute {
+		t.Errorf("GetCacheAge() = %s, want 1m0s", age)
 	}
 
-	time.Sleep(150 * time.Millisecond)
+	clk.Advance(time.Nanosecond)
 
-	if cache.Get() != nil {
+	if get(t, cache, entity.BTC) != nil {
 		t.Errorf("Prices should be expired after TTL")
 	}
+	if age := cache.GetCacheAge(); age != 0 {
+		t.Errorf("GetCacheAge() = %s, want 0 without prices", age)
+	}
 }
 
 func TestPriceCacheConccurrentAccess(t *testing.T) {
-	cache := NewPriceCache(1 * time.Minute)
+	cache := NewPriceCache(1*time.Minute, clock.Real())
 	done := make(chan bool)
 
 	for i := 0; i < 10; i++ {
 		go func() {
-			prices := &entity.PriceResponse{
-				BTC: &entity.Price{
-					Symbol: entity.BTC,
-					Price:  string(rune(50000 + i)),
-				},
+			price := &entity.Price{
+				Symbol: entity.BTC,
+				Price:  string(rune(50000 + i)),
 			}
 
-			cache.Set(prices)
+			cache.Set(context.Background(), price)
 			done <- true
 		}()
 	}
 
 	for i := 0; i < 10; i++ {
 		go func() {
-			cache.Get()
+			get(t, cache, entity.BTC)
 			done <- true
 		}()
 	}
@@ -84,7 +98,46 @@ func TestPriceCacheConccurrentAccess(t *testing.T) {
 		<-done
 	}
 
-	if cache.Get() == nil {
+	if get(t, cache, entity.BTC) == nil {
 		t.Errorf("Cache should have a value after concurrent access")
 	}
 }
+
+func TestPriceCacheRefresh(t *testing.T) {
+	ctx := context.Background()
+	cache := NewPriceCache(time.Minute, clock.Real())
+
+	if ok, _ := cache.StartRefresh(ctx, entity.BTC); !ok {
+		t.Fatal("first StartRefresh should win")
+	}
+	if ok, _ := cache.StartRefresh(ctx, entity.BTC); ok {
+		t.Fatal("second StartRefresh should lose while BTC is refreshing")
+	}
+	if ok, _ := cache.StartRefresh(ctx, entity.ETH); !ok {
+		t.Fatal("ETH should refresh independently of BTC")
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- cache.WaitRefresh(ctx, entity.BTC) }()
+
+	cache.EndRefresh(ctx, entity.BTC)
+
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Errorf("WaitRefresh() = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitRefresh did not return after EndRefresh")
+	}
+}
+
+func get(t *testing.T, cache Store, symbol entity.CurrencyName) *Entry {
+	t.Helper()
+
+	entry, err := cache.Get(context.Background(), symbol)
+	if err != nil {
+		t.Errorf("Get() error = %v", err)
+	}
+	return entry
+}
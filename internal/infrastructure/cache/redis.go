@@ -0,0 +1,258 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/entity"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// refreshLockTTL bounds how long a crashed replica can block refreshes.
+	// It must exceed the time a price fetch takes.
+	refreshLockTTL = 15 * time.Second
+	// refreshPollInterval is how often WaitRefresh re-checks the lock in case
+	// the unlock message was missed.
+	refreshPollInterval = 100 * time.Millisecond
+)
+
+// unlockScript deletes the refresh lock only if this replica still holds it.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// RedisCache is a Store shared by every replica using the same Redis and key
+// prefix. Each price is a key that expires after the retention period, the
+// refresh right for a symbol is a lock key so only one replica queries the
+// exchange for it at a time, and changes are announced on a pub/sub channel.
+//
+// Each replica also keeps the last prices it read so most lookups don't
+// reach Redis. A symbol's copy is dropped as soon as another replica
+// announces a change to it.
+type RedisCache struct {
+	client    redis.UniversalClient
+	prefix    string
+	channel   string
+	retention time.Duration
+	clock     clock.Clock
+	id        string
+	logger    *slog.Logger
+	pubsub    *redis.PubSub
+
+	mu    sync.Mutex
+	local map[entity.CurrencyName]*Entry
+	// changed is closed and replaced whenever a change is announced.
+	changed chan struct{}
+}
+
+// NewRedisCache subscribes to the change channel of prefix. Close stops the
+// subscription. clk dates the prices; their keys still expire on the Redis
+// server's clock.
+func NewRedisCache(client redis.UniversalClient, prefix string, retention time.Duration, clk clock.Clock, logger *slog.Logger) *RedisCache {
+	c := &RedisCache{
+		client:    client,
+		prefix:    prefix,
+		channel:   prefix + ":prices:events",
+		retention: retention,
+		clock:     clk,
+		id:        randomID(),
+		logger:    logger.With(slog.String("component", "cache.RedisCache")),
+		local:     make(map[entity.CurrencyName]*Entry),
+		changed:   make(chan struct{}),
+	}
+
+	c.pubsub = client.Subscribe(context.Background(), c.channel)
+	go c.listen()
+
+	return c
+}
+
+func (c *RedisCache) Get(ctx context.Context, symbol entity.CurrencyName) (*Entry, error) {
+	if entry := c.localEntry(symbol); entry != nil {
+		return entry, nil
+	}
+
+	raw, err := c.client.Get(ctx, c.key(symbol)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis get %s price: %w", symbol, err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, fmt.Errorf("decode cached %s price: %w", symbol, err)
+	}
+	if entry.Price == nil || entry.Age(c.clock.Now()) > c.retention {
+		return nil, nil
+	}
+
+	c.setLocal(symbol, &entry)
+	return &entry, nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, price *entity.Price) error {
+	entry := &Entry{Price: price, CachedAt: c.clock.Now()}
+
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode %s price: %w", price.Symbol, err)
+	}
+
+	if err := c.client.Set(ctx, c.key(price.Symbol), raw, c.retention).Err(); err != nil {
+		return fmt.Errorf("redis set %s price: %w", price.Symbol, err)
+	}
+
+	c.setLocal(price.Symbol, entry)
+	c.publish(ctx, "set", price.Symbol)
+	return nil
+}
+
+func (c *RedisCache) Invalidate(ctx context.Context, symbol entity.CurrencyName) error {
+	if err := c.client.Del(ctx, c.key(symbol)).Err(); err != nil {
+		return fmt.Errorf("redis delete %s price: %w", symbol, err)
+	}
+
+	c.setLocal(symbol, nil)
+	c.publish(ctx, "invalidate", symbol)
+	return nil
+}
+
+func (c *RedisCache) StartRefresh(ctx context.Context, symbol entity.CurrencyName) (bool, error) {
+	ok, err := c.client.SetNX(ctx, c.lockKey(symbol), c.id, refreshLockTTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis lock %s refresh: %w", symbol, err)
+	}
+	return ok, nil
+}
+
+func (c *RedisCache) EndRefresh(ctx context.Context, symbol entity.CurrencyName) {
+	// The lock must be released even if the refresh was cancelled.
+	ctx = context.WithoutCancel(ctx)
+
+	if err := unlockScript.Run(ctx, c.client, []string{c.lockKey(symbol)}, c.id).Err(); err != nil {
+		c.logger.WarnContext(ctx, "Failed to release refresh lock", "symbol", symbol, "error", err)
+	}
+	c.publish(ctx, "unlock", symbol)
+}
+
+func (c *RedisCache) WaitRefresh(ctx context.Context, symbol entity.CurrencyName) error {
+	for {
+		c.mu.Lock()
+		changed := c.changed
+		c.mu.Unlock()
+
+		n, err := c.client.Exists(ctx, c.lockKey(symbol)).Result()
+		if err != nil {
+			return fmt.Errorf("redis check %s refresh lock: %w", symbol, err)
+		}
+		if n == 0 {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-c.clock.After(refreshPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// GetCacheAge only looks at the prices this replica has read.
+func (c *RedisCache) GetCacheAge() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	var oldest time.Duration
+	for _, entry := range c.local {
+		if age := entry.Age(now); age <= c.retention && age > oldest {
+			oldest = age
+		}
+	}
+
+	return oldest
+}
+
+// Close stops listening for changes from other replicas.
+func (c *RedisCache) Close() error {
+	return c.pubsub.Close()
+}
+
+// listen drops the local copy of a symbol when another replica changes it
+// and wakes up WaitRefresh on every message.
+func (c *RedisCache) listen() {
+	for msg := range c.pubsub.Channel() {
+		fields := strings.Fields(msg.Payload)
+		if len(fields) != 3 {
+			continue
+		}
+		event, symbol, sender := fields[0], entity.CurrencyName(fields[1]), fields[2]
+
+		c.mu.Lock()
+		if sender != c.id && event != "unlock" {
+			delete(c.local, symbol)
+		}
+		close(c.changed)
+		c.changed = make(chan struct{})
+		c.mu.Unlock()
+	}
+}
+
+func (c *RedisCache) publish(ctx context.Context, event string, symbol entity.CurrencyName) {
+	payload := event + " " + string(symbol) + " " + c.id
+	if err := c.client.Publish(ctx, c.channel, payload).Err(); err != nil {
+		c.logger.WarnContext(ctx, "Failed to announce cache change", "event", event, "symbol", symbol, "error", err)
+	}
+}
+
+func (c *RedisCache) key(symbol entity.CurrencyName) string {
+	return c.prefix + ":price:" + string(symbol)
+}
+
+func (c *RedisCache) lockKey(symbol entity.CurrencyName) string {
+	return c.key(symbol) + ":lock"
+}
+
+func (c *RedisCache) localEntry(symbol entity.CurrencyName) *Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.local[symbol]
+	if entry == nil || entry.Age(c.clock.Now()) > c.retention {
+		return nil
+	}
+	return entry
+}
+
+func (c *RedisCache) setLocal(symbol entity.CurrencyName, entry *Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry == nil {
+		delete(c.local, symbol)
+		return
+	}
+	c.local[symbol] = entry
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/entity"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newRedisReplicas returns caches that share one in-process Redis, as
+// replicas of the bot would, and the Redis itself.
+func newRedisReplicas(t *testing.T, n int, ttl time.Duration, clk clock.Clock) ([]*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+
+	caches := make([]*RedisCache, n)
+	for i := range caches {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		caches[i] = NewRedisCache(client, "test", ttl, clk, slog.Default())
+		t.Cleanup(func() {
+			caches[i].Close()
+			client.Close()
+		})
+	}
+
+	// Subscriptions are set up asynchronously.
+	waitFor(t, func() bool { return mr.PubSubNumSub("test:prices:events")["test:prices:events"] == n })
+	return caches, mr
+}
+
+func btcPrice(price string) *entity.Price {
+	return &entity.Price{Symbol: entity.BTC, Price: price}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRedisCache_SharedBetweenReplicas(t *testing.T) {
+	ctx := context.Background()
+	caches, _ := newRedisReplicas(t, 2, time.Minute, clock.Real())
+	a, b := caches[0], caches[1]
+
+	if got := get(t, b, entity.BTC); got != nil {
+		t.Fatalf("empty cache returned %+v", got)
+	}
+
+	if err := a.Set(ctx, btcPrice("50000")); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(t, b, entity.BTC); got == nil || got.Price.Price != "50000" {
+		t.Fatalf("replica b read %+v, want the price set by a", got)
+	}
+
+	// b now holds a local copy; a's update must replace it.
+	if err := a.Set(ctx, btcPrice("51000")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		got := get(t, b, entity.BTC)
+		return got != nil && got.Price.Price == "51000"
+	})
+
+	if err := a.Invalidate(ctx, entity.BTC); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return get(t, b, entity.BTC) == nil })
+}
+
+func TestRedisCache_Expiration(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	caches, mr := newRedisReplicas(t, 1, time.Minute, clk)
+	cache := caches[0]
+
+	if err := cache.Set(context.Background(), btcPrice("50000")); err != nil {
+		t.Fatal(err)
+	}
+	if get(t, cache, entity.BTC) == nil {
+		t.Fatal("prices should be available before TTL")
+	}
+
+	clk.Advance(30 * time.Second)
+	if age := cache.GetCacheAge(); age != 30*time.Second {
+		t.Errorf("GetCacheAge() = %s, want 30s", age)
+	}
+
+	// Redis expires the key on its own clock, the local copy on clk.
+	clk.Advance(31 * time.Second)
+	mr.FastForward(61 * time.Second)
+
+	if get(t, cache, entity.BTC) != nil {
+		t.Error("prices should be expired after TTL")
+	}
+}
+
+func TestRedisCache_RefreshLock(t *testing.T) {
+	ctx := context.Background()
+	caches, _ := newRedisReplicas(t, 2, time.Minute, clock.Real())
+	a, b := caches[0], caches[1]
+
+	if ok, err := a.StartRefresh(ctx, entity.BTC); !ok || err != nil {
+		t.Fatalf("a.StartRefresh() = %t, %v; want the lock", ok, err)
+	}
+	if ok, err := b.StartRefresh(ctx, entity.BTC); ok || err != nil {
+		t.Fatalf("b.StartRefresh() = %t, %v; want the lock to be taken", ok, err)
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- b.WaitRefresh(ctx, entity.BTC) }()
+
+	select {
+	case err := <-waited:
+		t.Fatalf("WaitRefresh returned %v while a was refreshing", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// b must not be able to release a's lock.
+	b.EndRefresh(ctx, entity.BTC)
+	if ok, _ := b.StartRefresh(ctx, entity.BTC); ok {
+		t.Fatal("b released the lock held by a")
+	}
+
+	a.EndRefresh(ctx, entity.BTC)
+
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Fatalf("WaitRefresh() = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("WaitRefresh did not return after the refresh ended")
+	}
+
+	if ok, err := b.StartRefresh(ctx, entity.BTC); !ok || err != nil {
+		t.Errorf("b.StartRefresh() after release = %t, %v; want the lock", ok, err)
+	}
+}
+
+func TestRedisCache_WaitRefreshPollsLock(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC))
+	caches, mr := newRedisReplicas(t, 2, time.Minute, clk)
+	a, b := caches[0], caches[1]
+
+	if ok, err := a.StartRefresh(ctx, entity.BTC); !ok || err != nil {
+		t.Fatalf("a.StartRefresh() = %t, %v; want the lock", ok, err)
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- b.WaitRefresh(ctx, entity.BTC) }()
+	clk.BlockUntil(1)
+
+	// The lock goes away without an unlock message, as when it expires.
+	mr.Del(a.lockKey(entity.BTC))
+	clk.Advance(refreshPollInterval)
+
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Fatalf("WaitRefresh() = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("WaitRefresh did not notice the lock was gone")
+	}
+}
@@ -0,0 +1,177 @@
+package bybit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/infrastructure/metrics"
+	"tgBotFinal/internal/infrastructure/tracing"
+)
+
+// quoteCurrency is the currency the tracked symbols are quoted in, as in
+// BTCUSDT.
+const quoteCurrency = "USDT"
+
+// batchLabel is the symbol label of the metrics of batch requests.
+const batchLabel = "ALL"
+
+func (c *Client) getBatch(ctx context.Context) (map[entity.CurrencyName]*entity.Price, error) {
+	ctx, span := tracing.Start(ctx, "bybit.GetBatch")
+
+	start := time.Now()
+	prices, err := c.fetchBatch(ctx)
+	tracing.End(span, err)
+
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	metrics.BybitDuration.WithLabelValues(batchLabel).Observe(time.Since(start).Seconds())
+	metrics.BybitRequests.WithLabelValues(batchLabel, result).Inc()
+
+	return prices, err
+}
+
+// fetchBatch requests the tickers of every spot symbol, several hundred of
+// them, and keeps those of the tracked symbols.
+func (c *Client) fetchBatch(ctx context.Context) (map[entity.CurrencyName]*entity.Price, error) {
+	tracked := make(map[string]entity.CurrencyName, len(entity.TokenList))
+	for _, symbol := range entity.TokenList {
+		tracked[string(symbol)+quoteCurrency] = symbol
+	}
+
+	resp, err := c.get(ctx, c.baseURL+"?category=spot")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	list, err := decodeTickers(resp.Body, func(symbol string) bool {
+		_, ok := tracked[symbol]
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[entity.CurrencyName]*entity.Price, len(list))
+	for i := range list {
+		symbol := tracked[list[i].Symbol]
+		prices[symbol] = c.price(symbol, &list[i])
+	}
+
+	return prices, nil
+}
+
+// decodeTickers reads a tickers response one token at a time, so a list of
+// every ticker on the exchange is never held in memory, and returns the
+// tickers keep accepts.
+func decodeTickers(r io.Reader, keep func(symbol string) bool) ([]TickerInfo, error) {
+	dec := json.NewDecoder(r)
+
+	var (
+		retCode int
+		retMsg  string
+		list    []TickerInfo
+	)
+
+	err := decodeObject(dec, func(key string) error {
+		switch key {
+		case "retCode":
+			return dec.Decode(&retCode)
+		case "retMsg":
+			return dec.Decode(&retMsg)
+		case "result":
+			return decodeObject(dec, func(key string) error {
+				if key != "list" {
+					return skipValue(dec)
+				}
+				return decodeArray(dec, func() error {
+					var info TickerInfo
+					if err := dec.Decode(&info); err != nil {
+						return err
+					}
+					if keep(info.Symbol) {
+						list = append(list, info)
+					}
+					return nil
+				})
+			})
+		default:
+			return skipValue(dec)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decode tickers: %w", err)
+	}
+
+	if retCode != 0 {
+		return nil, fmt.Errorf("bybit API error: %s (code: %d)", retMsg, retCode)
+	}
+
+	return list, nil
+}
+
+// decodeObject calls field for each key of the next JSON object, which must
+// decode the value. null counts as an empty object.
+func decodeObject(dec *json.Decoder, field func(key string) error) error {
+	if open, err := openDelim(dec, '{'); err != nil || !open {
+		return err
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := field(tok.(string)); err != nil {
+			return err
+		}
+	}
+
+	_, err := dec.Token()
+	return err
+}
+
+// decodeArray calls elem for each element of the next JSON array, which
+// must decode the element. null counts as an empty array.
+func decodeArray(dec *json.Decoder, elem func() error) error {
+	if open, err := openDelim(dec, '['); err != nil || !open {
+		return err
+	}
+
+	for dec.More() {
+		if err := elem(); err != nil {
+			return err
+		}
+	}
+
+	_, err := dec.Token()
+	return err
+}
+
+// openDelim reads the opening delimiter of an object or array. It reports
+// false for null.
+func openDelim(dec *json.Decoder, delim json.Delim) (bool, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if tok == nil {
+		return false, nil
+	}
+	if tok != delim {
+		return false, fmt.Errorf("got %v, want %v", tok, delim)
+	}
+
+	return true, nil
+}
+
+func skipValue(dec *json.Decoder) error {
+	var raw json.RawMessage
+	return dec.Decode(&raw)
+}
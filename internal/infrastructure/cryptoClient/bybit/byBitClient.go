@@ -3,6 +3,7 @@ package bybit
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -10,15 +11,21 @@ import (
 	"tgBotFinal/internal/domain/service"
 	"time"
 
+	"tgBotFinal/internal/clock"
 	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/infrastructure/metrics"
+	"tgBotFinal/internal/infrastructure/tracing"
 
-	"golang.org/x/sync/errgroup"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Client struct {
 	httpClient *http.Client
 	logger     *slog.Logger
 	baseURL    string
+	clock      clock.Clock
 }
 
 type byBitResponse struct {
@@ -30,43 +37,71 @@ type byBitResponse struct {
 }
 
 type TickerInfo struct {
-	Symbol    string `json:"symbol"`
-	LastPrice string `json:"lastPrice"`
+	Symbol       string `json:"symbol"`
+	LastPrice    string `json:"lastPrice"`
+	Price24hPcnt string `json:"price24hPcnt"`
+	HighPrice24h string `json:"highPrice24h"`
+	LowPrice24h  string `json:"lowPrice24h"`
+	Volume24h    string `json:"volume24h"`
+	Bid1Price    string `json:"bid1Price"`
+	Ask1Price    string `json:"ask1Price"`
 }
 
-func NewClient(logger *slog.Logger, api string) service.CryptoClient {
+func (t *TickerInfo) ticker() entity.Ticker {
+	return entity.Ticker{
+		Change24h: t.Price24hPcnt,
+		High24h:   t.HighPrice24h,
+		Low24h:    t.LowPrice24h,
+		Volume24h: t.Volume24h,
+		Bid:       t.Bid1Price,
+		Ask:       t.Ask1Price,
+	}
+}
+
+// NewClient queries the tickers endpoint api and dates the prices with clk.
+func NewClient(logger *slog.Logger, api string, clk clock.Clock) service.CryptoClient {
 	client := &Client{
-		httpClient: &http.Client{Timeout: 10 * time.Second},
-		logger:     logger.With(slog.String("component", "byBitClient")),
-		baseURL:    api,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		logger:  logger.With(slog.String("component", "byBitClient")),
+		baseURL: api,
+		clock:   clk,
 	}
 
 	return client
 }
 
 func (c *Client) GetPriceBySymbol(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error) {
-	c.logger.Debug("Get price by symbol", "symbol", symbol)
+	ctx, span := tracing.Start(ctx, "bybit.GetPriceBySymbol",
+		trace.WithAttributes(attribute.String("symbol", string(symbol))))
 
-	url := fmt.Sprintf("%s?category=spot&symbol=%sUSDT", c.baseURL, symbol)
+	start := time.Now()
+	price, err := c.fetchPrice(ctx, symbol)
+	tracing.End(span, err)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	result := "ok"
 	if err != nil {
-		c.logger.Error("error creating request", "err", err)
-		return nil, err
+		result = "error"
 	}
+	metrics.BybitDuration.WithLabelValues(string(symbol)).Observe(time.Since(start).Seconds())
+	metrics.BybitRequests.WithLabelValues(string(symbol), result).Inc()
 
-	resp, err := c.httpClient.Do(req)
+	return price, err
+}
+
+func (c *Client) fetchPrice(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error) {
+	c.logger.Debug("Get price by symbol", "symbol", symbol)
+
+	url := fmt.Sprintf("%s?category=spot&symbol=%s%s", c.baseURL, symbol, quoteCurrency)
+
+	resp, err := c.get(ctx, url)
 	if err != nil {
-		c.logger.Error("error executing request", "err", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		c.logger.Error("error executing request", "err", resp.Status)
-		return nil, fmt.Errorf("bad status: %s", resp.Status)
-	}
-
 	var result byBitResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		c.logger.Error("error parsing response", "err", err)
@@ -84,51 +119,119 @@ func (c *Client) GetPriceBySymbol(ctx context.Context, symbol entity.CurrencyNam
 	}
 
 	c.logger.Debug("got price by symbol", "symbol", symbol)
+	return c.price(symbol, &result.Result.List[0]), nil
+}
+
+// get sends a GET request and returns the response if it is 200 OK.
+func (c *Client) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		c.logger.Error("error creating request", "err", err)
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("error executing request", "err", err)
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		c.logger.Error("error executing request", "err", resp.Status)
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	return resp, nil
+}
+
+func (c *Client) price(symbol entity.CurrencyName, info *TickerInfo) *entity.Price {
 	return &entity.Price{
 		Symbol:  symbol,
-		Price:   result.Result.List[0].LastPrice,
-		Updated: time.Now().Format("2006-01-02 15:04:05"),
-	}, nil
-
+		Price:   info.LastPrice,
+		Updated: c.clock.Now().UTC().Format(time.RFC3339),
+		Ticker:  info.ticker(),
+	}
 }
+
+// GetAllPrices downloads every ticker in one request and keeps the tracked
+// ones. Symbols the batch did not deliver, or all of them if it failed, are
+// then fetched one request each. It fails only when no tracked symbol was
+// fetched, with the errors of every request.
 func (c *Client) GetAllPrices(ctx context.Context) (*entity.PriceResponse, error) {
 	c.logger.Debug("Get all prices")
 
-	g, ctx := errgroup.WithContext(ctx)
-
-	var mu sync.Mutex
-
-	prices := make(map[entity.CurrencyName]*entity.Price)
+	var errs []error
+	prices, err := c.getBatch(ctx)
+	if err != nil {
+		c.logger.Warn("Batch ticker request failed, fetching symbols one by one", "err", err)
+		errs = append(errs, fmt.Errorf("batch: %w", err))
+		prices = make(map[entity.CurrencyName]*entity.Price)
+	}
 
+	var missing []entity.CurrencyName
 	for _, symbol := range entity.TokenList {
-		g.Go(func() error {
-			res, err := c.GetPriceBySymbol(ctx, symbol)
-			if err != nil {
-				c.logger.Error("error getting price by symbol", "symbol", symbol, "err", err)
-				return nil
-			}
+		if prices[symbol] == nil {
+			missing = append(missing, symbol)
+		}
+	}
+	if len(missing) > 0 {
+		errs = append(errs, c.fetchEach(ctx, missing, prices)...)
+	}
 
-			mu.Lock()
-			prices[symbol] = res
-			mu.Unlock()
+	if len(prices) == 0 {
+		if len(errs) == 0 {
+			errs = append(errs, errors.New("no tracked symbol in the response"))
+		}
+		return nil, errors.Join(errs...)
+	}
 
-			return nil
-		})
+	result := &entity.PriceResponse{}
+	for _, price := range prices {
+		result.Set(price)
 	}
 
-	if err := g.Wait(); err != nil {
+	return result, nil
+}
+
+// GetTickers returns the tracked tickers of GetAllPrices ordered by symbol.
+func (c *Client) GetTickers(ctx context.Context) ([]*entity.Price, error) {
+	prices, err := c.GetAllPrices(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	result := &entity.PriceResponse{}
-	for symbol, price := range prices {
-		switch symbol {
-		case entity.BTC:
-			result.BTC = price
-		case entity.ETH:
-			result.ETH = price
-		}
+	return prices.List(), nil
+}
+
+// fetchEach fetches symbols concurrently, one request each, into prices.
+// Symbols that fail are left out; their errors are returned.
+func (c *Client) fetchEach(ctx context.Context, symbols []entity.CurrencyName, prices map[entity.CurrencyName]*entity.Price) []error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for _, symbol := range symbols {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := c.GetPriceBySymbol(ctx, symbol)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				c.logger.Error("error getting price by symbol", "symbol", symbol, "err", err)
+				errs = append(errs, fmt.Errorf("%s: %w", symbol, err))
+				return
+			}
+			prices[symbol] = res
+		}()
 	}
+	wg.Wait()
 
-	return result, nil
+	return errs
 }
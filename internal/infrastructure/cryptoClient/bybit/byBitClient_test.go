@@ -2,10 +2,17 @@ package bybit
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"tgBotFinal/internal/clock"
 	"tgBotFinal/internal/entity"
 )
 
@@ -21,7 +28,13 @@ func TestByBitClient_GetPriceBySymbol(t *testing.T) {
 						"list":[
 								{
 										"symbol": "BTCUSDT",
-										"lastPrice": "50000.50"
+										"lastPrice": "50000.50",
+										"price24hPcnt": "0.0213",
+										"highPrice24h": "50500",
+										"lowPrice24h": "48800.1",
+										"volume24h": "1234.567",
+										"bid1Price": "50000.4",
+										"ask1Price": "50000.5"
 								}
 						]
 				}
@@ -33,6 +46,7 @@ func TestByBitClient_GetPriceBySymbol(t *testing.T) {
 		httpClient: server.Client(),
 		logger:     slog.Default(),
 		baseURL:    server.URL,
+		clock:      clock.NewFake(time.Date(2025, 1, 6, 9, 30, 0, 0, time.UTC)),
 	}
 
 	price, err := client.GetPriceBySymbol(context.Background(), entity.BTC)
@@ -47,6 +61,16 @@ func TestByBitClient_GetPriceBySymbol(t *testing.T) {
 	if price.Price != "50000.50" {
 		t.Errorf("Price = %v, want %v", price.Price, "50000.50")
 	}
+
+	if price.Updated != "2025-01-06T09:30:00Z" {
+		t.Errorf("Updated = %v, want the time of the clock", price.Updated)
+	}
+
+	want := entity.Ticker{Change24h: "0.0213", High24h: "50500", Low24h: "48800.1",
+		Volume24h: "1234.567", Bid: "50000.4", Ask: "50000.5"}
+	if price.Ticker != want {
+		t.Errorf("Ticker = %+v, want %+v", price.Ticker, want)
+	}
 }
 
 func TestByBitClient_GetPriceBySymbol_Error(t *testing.T) {
@@ -59,6 +83,7 @@ func TestByBitClient_GetPriceBySymbol_Error(t *testing.T) {
 		httpClient: server.Client(),
 		logger:     slog.Default(),
 		baseURL:    server.URL,
+		clock:      clock.Real(),
 	}
 
 	_, err := client.GetPriceBySymbol(context.Background(), entity.BTC)
@@ -84,6 +109,7 @@ func TestByBitClient_GetPriceBySymbol_NoData(t *testing.T) {
 		httpClient: server.Client(),
 		logger:     slog.Default(),
 		baseURL:    server.URL,
+		clock:      clock.Real(),
 	}
 
 	_, err := client.GetPriceBySymbol(context.Background(), entity.BTC)
@@ -91,3 +117,162 @@ func TestByBitClient_GetPriceBySymbol_NoData(t *testing.T) {
 		t.Errorf("Expected error for no data")
 	}
 }
+
+// tickersServer serves n filler tickers besides BTCUSDT and ETHUSDT when no
+// symbol is asked for, or the asked one, and counts the requests of both
+// kinds. batch overrides the batch response when set.
+type tickersServer struct {
+	*httptest.Server
+	batchCalls  atomic.Int32
+	symbolCalls atomic.Int32
+}
+
+func newTickersServer(tb testing.TB, n int, batch string) *tickersServer {
+	tb.Helper()
+
+	prices := map[string]string{"BTCUSDT": "60000.5", "ETHUSDT": "3000.25"}
+	var all strings.Builder
+	all.WriteString(`{"retCode":0,"retMsg":"OK","result":{"category":"spot","list":[`)
+	for i := range n {
+		fmt.Fprintf(&all, `{"symbol":"COIN%dUSDT","lastPrice":"%d.5","price24hPcnt":"0.01","volume24h":"100"},`, i, i)
+	}
+	all.WriteString(`{"symbol":"BTCUSDT","lastPrice":"60000.5","price24hPcnt":"0.0213","bid1Price":"60000.4","ask1Price":"60000.5"},`)
+	all.WriteString(`{"symbol":"ETHUSDT","lastPrice":"3000.25"}]},"retExtInfo":{},"time":1736150400000}`)
+	if batch == "" {
+		batch = all.String()
+	}
+
+	s := &tickersServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		symbol := r.URL.Query().Get("symbol")
+		if symbol == "" {
+			s.batchCalls.Add(1)
+			io.WriteString(w, batch)
+			return
+		}
+
+		s.symbolCalls.Add(1)
+		fmt.Fprintf(w, `{"retCode":0,"retMsg":"OK","result":{"list":[{"symbol":%q,"lastPrice":%q}]}}`, symbol, prices[symbol])
+	}))
+	tb.Cleanup(s.Close)
+
+	return s
+}
+
+func newTestClient(s *tickersServer) *Client {
+	return &Client{
+		httpClient: s.Client(),
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		baseURL:    s.URL,
+		clock:      clock.Real(),
+	}
+}
+
+func TestByBitClient_GetAllPrices_Batch(t *testing.T) {
+	server := newTickersServer(t, 500, "")
+	client := newTestClient(server)
+
+	prices, err := client.GetAllPrices(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllPrices failed: %v", err)
+	}
+
+	if prices.BTC == nil || prices.BTC.Price != "60000.5" || prices.BTC.Change24h != "0.0213" || prices.BTC.Bid != "60000.4" {
+		t.Errorf("BTC = %+v, want its ticker from the batch", prices.BTC)
+	}
+	if prices.ETH == nil || prices.ETH.Price != "3000.25" {
+		t.Errorf("ETH = %+v, want its ticker from the batch", prices.ETH)
+	}
+
+	if batch, each := server.batchCalls.Load(), server.symbolCalls.Load(); batch != 1 || each != 0 {
+		t.Errorf("Requests = %d batch and %d per symbol, want a single batch", batch, each)
+	}
+}
+
+func TestByBitClient_GetAllPrices_Fallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		batch    string
+		wantEach int32
+	}{
+		{"API error", `{"retCode":10001,"retMsg":"params error","result":{},"retExtInfo":{},"time":0}`, 2},
+		{"Malformed", `{"retCode":0,"result":{"list":[{"symbol":`, 2},
+		{"Symbol missing", `{"retCode":0,"retMsg":"OK","result":{"list":[{"symbol":"BTCUSDT","lastPrice":"60000.5"}]}}`, 1},
+		{"Null list", `{"retCode":0,"retMsg":"OK","result":{"list":null}}`, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTickersServer(t, 0, tt.batch)
+			client := newTestClient(server)
+
+			prices, err := client.GetAllPrices(context.Background())
+			if err != nil {
+				t.Fatalf("GetAllPrices failed: %v", err)
+			}
+			if prices.BTC == nil || prices.BTC.Price != "60000.5" || prices.ETH == nil || prices.ETH.Price != "3000.25" {
+				t.Errorf("Prices = %+v, want BTC and ETH", prices)
+			}
+			if each := server.symbolCalls.Load(); each != tt.wantEach {
+				t.Errorf("Per-symbol requests = %d, want %d", each, tt.wantEach)
+			}
+		})
+	}
+}
+
+func TestByBitClient_GetAllPrices_AllFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &Client{
+		httpClient: server.Client(),
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		baseURL:    server.URL,
+		clock:      clock.Real(),
+	}
+
+	prices, err := client.GetAllPrices(context.Background())
+	if err == nil {
+		t.Fatalf("GetAllPrices() = %+v, want an error when nothing was fetched", prices)
+	}
+	for _, part := range []string{"batch", "BTC", "ETH"} {
+		if !strings.Contains(err.Error(), part) {
+			t.Errorf("GetAllPrices error = %q, want it to mention %s", err, part)
+		}
+	}
+}
+
+// BenchmarkGetAllPrices compares one request for the whole spot market,
+// as large as Bybit's, with one request per tracked symbol. On loopback
+// decoding the batch dominates; against the exchange every request costs a
+// round trip and a share of the rate limit.
+func BenchmarkGetAllPrices(b *testing.B) {
+	server := newTickersServer(b, 600, "")
+	client := newTestClient(server)
+	ctx := context.Background()
+
+	b.Run("batch", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := client.fetchBatch(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("per-symbol", func(b *testing.B) {
+		symbols := make([]entity.CurrencyName, 0, len(entity.TokenList))
+		for _, symbol := range entity.TokenList {
+			symbols = append(symbols, symbol)
+		}
+
+		b.ReportAllocs()
+		for b.Loop() {
+			client.fetchEach(ctx, symbols, make(map[entity.CurrencyName]*entity.Price))
+		}
+	})
+}
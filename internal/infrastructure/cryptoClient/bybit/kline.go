@@ -0,0 +1,171 @@
+package bybit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// klineLimit is the most candles the exchange returns per request.
+const klineLimit = 1000
+
+// klineIntervals maps candle intervals to the exchange's names for them.
+var klineIntervals = map[entity.CandleInterval]string{
+	entity.Interval1m:  "1",
+	entity.Interval5m:  "5",
+	entity.Interval15m: "15",
+	entity.Interval30m: "30",
+	entity.Interval1h:  "60",
+	entity.Interval4h:  "240",
+	entity.Interval1d:  "D",
+	entity.Interval1w:  "W",
+}
+
+type KlineClient struct {
+	// client sends the requests; its baseURL is the kline endpoint.
+	client *Client
+}
+
+type klineResponse struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		// List holds start time in milliseconds, open, high, low, close,
+		// volume and turnover of each candle, newest first.
+		List [][]string `json:"list"`
+	} `json:"result"`
+}
+
+// NewKlineClient queries the kline endpoint api.
+func NewKlineClient(logger *slog.Logger, api string) service.KlineProvider {
+	return &KlineClient{
+		client: &Client{
+			httpClient: &http.Client{
+				Timeout:   10 * time.Second,
+				Transport: otelhttp.NewTransport(http.DefaultTransport),
+			},
+			logger:  logger.With(slog.String("component", "byBitKlineClient")),
+			baseURL: api,
+		},
+	}
+}
+
+// GetKlines pages backwards from to, klineLimit candles per request, until
+// it reaches from or the start of the symbol's history.
+func (k *KlineClient) GetKlines(ctx context.Context, symbol entity.CurrencyName, interval entity.CandleInterval, from, to time.Time) ([]*entity.Candle, error) {
+	name, ok := klineIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("unsupported candle interval: %q", interval)
+	}
+
+	ctx, span := tracing.Start(ctx, "bybit.GetKlines", trace.WithAttributes(
+		attribute.String("symbol", string(symbol)), attribute.String("interval", string(interval))))
+
+	var (
+		candles []*entity.Candle
+		err     error
+	)
+	// Both bounds of a request are inclusive.
+	for end := to.Add(-time.Millisecond); !end.Before(from); {
+		var page []*entity.Candle
+		page, err = k.fetchPage(ctx, symbol, interval, name, from, end)
+		if err != nil {
+			break
+		}
+		candles = append(candles, page...)
+		if len(page) < klineLimit {
+			break
+		}
+		end = page[len(page)-1].OpenTime.Add(-time.Millisecond)
+	}
+	tracing.End(span, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(candles)
+	return candles, nil
+}
+
+// fetchPage returns the newest klineLimit candles that open in [from, end],
+// newest first.
+func (k *KlineClient) fetchPage(ctx context.Context, symbol entity.CurrencyName, interval entity.CandleInterval, name string, from, end time.Time) ([]*entity.Candle, error) {
+	k.client.logger.Debug("Get klines", "symbol", symbol, "interval", interval, "from", from, "end", end)
+
+	url := fmt.Sprintf("%s?category=spot&symbol=%s%s&interval=%s&start=%d&end=%d&limit=%d",
+		k.client.baseURL, symbol, quoteCurrency, name, from.UnixMilli(), end.UnixMilli(), klineLimit)
+
+	resp, err := k.client.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result klineResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		k.client.logger.Error("error parsing response", "err", err)
+		return nil, err
+	}
+
+	if result.RetCode != 0 {
+		k.client.logger.Error("error executing request", "err", result.RetMsg)
+		return nil, fmt.Errorf("bybit API error: %s (code: %d)", result.RetMsg, result.RetCode)
+	}
+
+	candles := make([]*entity.Candle, 0, len(result.Result.List))
+	for _, row := range result.Result.List {
+		candle, err := parseKline(symbol, interval, row)
+		if err != nil {
+			k.client.logger.Error("error parsing kline", "symbol", symbol, "err", err)
+			return nil, err
+		}
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
+
+func parseKline(symbol entity.CurrencyName, interval entity.CandleInterval, row []string) (*entity.Candle, error) {
+	if len(row) < 6 {
+		return nil, fmt.Errorf("kline has %d fields, want at least 6", len(row))
+	}
+
+	start, err := strconv.ParseInt(row[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("kline start time: %w", err)
+	}
+
+	// The values are kept as sent, so only check that they are numbers.
+	var values [5]string
+	for i := range values {
+		if _, err := strconv.ParseFloat(row[i+1], 64); err != nil {
+			return nil, fmt.Errorf("kline field %d: %w", i+1, err)
+		}
+		values[i] = row[i+1]
+	}
+
+	return &entity.Candle{
+		Symbol:   symbol,
+		Interval: interval,
+		OpenTime: time.UnixMilli(start).UTC(),
+		Open:     values[0],
+		High:     values[1],
+		Low:      values[2],
+		Close:    values[3],
+		Volume:   values[4],
+	}, nil
+}
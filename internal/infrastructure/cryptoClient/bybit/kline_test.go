@@ -0,0 +1,142 @@
+package bybit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/entity"
+)
+
+// newKlineServer serves minute candles of BTCUSDT opening from first to
+// last, newest first and at most limit per response like the exchange. The
+// open and close price of each candle are its index.
+func newKlineServer(t *testing.T, first, last time.Time, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		q := r.URL.Query()
+		if q.Get("symbol") != "BTCUSDT" || q.Get("interval") != "1" {
+			t.Errorf("Query = %v, want BTCUSDT and interval 1", q)
+		}
+		start, _ := strconv.ParseInt(q.Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(q.Get("end"), 10, 64)
+		limit, _ := strconv.Atoi(q.Get("limit"))
+
+		var rows []string
+		for at := last; !at.Before(first) && len(rows) < limit; at = at.Add(-time.Minute) {
+			if ms := at.UnixMilli(); ms < start || ms > end {
+				continue
+			}
+			i := int(at.Sub(first) / time.Minute)
+			rows = append(rows, fmt.Sprintf(`["%d","%d","%d","%d","%d","1.5","0"]`, at.UnixMilli(), i, i+1, i-1, i))
+		}
+
+		fmt.Fprintf(w, `{"retCode":0,"retMsg":"OK","result":{"list":[%s]}}`, strings.Join(rows, ","))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func newTestKlineClient(server *httptest.Server) *KlineClient {
+	return &KlineClient{client: &Client{
+		httpClient: server.Client(),
+		logger:     slog.Default(),
+		baseURL:    server.URL,
+	}}
+}
+
+func TestKlineClient_GetKlines_Pages(t *testing.T) {
+	first := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	var requests atomic.Int32
+	server := newKlineServer(t, first, first.Add(3000*time.Minute), &requests)
+	client := newTestKlineClient(server)
+
+	from, to := first.Add(100*time.Minute), first.Add(2600*time.Minute)
+	candles, err := client.GetKlines(context.Background(), entity.BTC, entity.Interval1m, from, to)
+	if err != nil {
+		t.Fatalf("GetKlines failed: %v", err)
+	}
+
+	if len(candles) != 2500 {
+		t.Fatalf("Got %d candles, want 2500", len(candles))
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("Requests = %d, want 3 pages", got)
+	}
+	for i, candle := range candles {
+		if want := from.Add(time.Duration(i) * time.Minute); !candle.OpenTime.Equal(want) {
+			t.Fatalf("Candle %d opens at %v, want %v oldest first", i, candle.OpenTime, want)
+		}
+	}
+
+	want := entity.Candle{Symbol: entity.BTC, Interval: entity.Interval1m, OpenTime: from,
+		Open: "100", High: "101", Low: "99", Close: "100", Volume: "1.5"}
+	if *candles[0] != want {
+		t.Errorf("Candle = %+v, want %+v", *candles[0], want)
+	}
+}
+
+func TestKlineClient_GetKlines_BeforeHistory(t *testing.T) {
+	first := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	var requests atomic.Int32
+	server := newKlineServer(t, first, first.Add(10*time.Minute), &requests)
+	client := newTestKlineClient(server)
+
+	candles, err := client.GetKlines(context.Background(), entity.BTC, entity.Interval1m,
+		first.Add(-24*time.Hour), first.Add(5*time.Minute))
+	if err != nil {
+		t.Fatalf("GetKlines failed: %v", err)
+	}
+
+	if len(candles) != 5 {
+		t.Fatalf("Got %d candles, want 5", len(candles))
+	}
+	if !candles[0].OpenTime.Equal(first) {
+		t.Errorf("First candle opens at %v, want %v", candles[0].OpenTime, first)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Requests = %d, want 1", got)
+	}
+}
+
+func TestKlineClient_GetKlines_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"API error", `{"retCode":10001,"retMsg":"params error","result":{}}`},
+		{"malformed kline", `{"retCode":0,"retMsg":"OK","result":{"list":[["1764547200000","1","2"]]}}`},
+		{"bad number", `{"retCode":0,"retMsg":"OK","result":{"list":[["1764547200000","x","2","0","1","1","0"]]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			from := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+			_, err := newTestKlineClient(server).GetKlines(context.Background(), entity.BTC, entity.Interval1m, from, from.Add(time.Hour))
+			if err == nil {
+				t.Error("GetKlines succeeded, want an error")
+			}
+		})
+	}
+
+	_, err := (&KlineClient{}).GetKlines(context.Background(), entity.BTC, "2h", time.Time{}, time.Time{})
+	if err == nil || !strings.Contains(err.Error(), "unsupported candle interval") {
+		t.Errorf("GetKlines error = %v, want unsupported interval", err)
+	}
+}
@@ -2,69 +2,277 @@ package cryptoClient
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"tgBotFinal/internal/clock"
 	"tgBotFinal/internal/domain/service"
 	"tgBotFinal/internal/entity"
 	"tgBotFinal/internal/infrastructure/cache"
+	"tgBotFinal/internal/infrastructure/metrics"
+	"tgBotFinal/internal/infrastructure/tracing"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// fetchTimeout bounds a refresh shared by several callers. It does not
+	// depend on any caller's context so one caller giving up does not fail
+	// the others.
+	fetchTimeout = 15 * time.Second
+	// refreshWait bounds how long a refresh waits for another replica that
+	// is fetching the same symbol before querying the exchange itself.
+	refreshWait = 5 * time.Second
 )
 
+// refreshAll names the store's refresh lock taken while fetching every
+// price.
+const refreshAll entity.CurrencyName = "ALL"
+
+// CachedClient serves prices from a cache.Store:
+//
+//   - a price younger than ttl is returned as is;
+//   - a price up to maxStale older than that is returned with Stale set,
+//     and a single refresh is started in the background;
+//   - otherwise the caller waits for a refresh.
+//
+// A refresh fetches every price in one upstream GetAllPrices call and
+// caches each of them. Concurrent callers share one refresh, and the
+// store's refresh lock extends that to other replicas.
 type CachedClient struct {
-	client service.CryptoClient
-	cache  *cache.PriceCache
-	logger *slog.Logger
-	ttl    time.Duration
+	client   service.CryptoClient
+	cache    cache.Store
+	ttl      time.Duration
+	maxStale time.Duration
+	clock    clock.Clock
+	group    singleflight.Group
+	logger   *slog.Logger
 }
 
-func NewCachedClient(client service.CryptoClient, ttl time.Duration, logger *slog.Logger) *CachedClient {
+// NewCachedClient wraps client. The store should keep prices for at least
+// ttl + maxStale and date them with the same clock.
+func NewCachedClient(client service.CryptoClient, store cache.Store, ttl, maxStale time.Duration, clk clock.Clock, logger *slog.Logger) *CachedClient {
+	metrics.TrackCacheAge(store.GetCacheAge)
+
 	return &CachedClient{
-		client: client,
-		cache:  cache.NewPriceCache(ttl),
-		logger: logger.With(slog.String("component", "CachedClient")),
-		ttl:    ttl,
+		client:   client,
+		cache:    store,
+		ttl:      ttl,
+		maxStale: maxStale,
+		clock:    clk,
+		logger:   logger.With(slog.String("component", "CachedClient")),
 	}
 }
 
 func (c *CachedClient) GetPriceBySymbol(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error) {
-	return c.client.GetPriceBySymbol(ctx, symbol)
+	ctx, span := tracing.Start(ctx, "CachedClient.GetPriceBySymbol",
+		trace.WithAttributes(attribute.String("symbol", string(symbol))))
+	defer span.End()
+
+	if price := c.cached(ctx, span, symbol); price != nil {
+		return price, nil
+	}
+
+	prices, err := c.wait(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	price := prices.Get(symbol)
+	if price == nil {
+		err := fmt.Errorf("no price for %s", symbol)
+		span.RecordError(err)
+		return nil, err
+	}
+	return copyPrice(price, false), nil
 }
 
+// GetAllPrices returns every price it can get and fails only when none is
+// available.
 func (c *CachedClient) GetAllPrices(ctx context.Context) (*entity.PriceResponse, error) {
-	if cached := c.cache.Get(); cached != nil {
-		c.logger.Debug("Return price from cache",
-			"cache_age", c.cache.GetCacheAge().Round(time.Second))
-		return cached, nil
+	ctx, span := tracing.Start(ctx, "CachedClient.GetAllPrices")
+	defer span.End()
+
+	result := &entity.PriceResponse{}
+	var missing []entity.CurrencyName
+	for _, symbol := range entity.TokenList {
+		if price := c.cached(ctx, span, symbol); price != nil {
+			result.Set(price)
+		} else {
+			missing = append(missing, symbol)
+		}
+	}
+	if len(missing) == 0 {
+		return result, nil
 	}
 
-	// Если кэш пустой или просроченный, и мы не обновляем уже - начинаем обновление
-	if c.cache.StartRefresh() {
-		defer c.cache.EndRefresh()
+	prices, err := c.wait(ctx)
+	if err != nil {
+		if len(result.List()) == 0 {
+			span.RecordError(err)
+			return nil, err
+		}
+		c.logger.WarnContext(ctx, "Returning cached prices only", "missing", missing, "error", err)
+		return result, nil
+	}
+
+	for _, symbol := range missing {
+		if price := prices.Get(symbol); price != nil {
+			result.Set(copyPrice(price, false))
+		}
+	}
 
-		c.logger.Debug("Cache miss, fetching prices from API")
-		prices, err := c.client.GetAllPrices(ctx)
-		if err != nil {
-			c.logger.Error("Failed to fetch prices from API", "error", err)
+	return result, nil
+}
 
-			// Если есть старые данные в кэше, вернем их даже если просрочены
-			if stale := c.cache.Get(); stale != nil {
-				c.logger.Warn("Returning stale cache data due to API error")
+// GetTickers serves each symbol from the cache like GetAllPrices.
+func (c *CachedClient) GetTickers(ctx context.Context) ([]*entity.Price, error) {
+	prices, err := c.GetAllPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-				return stale, nil
-			}
+	return prices.List(), nil
+}
 
-			return nil, err
+// cached returns the cached price of symbol, flagged stale past the TTL,
+// or nil when it has to be fetched. A stale price starts a refresh in the
+// background.
+func (c *CachedClient) cached(ctx context.Context, span trace.Span, symbol entity.CurrencyName) *entity.Price {
+	entry := c.lookup(ctx, symbol)
+	var age time.Duration
+	if entry != nil {
+		age = entry.Age(c.clock.Now())
+	}
+
+	switch {
+	case entry != nil && age <= c.ttl:
+		c.record(span, "hit")
+		c.logger.DebugContext(ctx, "Return price from cache", "symbol", symbol,
+			"cache_age", age.Round(time.Second))
+		return copyPrice(entry.Price, false)
+
+	case entry != nil && age <= c.ttl+c.maxStale:
+		c.record(span, "stale")
+		c.logger.DebugContext(ctx, "Return stale price and refresh in background", "symbol", symbol,
+			"cache_age", age.Round(time.Second))
+		c.refresh(ctx)
+		return copyPrice(entry.Price, true)
+	}
+
+	c.record(span, "miss")
+	return nil
+}
+
+// wait refreshes the prices and returns them, or gives up when ctx is done
+// while the refresh goes on for other callers.
+func (c *CachedClient) wait(ctx context.Context) (*entity.PriceResponse, error) {
+	select {
+	case res := <-c.refresh(ctx):
+		if res.Err != nil {
+			return nil, res.Err
 		}
+		return res.Val.(*entity.PriceResponse), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// refresh starts fetching every price unless a fetch is already in flight,
+// and returns a channel that receives the fetched prices.
+func (c *CachedClient) refresh(ctx context.Context) <-chan singleflight.Result {
+	return c.group.DoChan(string(refreshAll), func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+
+		return c.fetch(ctx)
+	})
+}
+
+func (c *CachedClient) fetch(ctx context.Context) (*entity.PriceResponse, error) {
+	started, err := c.cache.StartRefresh(ctx, refreshAll)
+	if err != nil {
+		c.logger.WarnContext(ctx, "Failed to start cache refresh", "error", err)
+	}
+
+	switch {
+	case started:
+		defer c.cache.EndRefresh(ctx, refreshAll)
+
+		// A refresh may have finished between the lookup and the lock.
+		if prices, ok := c.freshAll(ctx); ok {
+			return prices, nil
+		}
+
+	case err == nil:
+		// Another replica is fetching: use its result.
+		c.logger.DebugContext(ctx, "Waiting for refresh by another replica")
+
+		waitCtx, cancel := context.WithTimeout(ctx, refreshWait)
+		err := c.cache.WaitRefresh(waitCtx, refreshAll)
+		cancel()
+
+		if prices, ok := c.freshAll(ctx); err == nil && ok {
+			return prices, nil
+		}
+	}
 
-		c.cache.Set(prices)
-		c.logger.Debug("Prices cached successfully",
-			"ttl", c.ttl,
-			"BTC", prices.BTC != nil,
-			"ETH", prices.ETH != nil)
+	c.logger.DebugContext(ctx, "Fetching prices from API")
+	prices, err := c.client.GetAllPrices(ctx)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "Failed to fetch prices from API", "error", err)
+		return nil, err
+	}
+
+	for _, price := range prices.List() {
+		if err := c.cache.Set(ctx, price); err != nil {
+			c.logger.WarnContext(ctx, "Failed to cache price", "symbol", price.Symbol, "error", err)
+		}
+	}
 
-		return prices, nil
+	return prices, nil
+}
+
+// freshAll returns the cached prices if every tracked symbol has a fresh
+// one.
+func (c *CachedClient) freshAll(ctx context.Context) (*entity.PriceResponse, bool) {
+	prices := &entity.PriceResponse{}
+	for _, symbol := range entity.TokenList {
+		entry := c.lookup(ctx, symbol)
+		if !c.fresh(entry) {
+			return nil, false
+		}
+		prices.Set(entry.Price)
 	}
+	return prices, true
+}
+
+// lookup reads the cache, treating a failing cache like an empty one.
+func (c *CachedClient) lookup(ctx context.Context, symbol entity.CurrencyName) *cache.Entry {
+	entry, err := c.cache.Get(ctx, symbol)
+	if err != nil {
+		c.logger.WarnContext(ctx, "Failed to read price cache", "symbol", symbol, "error", err)
+		return nil
+	}
+	return entry
+}
+
+// fresh reports whether entry is younger than the TTL.
+func (c *CachedClient) fresh(entry *cache.Entry) bool {
+	return entry != nil && entry.Age(c.clock.Now()) <= c.ttl
+}
+
+func (c *CachedClient) record(span trace.Span, result string) {
+	metrics.CacheLookups.WithLabelValues(result).Inc()
+	span.SetAttributes(attribute.String("cache.result", result))
+}
 
-	// Если уже обновляется, ждем и возвращаем то что есть (даже просроченное)
-	c.logger.Debug("No cache available, waiting for refresh")
-	return c.client.GetAllPrices(ctx)
+// copyPrice returns a copy so callers never share a cached value.
+func copyPrice(price *entity.Price, stale bool) *entity.Price {
+	p := *price
+	p.Stale = stale
+	return &p
 }
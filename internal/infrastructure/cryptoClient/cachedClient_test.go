@@ -0,0 +1,270 @@
+package cryptoClient
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/infrastructure/cache"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeClient counts batch fetches and answers them with its current price
+// for every symbol or its error, after its gate (if any) is opened.
+type fakeClient struct {
+	calls atomic.Int32
+
+	mu    sync.Mutex
+	price string
+	err   error
+	gate  chan struct{}
+}
+
+func newFakeClient(price string) *fakeClient {
+	return &fakeClient{price: price}
+}
+
+func (c *fakeClient) set(price string, err error, gate chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.price, c.err, c.gate = price, err, gate
+}
+
+func (c *fakeClient) GetPriceBySymbol(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error) {
+	return nil, errors.New("CachedClient must fetch every price at once")
+}
+
+func (c *fakeClient) GetAllPrices(ctx context.Context) (*entity.PriceResponse, error) {
+	c.calls.Add(1)
+
+	c.mu.Lock()
+	gate := c.gate
+	c.mu.Unlock()
+
+	if gate != nil {
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	prices := &entity.PriceResponse{}
+	for _, symbol := range entity.TokenList {
+		prices.Set(&entity.Price{Symbol: symbol, Price: c.price})
+	}
+	return prices, nil
+}
+
+func (c *fakeClient) GetTickers(ctx context.Context) ([]*entity.Price, error) {
+	return nil, errors.New("CachedClient must fetch every price at once")
+}
+
+// observedStore reports every cache read on gets and every finished
+// refresh on refreshed, when they are set.
+type observedStore struct {
+	cache.Store
+	gets      chan<- struct{}
+	refreshed chan<- struct{}
+}
+
+func (s *observedStore) Get(ctx context.Context, symbol entity.CurrencyName) (*cache.Entry, error) {
+	if s.gets != nil {
+		s.gets <- struct{}{}
+	}
+	return s.Store.Get(ctx, symbol)
+}
+
+func (s *observedStore) EndRefresh(ctx context.Context, symbol entity.CurrencyName) {
+	s.Store.EndRefresh(ctx, symbol)
+	if s.refreshed != nil {
+		s.refreshed <- struct{}{}
+	}
+}
+
+func TestCachedClient_ConcurrentMissesFetchOnce(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisStore := func() cache.Store {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		store := cache.NewRedisCache(client, "test", time.Minute, clock.Real(), slog.Default())
+		t.Cleanup(func() {
+			store.Close()
+			client.Close()
+		})
+		return store
+	}
+
+	tests := []struct {
+		name   string
+		stores []cache.Store
+	}{
+		{"memory", []cache.Store{cache.NewPriceCache(time.Minute, clock.Real())}},
+		{"redis replicas", []cache.Store{redisStore(), redisStore(), redisStore()}},
+	}
+
+	const callers = 12
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gate := make(chan struct{})
+			upstream := newFakeClient("50000")
+			upstream.set("50000", nil, gate)
+
+			gets := make(chan struct{}, 4*callers*len(entity.TokenList))
+			clients := make([]*CachedClient, len(tt.stores))
+			for i, store := range tt.stores {
+				clients[i] = NewCachedClient(upstream, &observedStore{Store: store, gets: gets},
+					time.Minute, 0, clock.Real(), slog.Default())
+			}
+
+			var wg sync.WaitGroup
+			for i := range callers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					prices, err := clients[i%len(clients)].GetAllPrices(context.Background())
+					if err != nil || prices.BTC == nil || prices.ETH == nil || prices.Stale() {
+						t.Errorf("GetAllPrices() = %+v, %v", prices, err)
+					}
+				}()
+			}
+
+			// Every caller misses each symbol, and the single fetch reads the
+			// cache once more under the lock before blocking on the gate:
+			// after that many reads all callers are waiting for the fetch.
+			for range callers*len(entity.TokenList) + 1 {
+				select {
+				case <-gets:
+				case <-time.After(5 * time.Second):
+					t.Fatal("callers did not all miss the cache")
+				}
+			}
+			close(gate)
+			wg.Wait()
+
+			if calls := upstream.calls.Load(); calls != 1 {
+				t.Errorf("upstream fetches = %d, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestCachedClient_StaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+	upstream := newFakeClient("50000")
+	clk := clock.NewFake(time.Now())
+	refreshed := make(chan struct{}, 1)
+	store := &observedStore{Store: cache.NewPriceCache(2*time.Minute, clk), refreshed: refreshed}
+	client := NewCachedClient(upstream, store, 20*time.Second, time.Minute, clk, slog.Default())
+
+	if _, err := client.GetPriceBySymbol(ctx, entity.BTC); err != nil {
+		t.Fatal(err)
+	}
+	<-refreshed
+
+	clk.Advance(30 * time.Second)
+	gate := make(chan struct{})
+	upstream.set("51000", nil, gate)
+
+	for range 3 {
+		price, err := client.GetPriceBySymbol(ctx, entity.BTC)
+		if err != nil || !price.Stale || price.Price != "50000" {
+			t.Fatalf("GetPriceBySymbol() = %+v, %v; want the old price flagged stale", price, err)
+		}
+	}
+
+	close(gate)
+	select {
+	case <-refreshed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("background refresh did not finish")
+	}
+
+	if price, err := client.GetPriceBySymbol(ctx, entity.BTC); err != nil || price.Stale || price.Price != "51000" {
+		t.Fatalf("GetPriceBySymbol() = %+v, %v; want the refreshed price", price, err)
+	}
+
+	if calls := upstream.calls.Load(); calls != 2 {
+		t.Errorf("upstream fetches = %d, want the initial one and a single background refresh", calls)
+	}
+}
+
+func TestCachedClient_TooStaleIsAMiss(t *testing.T) {
+	ctx := context.Background()
+	upstream := newFakeClient("50000")
+	clk := clock.NewFake(time.Now())
+	client := NewCachedClient(upstream, cache.NewPriceCache(time.Minute, clk), 10*time.Second, 10*time.Second, clk, slog.Default())
+
+	if _, err := client.GetPriceBySymbol(ctx, entity.BTC); err != nil {
+		t.Fatal(err)
+	}
+
+	clk.Advance(30 * time.Second)
+	upstream.set("50000", errors.New("exchange down"), nil)
+
+	if price, err := client.GetPriceBySymbol(ctx, entity.BTC); err == nil {
+		t.Errorf("GetPriceBySymbol() = %+v, want the fetch error past max staleness", price)
+	}
+}
+
+func TestCachedClient_WaiterGivingUpDoesNotFailOthers(t *testing.T) {
+	gate := make(chan struct{})
+	upstream := newFakeClient("50000")
+	upstream.set("50000", nil, gate)
+	client := NewCachedClient(upstream, cache.NewPriceCache(time.Minute, clock.Real()), time.Minute, 0, clock.Real(), slog.Default())
+
+	impatient, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	patient := make(chan error, 1)
+	go func() {
+		_, err := client.GetPriceBySymbol(context.Background(), entity.BTC)
+		patient <- err
+	}()
+
+	if _, err := client.GetPriceBySymbol(impatient, entity.BTC); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("impatient caller error = %v, want deadline exceeded", err)
+	}
+
+	close(gate)
+
+	if err := <-patient; err != nil {
+		t.Errorf("patient caller error = %v, want the shared fetch result", err)
+	}
+	if calls := upstream.calls.Load(); calls != 1 {
+		t.Errorf("upstream fetches = %d, want 1", calls)
+	}
+}
+
+func TestCachedClient_MissCachesEverySymbol(t *testing.T) {
+	ctx := context.Background()
+	upstream := newFakeClient("50000")
+	client := NewCachedClient(upstream, cache.NewPriceCache(time.Minute, clock.Real()), time.Minute, 0, clock.Real(), slog.Default())
+
+	if _, err := client.GetPriceBySymbol(ctx, entity.BTC); err != nil {
+		t.Fatal(err)
+	}
+	if price, err := client.GetPriceBySymbol(ctx, entity.ETH); err != nil || price.Price != "50000" {
+		t.Fatalf("GetPriceBySymbol(ETH) = %+v, %v", price, err)
+	}
+
+	if calls := upstream.calls.Load(); calls != 1 {
+		t.Errorf("upstream fetches = %d, want the first miss to fetch every price", calls)
+	}
+}
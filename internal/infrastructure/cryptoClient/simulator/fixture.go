@@ -0,0 +1,185 @@
+package simulator
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tgBotFinal/internal/entity"
+)
+
+// Point is a recorded price.
+type Point struct {
+	Time  time.Time `json:"time"`
+	Price float64   `json:"price"`
+}
+
+// Replay plays back recorded price series. A price holds until the next
+// point of its series, and the last one holds forever.
+type Replay struct {
+	series map[entity.CurrencyName][]Point
+}
+
+// NewReplay sorts the series by time. Every series must have a point and
+// every price must be positive.
+func NewReplay(series map[entity.CurrencyName][]Point) (*Replay, error) {
+	if len(series) == 0 {
+		return nil, errors.New("fixture has no prices")
+	}
+
+	sorted := make(map[entity.CurrencyName][]Point, len(series))
+	for symbol, points := range series {
+		if len(points) == 0 {
+			return nil, fmt.Errorf("%s: no prices", symbol)
+		}
+		for _, p := range points {
+			if p.Price <= 0 {
+				return nil, fmt.Errorf("%s: price at %s is not positive: %v", symbol, p.Time.Format(time.RFC3339), p.Price)
+			}
+		}
+
+		points = slices.Clone(points)
+		slices.SortStableFunc(points, func(a, b Point) int { return a.Time.Compare(b.Time) })
+		sorted[symbol] = points
+	}
+
+	return &Replay{series: sorted}, nil
+}
+
+// LoadFixture reads a CSV or JSON fixture, chosen by the file extension.
+func LoadFixture(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var replay *Replay
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		replay, err = ReadCSV(f)
+	case ".json":
+		replay, err = ReadJSON(f)
+	default:
+		return nil, fmt.Errorf("fixture %s: unknown format %q, want .csv or .json", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", path, err)
+	}
+
+	return replay, nil
+}
+
+// ReadCSV reads a fixture with a header row naming the time, symbol and
+// price columns, in any order:
+//
+//	time,symbol,price
+//	2025-01-06T09:00:00Z,BTC,60000
+//
+// Times are RFC 3339.
+func ReadCSV(r io.Reader) (*Replay, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	cols := map[string]int{"time": -1, "symbol": -1, "price": -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := cols[name]; ok {
+			cols[name] = i
+		}
+	}
+	for name, i := range cols {
+		if i < 0 {
+			return nil, fmt.Errorf("header has no %s column", name)
+		}
+	}
+
+	series := make(map[entity.CurrencyName][]Point)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		at, err := time.Parse(time.RFC3339, record[cols["time"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid time %q", line, record[cols["time"]])
+		}
+		price, err := strconv.ParseFloat(record[cols["price"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid price %q", line, record[cols["price"]])
+		}
+
+		symbol := entity.CurrencyName(strings.ToUpper(record[cols["symbol"]]))
+		series[symbol] = append(series[symbol], Point{Time: at, Price: price})
+	}
+
+	return NewReplay(series)
+}
+
+// ReadJSON reads a fixture that maps symbols to their points:
+//
+//	{"BTC": [{"time": "2025-01-06T09:00:00Z", "price": 60000}]}
+func ReadJSON(r io.Reader) (*Replay, error) {
+	var series map[entity.CurrencyName][]Point
+	if err := json.NewDecoder(r).Decode(&series); err != nil {
+		return nil, err
+	}
+
+	return NewReplay(series)
+}
+
+// Start returns the time of the earliest recorded price.
+func (r *Replay) Start() time.Time {
+	var start time.Time
+	for _, points := range r.series {
+		if start.IsZero() || points[0].Time.Before(start) {
+			start = points[0].Time
+		}
+	}
+
+	return start
+}
+
+func (r *Replay) Symbols() []entity.CurrencyName {
+	symbols := make([]entity.CurrencyName, 0, len(r.series))
+	for symbol := range r.series {
+		symbols = append(symbols, symbol)
+	}
+	slices.Sort(symbols)
+
+	return symbols
+}
+
+func (r *Replay) PriceAt(symbol entity.CurrencyName, at time.Time) (float64, error) {
+	points, ok := r.series[symbol]
+	if !ok {
+		return 0, ErrUnknownSymbol
+	}
+
+	// The first point after at; the price is the one before it.
+	i := sort.Search(len(points), func(i int) bool { return points[i].Time.After(at) })
+	if i == 0 {
+		return 0, ErrNoPrice
+	}
+
+	return points[i-1].Price, nil
+}
@@ -0,0 +1,113 @@
+package simulator
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/entity"
+)
+
+// TickersPath is where NewHandler serves tickers, as Bybit does. Point
+// bybit.NewClient (API_URL) at the server URL followed by it.
+const TickersPath = "/v5/market/tickers"
+
+// quoteCurrency is the currency every symbol is traded against, as in
+// BTCUSDT.
+const quoteCurrency = "USDT"
+
+type tickersResponse struct {
+	RetCode    int            `json:"retCode"`
+	RetMsg     string         `json:"retMsg"`
+	Result     tickersResult  `json:"result"`
+	RetExtInfo map[string]any `json:"retExtInfo"`
+	Time       int64          `json:"time"`
+}
+
+type tickersResult struct {
+	Category string   `json:"category,omitempty"`
+	List     []ticker `json:"list,omitempty"`
+}
+
+type ticker struct {
+	Symbol       string `json:"symbol"`
+	LastPrice    string `json:"lastPrice"`
+	PrevPrice24h string `json:"prevPrice24h"`
+	Price24hPcnt string `json:"price24hPcnt"`
+	HighPrice24h string `json:"highPrice24h"`
+	LowPrice24h  string `json:"lowPrice24h"`
+	Volume24h    string `json:"volume24h"`
+	Bid1Price    string `json:"bid1Price"`
+	Ask1Price    string `json:"ask1Price"`
+}
+
+// NewHandler serves the prices of feed at the time of clk in the format of
+// Bybit's GET /v5/market/tickers, so the real exchange client can be
+// tested against simulated prices. Without a symbol parameter every
+// symbol of the feed is listed. Errors are reported the way Bybit does,
+// with HTTP 200 and a non-zero retCode.
+func NewHandler(feed Feed, clk clock.Clock) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+TickersPath, func(w http.ResponseWriter, r *http.Request) {
+		now := clk.Now()
+		resp := tickersResponse{RetCode: 0, RetMsg: "OK", RetExtInfo: map[string]any{}, Time: now.UnixMilli()}
+
+		category := r.URL.Query().Get("category")
+		list, ok := tickers(feed, r.URL.Query().Get("symbol"), now)
+		switch {
+		case category != "spot" && category != "linear" && category != "inverse":
+			resp.RetCode, resp.RetMsg = 10001, "params error: Category is invalid"
+		case !ok:
+			resp.RetCode, resp.RetMsg = 10001, "Not supported symbols"
+		default:
+			resp.Result = tickersResult{Category: category, List: list}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	return mux
+}
+
+// tickers lists the tickers of symbol, or of every symbol if it is empty.
+// It reports false for a symbol the feed does not quote.
+func tickers(feed Feed, symbol string, now time.Time) ([]ticker, bool) {
+	symbols := feed.Symbols()
+	if symbol != "" {
+		base, ok := strings.CutSuffix(strings.ToUpper(symbol), quoteCurrency)
+		if !ok {
+			return nil, false
+		}
+		symbols = []entity.CurrencyName{entity.CurrencyName(base)}
+	}
+
+	list := make([]ticker, 0, len(symbols))
+	for _, s := range symbols {
+		q, err := quoteAt(feed, s, now)
+		if errors.Is(err, ErrNoPrice) && symbol == "" {
+			// Not listed yet.
+			continue
+		}
+		if err != nil {
+			return nil, false
+		}
+
+		list = append(list, ticker{
+			Symbol:       string(s) + quoteCurrency,
+			LastPrice:    formatPrice(q.last),
+			PrevPrice24h: formatPrice(q.prev),
+			Price24hPcnt: q.change(),
+			HighPrice24h: formatPrice(q.high),
+			LowPrice24h:  formatPrice(q.low),
+			Volume24h:    "0",
+			Bid1Price:    formatPrice(q.bid),
+			Ask1Price:    formatPrice(q.ask),
+		})
+	}
+
+	return list, true
+}
@@ -0,0 +1,136 @@
+// Package simulator provides a service.CryptoClient with made-up or
+// recorded prices, so the bot can run without the exchange and tests can
+// play reproducible price scenarios. Prices are a function of time read
+// from a clock, so a fake clock decides what the exchange quotes.
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+var (
+	// ErrUnknownSymbol is returned for symbols a feed has no series for.
+	ErrUnknownSymbol = errors.New("unknown symbol")
+	// ErrNoPrice is returned for times before a feed's first price.
+	ErrNoPrice = errors.New("no price yet")
+)
+
+const (
+	// statsStep is how often the 24 hour high and low are sampled.
+	statsStep = 5 * time.Minute
+	// halfSpread is how far the bid and ask are from the last price,
+	// relative to it.
+	halfSpread = 0.00005
+)
+
+// Feed is a price series per symbol.
+type Feed interface {
+	Symbols() []entity.CurrencyName
+	PriceAt(symbol entity.CurrencyName, at time.Time) (float64, error)
+}
+
+// Client quotes the prices of a feed at the time of its clock.
+type Client struct {
+	feed  Feed
+	clock clock.Clock
+}
+
+func NewClient(feed Feed, clk clock.Clock) service.CryptoClient {
+	return &Client{feed: feed, clock: clk}
+}
+
+func (c *Client) GetPriceBySymbol(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error) {
+	now := c.clock.Now()
+
+	q, err := quoteAt(c.feed, symbol, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", symbol, err)
+	}
+
+	return &entity.Price{
+		Symbol:  symbol,
+		Price:   formatPrice(q.last),
+		Updated: now.UTC().Format(time.RFC3339),
+		Ticker: entity.Ticker{
+			Change24h: q.change(),
+			High24h:   formatPrice(q.high),
+			Low24h:    formatPrice(q.low),
+			Bid:       formatPrice(q.bid),
+			Ask:       formatPrice(q.ask),
+		},
+	}, nil
+}
+
+func (c *Client) GetAllPrices(ctx context.Context) (*entity.PriceResponse, error) {
+	var prices entity.PriceResponse
+	for _, symbol := range entity.TokenList {
+		price, err := c.GetPriceBySymbol(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		prices.Set(price)
+	}
+
+	return &prices, nil
+}
+
+func (c *Client) GetTickers(ctx context.Context) ([]*entity.Price, error) {
+	prices, err := c.GetAllPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return prices.List(), nil
+}
+
+// quote is the market data of a symbol at some time. Volume is not
+// simulated.
+type quote struct {
+	last, prev float64
+	high, low  float64
+	bid, ask   float64
+}
+
+// quoteAt samples feed over the 24 hours before now. A series that starts
+// less than a day ago has no change yet.
+func quoteAt(feed Feed, symbol entity.CurrencyName, now time.Time) (quote, error) {
+	last, err := feed.PriceAt(symbol, now)
+	if err != nil {
+		return quote{}, err
+	}
+
+	q := quote{
+		last: last, prev: last,
+		high: last, low: last,
+		bid: last * (1 - halfSpread), ask: last * (1 + halfSpread),
+	}
+
+	from := now.Add(-24 * time.Hour)
+	if prev, err := feed.PriceAt(symbol, from); err == nil {
+		q.prev = prev
+	}
+	for at := from; at.Before(now); at = at.Add(statsStep) {
+		if price, err := feed.PriceAt(symbol, at); err == nil {
+			q.high, q.low = max(q.high, price), min(q.low, price)
+		}
+	}
+
+	return q, nil
+}
+
+// change formats the relative 24 hour change as Bybit does.
+func (q quote) change() string {
+	return strconv.FormatFloat(q.last/q.prev-1, 'f', 4, 64)
+}
+
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', 2, 64)
+}
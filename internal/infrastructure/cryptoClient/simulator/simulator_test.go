@@ -0,0 +1,223 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/infrastructure/cryptoClient/bybit"
+)
+
+var fixtureStart = time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+
+func TestRandomWalk_Seeded(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := NewRandomWalk(7, start, time.Minute)
+	b := NewRandomWalk(7, start, time.Minute)
+
+	// b is read backwards and skips ETH: neither changes its prices.
+	for i := 100; i >= 0; i-- {
+		if _, err := b.PriceAt(entity.BTC, start.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("PriceAt failed: %v", err)
+		}
+	}
+
+	prev := startPrices[entity.BTC]
+	for i := range 100 {
+		at := start.Add(time.Duration(i)*time.Minute + 30*time.Second)
+		pa, err := a.PriceAt(entity.BTC, at)
+		if err != nil {
+			t.Fatalf("PriceAt failed: %v", err)
+		}
+		if _, err := a.PriceAt(entity.ETH, at); err != nil {
+			t.Fatalf("PriceAt failed: %v", err)
+		}
+		if pb, _ := b.PriceAt(entity.BTC, at); pa != pb {
+			t.Fatalf("Prices with the same seed differ at step %d: %v and %v", i, pa, pb)
+		}
+
+		if change := pa/prev - 1; change > maxStep+1e-9 || change < -maxStep-1e-9 {
+			t.Fatalf("Price moved by %.4f, more than the step of %.4f", change, maxStep)
+		}
+		prev = pa
+	}
+
+	if p, _ := a.PriceAt(entity.BTC, start.Add(-time.Hour)); p != startPrices[entity.BTC] {
+		t.Errorf("Price before the start = %v, want the start price", p)
+	}
+	if _, err := a.PriceAt("DOGE", start); !errors.Is(err, ErrUnknownSymbol) {
+		t.Errorf("PriceAt(DOGE) = %v, want ErrUnknownSymbol", err)
+	}
+}
+
+func TestLoadFixture(t *testing.T) {
+	for _, path := range []string{"testdata/dip.csv", "testdata/dip.json"} {
+		t.Run(path, func(t *testing.T) {
+			replay, err := LoadFixture(path)
+			if err != nil {
+				t.Fatalf("LoadFixture failed: %v", err)
+			}
+
+			if got := replay.Start(); !got.Equal(fixtureStart) {
+				t.Errorf("Start() = %s, want %s", got, fixtureStart)
+			}
+			if got := replay.Symbols(); len(got) != 2 || got[0] != entity.BTC || got[1] != entity.ETH {
+				t.Errorf("Symbols() = %v, want [BTC ETH]", got)
+			}
+
+			tests := []struct {
+				symbol entity.CurrencyName
+				at     time.Duration
+				want   float64
+			}{
+				{entity.BTC, 0, 60000},
+				{entity.BTC, 14 * time.Minute, 60000},
+				{entity.BTC, 15 * time.Minute, 57000},
+				{entity.ETH, 20 * time.Minute, 2900},
+				{entity.BTC, 48 * time.Hour, 61200.5},
+			}
+			for _, tt := range tests {
+				got, err := replay.PriceAt(tt.symbol, fixtureStart.Add(tt.at))
+				if err != nil || got != tt.want {
+					t.Errorf("PriceAt(%s, +%s) = %v, %v, want %v", tt.symbol, tt.at, got, err, tt.want)
+				}
+			}
+
+			if _, err := replay.PriceAt(entity.BTC, fixtureStart.Add(-time.Second)); !errors.Is(err, ErrNoPrice) {
+				t.Errorf("PriceAt before the first point = %v, want ErrNoPrice", err)
+			}
+		})
+	}
+}
+
+func TestReadCSV_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"empty":          "",
+		"missing column": "time,symbol\n2025-01-06T09:00:00Z,BTC\n",
+		"bad time":       "time,symbol,price\nyesterday,BTC,1\n",
+		"bad price":      "time,symbol,price\n2025-01-06T09:00:00Z,BTC,lots\n",
+		"negative price": "time,symbol,price\n2025-01-06T09:00:00Z,BTC,-1\n",
+		"no rows":        "time,symbol,price\n",
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ReadCSV(strings.NewReader(input)); err == nil {
+				t.Error("ReadCSV accepted an invalid fixture")
+			}
+		})
+	}
+}
+
+func TestClient_VirtualClock(t *testing.T) {
+	replay, err := LoadFixture("testdata/dip.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk := clock.NewFake(fixtureStart.Add(-time.Minute))
+	client := NewClient(replay, clk)
+	ctx := context.Background()
+
+	if _, err := client.GetAllPrices(ctx); !errors.Is(err, ErrNoPrice) {
+		t.Errorf("GetAllPrices before the fixture = %v, want ErrNoPrice", err)
+	}
+
+	for _, step := range []struct {
+		advance  time.Duration
+		btc, eth string
+	}{
+		{time.Minute, "60000.00", "3000.00"},
+		{15 * time.Minute, "57000.00", "2900.00"},
+		{15 * time.Minute, "61200.50", "3050.25"},
+	} {
+		clk.Advance(step.advance)
+
+		prices, err := client.GetAllPrices(ctx)
+		if err != nil {
+			t.Fatalf("GetAllPrices failed: %v", err)
+		}
+		if prices.BTC.Price != step.btc || prices.ETH.Price != step.eth {
+			t.Errorf("Prices at %s = %s/%s, want %s/%s", clk.Now().Format(time.TimeOnly),
+				prices.BTC.Price, prices.ETH.Price, step.btc, step.eth)
+		}
+		if want := clk.Now().UTC().Format(time.RFC3339); prices.BTC.Updated != want {
+			t.Errorf("Updated = %q, want the virtual time %q", prices.BTC.Updated, want)
+		}
+	}
+}
+
+func TestHandler_ImpersonatesBybit(t *testing.T) {
+	replay, err := LoadFixture("testdata/dip.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk := clock.NewFake(fixtureStart.Add(15 * time.Minute))
+	srv := httptest.NewServer(NewHandler(replay, clk))
+	defer srv.Close()
+
+	client := bybit.NewClient(slog.Default(), srv.URL+TickersPath, clk)
+	ctx := context.Background()
+
+	prices, err := client.GetAllPrices(ctx)
+	if err != nil {
+		t.Fatalf("GetAllPrices failed: %v", err)
+	}
+	if prices.BTC.Price != "57000.00" || prices.ETH.Price != "2900.00" {
+		t.Errorf("Prices = %s/%s, want 57000.00/2900.00", prices.BTC.Price, prices.ETH.Price)
+	}
+
+	clk.Advance(time.Hour)
+	price, err := client.GetPriceBySymbol(ctx, entity.BTC)
+	if err != nil || price.Price != "61200.50" {
+		t.Errorf("GetPriceBySymbol after an hour = %+v, %v, want 61200.50", price, err)
+	}
+
+	if _, err := client.GetPriceBySymbol(ctx, "DOGE"); err == nil {
+		t.Error("GetPriceBySymbol(DOGE) succeeded against a feed without it")
+	}
+
+	// A day later the 24h change is measured from the last fixture price.
+	clk.Set(fixtureStart.Add(24*time.Hour + 20*time.Minute))
+	resp, err := http.Get(srv.URL + TickersPath + "?category=spot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body tickersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.RetCode != 0 || len(body.Result.List) != 2 {
+		t.Fatalf("All tickers = %+v, want BTCUSDT and ETHUSDT", body)
+	}
+	btc := body.Result.List[0]
+	pcnt, _ := strconv.ParseFloat(btc.Price24hPcnt, 64)
+	if btc.Symbol != "BTCUSDT" || btc.PrevPrice24h != "57000.00" || pcnt != 0.0737 {
+		t.Errorf("BTC ticker = %+v, want a 7.37%% change from 57000.00", btc)
+	}
+	if btc.HighPrice24h != "61200.50" || btc.LowPrice24h != "57000.00" {
+		t.Errorf("BTC 24h range = %s-%s, want 57000.00-61200.50", btc.LowPrice24h, btc.HighPrice24h)
+	}
+	if btc.Bid1Price != "61197.44" || btc.Ask1Price != "61203.56" {
+		t.Errorf("BTC bid/ask = %s/%s, want 61197.44/61203.56", btc.Bid1Price, btc.Ask1Price)
+	}
+
+	// The exchange client carries the market data along with the price.
+	price, err = client.GetPriceBySymbol(ctx, entity.BTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price.Change24h != "0.0737" || price.High24h != "61200.50" || price.Bid != "61197.44" {
+		t.Errorf("GetPriceBySymbol = %+v, want the ticker of the simulator", price)
+	}
+}
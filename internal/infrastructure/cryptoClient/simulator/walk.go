@@ -0,0 +1,91 @@
+package simulator
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"tgBotFinal/internal/entity"
+)
+
+// startPrices are where the random walks begin.
+var startPrices = map[entity.CurrencyName]float64{
+	entity.BTC: 60000,
+	entity.ETH: 3000,
+}
+
+// maxStep is the largest relative move of a price in one step.
+const maxStep = 0.005
+
+// RandomWalk moves every price by a random step of up to ±0.5% once per
+// step interval after start. The price at a given time depends only on the
+// seed, not on when or how often it is read, so walks with the same seed
+// are the same.
+type RandomWalk struct {
+	start time.Time
+	step  time.Duration
+	seed  uint64
+
+	mu    sync.Mutex
+	walks map[entity.CurrencyName]*walk
+}
+
+type walk struct {
+	rnd *rand.Rand
+	// prices[i] is the price during the i-th step.
+	prices []float64
+}
+
+func NewRandomWalk(seed uint64, start time.Time, step time.Duration) *RandomWalk {
+	return &RandomWalk{
+		start: start,
+		step:  step,
+		seed:  seed,
+		walks: make(map[entity.CurrencyName]*walk),
+	}
+}
+
+func (w *RandomWalk) Symbols() []entity.CurrencyName {
+	symbols := make([]entity.CurrencyName, 0, len(startPrices))
+	for symbol := range startPrices {
+		symbols = append(symbols, symbol)
+	}
+	slices.Sort(symbols)
+
+	return symbols
+}
+
+// PriceAt returns the start price for times before start.
+func (w *RandomWalk) PriceAt(symbol entity.CurrencyName, at time.Time) (float64, error) {
+	start, ok := startPrices[symbol]
+	if !ok {
+		return 0, ErrUnknownSymbol
+	}
+
+	n := 0
+	if at.After(w.start) {
+		n = int(at.Sub(w.start) / w.step)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	sw, ok := w.walks[symbol]
+	if !ok {
+		// Every symbol has its own stream so that reading one does not
+		// move the others.
+		h := fnv.New64a()
+		h.Write([]byte(symbol))
+		sw = &walk{rnd: rand.New(rand.NewPCG(w.seed, h.Sum64())), prices: []float64{start}}
+		w.walks[symbol] = sw
+	}
+
+	for len(sw.prices) <= n {
+		last := sw.prices[len(sw.prices)-1]
+		sw.prices = append(sw.prices, last*(1+(sw.rnd.Float64()*2-1)*maxStep))
+	}
+
+	return sw.prices[n], nil
+}
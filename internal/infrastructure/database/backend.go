@@ -0,0 +1,36 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Backends, named after their database/sql driver and goose dialect.
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// ParseURL picks the backend for a DATABASE_URL and returns the data
+// source name its driver expects. sqlite:// URLs select SQLite with the
+// rest of the URL as the file path, e.g. sqlite://data/bot.db or
+// sqlite:///var/lib/bot/bot.db; anything else is handed to Postgres.
+func ParseURL(url string) (backend, dsn string) {
+	for _, prefix := range []string{"sqlite://", "sqlite3://"} {
+		if strings.HasPrefix(url, prefix) {
+			return SQLite, strings.TrimPrefix(url, prefix)
+		}
+	}
+
+	return Postgres, url
+}
+
+// MigrationsDir returns the directory holding backend's migrations. The
+// Postgres migrations live in base itself and the SQLite ones in its
+// sqlite subdirectory, which goose does not descend into.
+func MigrationsDir(base, backend string) string {
+	if backend == SQLite {
+		return filepath.Join(base, "sqlite")
+	}
+	return base
+}
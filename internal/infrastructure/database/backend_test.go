@@ -0,0 +1,33 @@
+package database
+
+import "testing"
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		backend string
+		dsn     string
+	}{
+		{"postgres://app@localhost:5432/bot?sslmode=disable", Postgres, "postgres://app@localhost:5432/bot?sslmode=disable"},
+		{"host=localhost dbname=bot", Postgres, "host=localhost dbname=bot"},
+		{"sqlite://data/bot.db", SQLite, "data/bot.db"},
+		{"sqlite:///var/lib/bot/bot.db", SQLite, "/var/lib/bot/bot.db"},
+		{"sqlite3://:memory:", SQLite, ":memory:"},
+	}
+
+	for _, tt := range tests {
+		backend, dsn := ParseURL(tt.url)
+		if backend != tt.backend || dsn != tt.dsn {
+			t.Errorf("ParseURL(%q) = %q, %q, want %q, %q", tt.url, backend, dsn, tt.backend, tt.dsn)
+		}
+	}
+}
+
+func TestMigrationsDir(t *testing.T) {
+	if got := MigrationsDir("migrations", Postgres); got != "migrations" {
+		t.Errorf("Postgres migrations dir = %q", got)
+	}
+	if got := MigrationsDir("migrations", SQLite); got != "migrations/sqlite" {
+		t.Errorf("SQLite migrations dir = %q", got)
+	}
+}
@@ -4,24 +4,29 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 
 	_ "github.com/lib/pq"
 	"github.com/pressly/goose/v3"
 )
 
 type Migrator struct {
-	db     *sql.DB
-	logger *slog.Logger
+	db      *sql.DB
+	backend string
+	logger  *slog.Logger
 }
 
-func NewMigrator(db *sql.DB, logger *slog.Logger) *Migrator {
-	return &Migrator{db: db, logger: logger.With(slog.String("component", "migrator"))}
+// NewMigrator returns a migrator for a database of the given backend. The
+// directories passed to its methods are resolved with MigrationsDir.
+func NewMigrator(db *sql.DB, backend string, logger *slog.Logger) *Migrator {
+	return &Migrator{db: db, backend: backend, logger: logger.With(slog.String("component", "migrator"))}
 }
 
 func (m *Migrator) Up(migrationsDir string) error {
+	migrationsDir = MigrationsDir(migrationsDir, m.backend)
 	m.logger.Info("Running database migrations", "dir", migrationsDir)
 
-	if err := goose.SetDialect("postgres"); err != nil {
+	if err := goose.SetDialect(m.backend); err != nil {
 		return fmt.Errorf("set dialect: %w", err)
 	}
 
@@ -34,9 +39,10 @@ func (m *Migrator) Up(migrationsDir string) error {
 }
 
 func (m *Migrator) Down(migrationsDir string) error {
+	migrationsDir = MigrationsDir(migrationsDir, m.backend)
 	m.logger.Info("Rolling back database migrations")
 
-	if err := goose.SetDialect("postgres"); err != nil {
+	if err := goose.SetDialect(m.backend); err != nil {
 		return fmt.Errorf("set dialect: %w", err)
 	}
 
@@ -49,11 +55,11 @@ func (m *Migrator) Down(migrationsDir string) error {
 }
 
 func (m *Migrator) Status(migrationsDir string) error {
-	if err := goose.SetDialect("postgres"); err != nil {
+	if err := goose.SetDialect(m.backend); err != nil {
 		return fmt.Errorf("set dialect: %w", err)
 	}
 
-	return goose.Status(m.db, migrationsDir)
+	return goose.Status(m.db, MigrationsDir(migrationsDir, m.backend))
 }
 
 func (m *Migrator) CheckAndMigrate(migrationsDir string) error {
@@ -63,3 +69,34 @@ func (m *Migrator) CheckAndMigrate(migrationsDir string) error {
 
 	return m.Up(migrationsDir)
 }
+
+// Create writes a new empty SQL migration named name into migrationsDir and
+// returns its path. It does not need a database connection.
+func Create(migrationsDir, name string) (string, error) {
+	pattern := filepath.Join(migrationsDir, "*.sql")
+
+	before, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", fmt.Errorf("list migrations: %w", err)
+	}
+	existing := make(map[string]bool, len(before))
+	for _, path := range before {
+		existing[path] = true
+	}
+
+	if err := goose.Create(nil, migrationsDir, name, "sql"); err != nil {
+		return "", fmt.Errorf("create migration: %w", err)
+	}
+
+	after, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", fmt.Errorf("list migrations: %w", err)
+	}
+	for _, path := range after {
+		if !existing[path] {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("create migration: file for %q not found", name)
+}
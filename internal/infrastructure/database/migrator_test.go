@@ -48,7 +48,7 @@ func TestMigrator_SkipIfNoDB(t *testing.T) {
 	}
 	defer testDB.Close()
 
-	migrator := NewMigrator(testDB, slog.Default())
+	migrator := NewMigrator(testDB, Postgres, slog.Default())
 	migrationsPath := "./testdata/migrations"
 
 	err = migrator.CheckAndMigrate(migrationsPath)
@@ -86,7 +86,7 @@ func TestMigrator_SkipIfNoDB(t *testing.T) {
 }
 
 func TestMigrator_Unit(t *testing.T) {
-	migrator := NewMigrator(nil, slog.Default())
+	migrator := NewMigrator(nil, Postgres, slog.Default())
 
 	assert.NotNil(t, migrator)
 
@@ -96,14 +96,14 @@ func TestMigrator_Unit(t *testing.T) {
 			db.Close()
 		}
 
-		migrator := NewMigrator(db, slog.Default())
+		migrator := NewMigrator(db, Postgres, slog.Default())
 		assert.NotNil(t, migrator)
 	})
 }
 
 func TestMigrator_WithMockDB(t *testing.T) {
 
-	migrator := NewMigrator(nil, slog.Default())
+	migrator := NewMigrator(nil, Postgres, slog.Default())
 
 	t.Run("NewMigrator", func(t *testing.T) {
 		assert.NotNil(t, migrator)
@@ -111,7 +111,7 @@ func TestMigrator_WithMockDB(t *testing.T) {
 
 	t.Run("MigratorWithLogger", func(t *testing.T) {
 		logger := slog.Default()
-		migrator := NewMigrator(nil, logger)
+		migrator := NewMigrator(nil, Postgres, logger)
 		assert.NotNil(t, migrator)
 	})
 }
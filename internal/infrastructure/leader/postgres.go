@@ -0,0 +1,181 @@
+// Package leader elects the replica that runs the scheduled workers.
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/infrastructure/metrics"
+)
+
+// PostgresElector elects a leader with a session-level Postgres advisory
+// lock. The lock lives as long as the connection that took it, so a replica
+// that crashes or loses the database hands leadership over without any
+// cleanup. The leader checks its connection every checkInterval and steps
+// down when the check fails; followers retry the lock at the same pace.
+type PostgresElector struct {
+	db            *sql.DB
+	lockKey       int64
+	instanceID    string
+	checkInterval time.Duration
+	logger        *slog.Logger
+
+	mu    sync.Mutex
+	since *time.Time
+}
+
+func NewPostgresElector(db *sql.DB, lockKey int64, instanceID string, checkInterval time.Duration, logger *slog.Logger) service.LeaderElector {
+	return &PostgresElector{
+		db:            db,
+		lockKey:       lockKey,
+		instanceID:    instanceID,
+		checkInterval: checkInterval,
+		logger: logger.With(slog.String("component", "leader.PostgresElector"),
+			slog.String("instance_id", instanceID)),
+	}
+}
+
+func (e *PostgresElector) Lead(ctx context.Context, work func(ctx context.Context)) error {
+	ticker := time.NewTicker(e.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		conn, err := e.tryLock(ctx)
+		if err != nil && ctx.Err() == nil {
+			e.logger.Warn("Failed to campaign for leadership", "error", err)
+		}
+		if conn != nil {
+			e.lead(ctx, conn, work)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *PostgresElector) Status() entity.LeaderStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return entity.LeaderStatus{InstanceID: e.instanceID, Leader: e.since != nil, Since: e.since}
+}
+
+// tryLock returns the connection holding the lock, or nil when another
+// replica holds it.
+func (e *PostgresElector) tryLock(ctx context.Context) (*sql.Conn, error) {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, e.lockKey).Scan(&locked); err != nil {
+		discard(conn)
+		return nil, fmt.Errorf("try advisory lock: %w", err)
+	}
+
+	if !locked {
+		conn.Close()
+		return nil, nil
+	}
+
+	return conn, nil
+}
+
+// lead runs work while conn keeps the lock, then releases it.
+func (e *PostgresElector) lead(ctx context.Context, conn *sql.Conn, work func(ctx context.Context)) {
+	e.setLeader(true)
+	e.logger.Info("Became leader")
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if r := recover(); r != nil {
+				e.logger.Error("panic in leader work", "recover", r)
+			}
+		}()
+		work(leaderCtx)
+	}()
+
+	ticker := time.NewTicker(e.checkInterval)
+	defer ticker.Stop()
+
+	for held := true; held; {
+		select {
+		case <-ctx.Done():
+			held = false
+		case <-done:
+			held = false
+		case <-ticker.C:
+			if err := e.check(ctx, conn); err != nil && ctx.Err() == nil {
+				e.logger.Warn("Lost leadership", "error", err)
+				held = false
+			}
+		}
+	}
+
+	cancel()
+	<-done
+
+	e.unlock(conn)
+	e.setLeader(false)
+	e.logger.Info("Stepped down as leader")
+}
+
+func (e *PostgresElector) check(ctx context.Context, conn *sql.Conn) error {
+	ctx, cancel := context.WithTimeout(ctx, e.checkInterval)
+	defer cancel()
+
+	return conn.PingContext(ctx)
+}
+
+// unlock releases the lock so a follower can take over right away. If that
+// fails the connection is discarded instead of going back to the pool, which
+// ends the session and releases the lock on the server side.
+func (e *PostgresElector) unlock(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var unlocked bool
+	err := conn.QueryRowContext(ctx, `SELECT pg_advisory_unlock($1)`, e.lockKey).Scan(&unlocked)
+	if err != nil || !unlocked {
+		e.logger.Warn("Failed to release leader lock, closing its session", "error", err)
+		discard(conn)
+		return
+	}
+
+	conn.Close()
+}
+
+func (e *PostgresElector) setLeader(leader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if leader {
+		now := time.Now()
+		e.since = &now
+		metrics.Leader.Set(1)
+	} else {
+		e.since = nil
+		metrics.Leader.Set(0)
+	}
+}
+
+// discard closes the physical connection behind conn rather than returning
+// it to the pool.
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	conn.Close()
+}
@@ -0,0 +1,98 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=postgres sslmode=disable")
+	if err != nil {
+		t.Skip("PostgreSQL not available, skipping leader election test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		t.Skip("PostgreSQL connection failed, skipping leader election test")
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestPostgresElector_Handover(t *testing.T) {
+	db := openTestDB(t)
+	lockKey := time.Now().UnixNano()
+
+	first := NewPostgresElector(db, lockKey, "first", 20*time.Millisecond, slog.Default())
+	second := NewPostgresElector(db, lockKey, "second", 20*time.Millisecond, slog.Default())
+
+	firstCtx, stopFirst := context.WithCancel(context.Background())
+	defer stopFirst()
+	secondCtx, stopSecond := context.WithCancel(context.Background())
+	defer stopSecond()
+
+	leading := make(chan string, 2)
+	work := func(name string) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			leading <- name
+			<-ctx.Done()
+		}
+	}
+
+	firstDone := make(chan struct{})
+	go func() {
+		defer close(firstDone)
+		first.Lead(firstCtx, work("first"))
+	}()
+
+	if got := waitLeader(t, leading); got != "first" {
+		t.Fatalf("leader = %s, want first", got)
+	}
+
+	go second.Lead(secondCtx, work("second"))
+
+	select {
+	case name := <-leading:
+		t.Fatalf("%s leads while first holds the lock", name)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if second.Status().Leader {
+		t.Fatal("second reports leadership while first holds the lock")
+	}
+
+	stopFirst()
+	<-firstDone
+	if first.Status().Leader {
+		t.Error("first still reports leadership after shutdown")
+	}
+
+	if got := waitLeader(t, leading); got != "second" {
+		t.Fatalf("leader after handover = %s, want second", got)
+	}
+	if status := second.Status(); !status.Leader || status.InstanceID != "second" || status.Since == nil {
+		t.Errorf("second.Status() = %+v, want leader with a start time", status)
+	}
+}
+
+func waitLeader(t *testing.T, leading <-chan string) string {
+	t.Helper()
+
+	select {
+	case name := <-leading:
+		return name
+	case <-time.After(2 * time.Second):
+		t.Fatal("no replica became leader")
+		return ""
+	}
+}
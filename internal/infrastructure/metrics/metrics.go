@@ -0,0 +1,177 @@
+// Package metrics holds the Prometheus collectors shared by the bot's
+// subsystems and the handler that exposes them.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "tgbot"
+
+// Registry is the registry served on /metrics. A dedicated registry keeps
+// test binaries and libraries from leaking collectors into the output.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route pattern, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route pattern and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	BybitRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bybit_requests_total",
+		Help:      "Bybit ticker requests by symbol and result (ok or error).",
+	}, []string{"symbol", "result"})
+
+	BybitDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "bybit_request_duration_seconds",
+		Help:      "Bybit ticker request latency by symbol.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"symbol"})
+
+	CacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "price_cache_lookups_total",
+		Help:      "Price cache lookups by result (hit, miss or stale).",
+	}, []string{"result"})
+
+	NotificationsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Telegram messages sent by result (ok or an error class).",
+	}, []string{"result"})
+
+	WorkerTickDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "worker_tick_duration_seconds",
+		Help:      "Time spent in a single tick of a background worker.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"worker"})
+
+	Updates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "updates_total",
+		Help:      "Telegram updates by result (queued, rejected or dropped).",
+	}, []string{"result"})
+
+	UpdateQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "update_queue_depth",
+		Help:      "Telegram updates waiting for a worker.",
+	})
+
+	UpdateQueueWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "update_queue_wait_seconds",
+		Help:      "Time a Telegram update waited in the queue before a worker took it.",
+		Buckets:   []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10, 30},
+	})
+
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_commands_total",
+		Help:      "Bot commands refused by rate limiting, by limit (chat, command or global).",
+	}, []string{"limit"})
+
+	Bans = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chat_bans_total",
+		Help:      "Chats banned automatically for exceeding rate limits.",
+	})
+
+	Leader = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "leader",
+		Help:      "1 while this replica is the leader running the scheduled workers, 0 otherwise.",
+	})
+)
+
+// cacheAge reports the age of the price cache. It is set by the cache owner
+// through TrackCacheAge so the gauge can be registered once at start-up.
+var cacheAge atomic.Pointer[func() time.Duration]
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		BybitRequests,
+		BybitDuration,
+		CacheLookups,
+		NotificationsSent,
+		WorkerTickDuration,
+		Updates,
+		UpdateQueueDepth,
+		UpdateQueueWait,
+		RateLimited,
+		Bans,
+		Leader,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "price_cache_age_seconds",
+			Help:      "Seconds since the price cache was last filled; 0 when empty.",
+		}, func() float64 {
+			if fn := cacheAge.Load(); fn != nil {
+				return (*fn)().Seconds()
+			}
+			return 0
+		}),
+	)
+}
+
+// TrackCacheAge makes the price_cache_age_seconds gauge report fn.
+func TrackCacheAge(fn func() time.Duration) {
+	cacheAge.Store(&fn)
+}
+
+// RegisterDB exports connection pool statistics for db.
+func RegisterDB(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Observer records the measurements of the domain services in the
+// collectors of this package.
+type Observer struct{}
+
+func (Observer) WorkerTick(worker string, elapsed time.Duration) {
+	WorkerTickDuration.WithLabelValues(worker).Observe(elapsed.Seconds())
+}
+
+func (Observer) Leading(leader bool) {
+	if leader {
+		Leader.Set(1)
+	} else {
+		Leader.Set(0)
+	}
+}
+
+func (Observer) RateLimited(limit string) {
+	RateLimited.WithLabelValues(limit).Inc()
+}
+
+func (Observer) Banned() {
+	Bans.Inc()
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,68 @@
+// Package console is a service.Notification that logs the messages it is
+// asked to send instead of sending them, for the demo mode.
+package console
+
+import (
+	"context"
+	"log/slog"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+type Notification struct {
+	logger *slog.Logger
+}
+
+func NewNotification(logger *slog.Logger) service.Notification {
+	return &Notification{logger: logger.With(slog.String("component", "console.Notification"))}
+}
+
+func (n *Notification) SendAllPrices(ctx context.Context, chatID int64, prices *entity.PriceResponse) error {
+	attrs := []any{"chat_id", chatID, "stale", prices.Stale()}
+	if prices.BTC != nil {
+		attrs = append(attrs, "BTC", prices.BTC.Price)
+	}
+	if prices.ETH != nil {
+		attrs = append(attrs, "ETH", prices.ETH.Price)
+	}
+
+	n.logger.InfoContext(ctx, "Send prices", attrs...)
+	return nil
+}
+
+func (n *Notification) ActivateUser(ctx context.Context, chatID int64) error {
+	n.logger.InfoContext(ctx, "Send message", "chat_id", chatID, "text", "You have been successfully activated")
+	return nil
+}
+
+func (n *Notification) DeactivateUser(ctx context.Context, chatID int64) error {
+	n.logger.InfoContext(ctx, "Send message", "chat_id", chatID, "text", "You have been successfully deactivated")
+	return nil
+}
+
+func (n *Notification) SendInfoMessage(ctx context.Context, chatID int64, text string) error {
+	n.logger.InfoContext(ctx, "Send message", "chat_id", chatID, "text", text)
+	return nil
+}
+
+func (n *Notification) SendDigest(ctx context.Context, chatID int64, digest *entity.Digest) error {
+	attrs := []any{"chat_id", chatID, "period", digest.Period}
+	for _, ohlc := range digest.Symbols {
+		attrs = append(attrs, string(ohlc.Symbol), ohlc.Close)
+	}
+
+	n.logger.InfoContext(ctx, "Send digest", attrs...)
+	return nil
+}
+
+func (n *Notification) CheckAPI(ctx context.Context) error {
+	return nil
+}
+
+// SetWebhook only logs: there is no Telegram behind the console to deliver
+// updates.
+func (n *Notification) SetWebhook(ctx context.Context, url string) error {
+	n.logger.InfoContext(ctx, "Set webhook", "url", url)
+	return nil
+}
@@ -2,50 +2,77 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strings"
 	"tgBotFinal/internal/domain/service"
 	"time"
 
+	"tgBotFinal/internal/clock"
 	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/infrastructure/metrics"
+	"tgBotFinal/internal/infrastructure/tracing"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// DefaultAPIURL is the public Telegram Bot API server.
+const DefaultAPIURL = "https://api.telegram.org"
+
+// maxRetryAfter bounds how long a send waits when Telegram asks to slow
+// down. Longer waits fail the send instead of holding up the caller.
+const maxRetryAfter = 30 * time.Second
+
 type NotificationTelegram struct {
 	api    *tgbotapi.BotAPI
+	clock  clock.Clock
 	logger *slog.Logger
 }
 
-func NewNotificationTelegram(logger *slog.Logger, token string) (service.Notification, error) {
+// NewBotAPI connects to the Bot API server at apiURL, such as DefaultAPIURL
+// or a self-hosted server, and checks the token with getMe.
+func NewBotAPI(token, apiURL string) (*tgbotapi.BotAPI, error) {
+	return tgbotapi.NewBotAPIWithAPIEndpoint(token, strings.TrimSuffix(apiURL, "/")+"/bot%s/%s")
+}
+
+// NewNotificationTelegram sends through the Bot API server at apiURL. clk
+// dates price messages and times the wait after a rate limit.
+func NewNotificationTelegram(logger *slog.Logger, token, apiURL string, clk clock.Clock) (service.Notification, error) {
 
-	bot, err := tgbotapi.NewBotAPI(token)
+	bot, err := NewBotAPI(token, apiURL)
 	if err != nil {
 		logger.Error("error creating telegram bot ", "error", err)
 		return nil, err
 	}
 	notificationTelegram := &NotificationTelegram{
 		api:    bot,
+		clock:  clk,
 		logger: logger.With(slog.String("component", "NotificationTelegram")),
 	}
 	return notificationTelegram, nil
 }
 
 func (n *NotificationTelegram) SendAllPrices(ctx context.Context, chatID int64, prices *entity.PriceResponse) error {
-	n.logger.Debug("Starting sendAllPrices")
+	n.logger.DebugContext(ctx, "Starting sendAllPrices")
 
 	message := "Current Crypto Prices: \n"
-	if prices.BTC != nil {
-		message += fmt.Sprintf("BTC: %s\n", prices.BTC.Price)
-	}
-	if prices.ETH != nil {
-		message += fmt.Sprintf("ETH: %s\n", prices.ETH.Price)
+	for _, price := range prices.List() {
+		message += formatPrice(price) + "\n"
 	}
 
-	message += fmt.Sprintf("Last update: %s", time.Now().Format("2006-01-02 15:04:05"))
+	message += fmt.Sprintf("Last update: %s", n.clock.Now().Format("2006-01-02 15:04:05"))
+	if prices.Stale() {
+		message += "\nPrices may be outdated: the exchange is not responding."
+	}
 
-	if err := n.sendMessage(chatID, message); err != nil {
-		n.logger.Error("error sending message ", "error", err)
+	if err := n.sendMessage(ctx, chatID, message); err != nil {
+		n.logger.ErrorContext(ctx, "error sending message ", "error", err)
 		return err
 	}
 
@@ -53,10 +80,10 @@ func (n *NotificationTelegram) SendAllPrices(ctx context.Context, chatID int64,
 }
 
 func (n *NotificationTelegram) ActivateUser(ctx context.Context, chatID int64) error {
-	n.logger.Debug("Starting activateUser")
+	n.logger.DebugContext(ctx, "Starting activateUser")
 	message := "You have been successfully activated"
-	if err := n.sendMessage(chatID, message); err != nil {
-		n.logger.Error("error sending message ", "error", err)
+	if err := n.sendMessage(ctx, chatID, message); err != nil {
+		n.logger.ErrorContext(ctx, "error sending message ", "error", err)
 		return err
 	}
 
@@ -64,12 +91,12 @@ func (n *NotificationTelegram) ActivateUser(ctx context.Context, chatID int64) e
 }
 
 func (n *NotificationTelegram) DeactivateUser(ctx context.Context, chatID int64) error {
-	n.logger.Debug("Starting deactivateUser")
+	n.logger.DebugContext(ctx, "Starting deactivateUser")
 
 	message := "You have been successfully deactivated"
 
-	if err := n.sendMessage(chatID, message); err != nil {
-		n.logger.Error("error sending message ", "error", err)
+	if err := n.sendMessage(ctx, chatID, message); err != nil {
+		n.logger.ErrorContext(ctx, "error sending message ", "error", err)
 		return err
 	}
 
@@ -77,35 +104,185 @@ func (n *NotificationTelegram) DeactivateUser(ctx context.Context, chatID int64)
 }
 
 func (n *NotificationTelegram) SendInfoMessage(ctx context.Context, chatID int64, text string) error {
-	n.logger.Debug("Starting sendInfoMessage")
+	n.logger.DebugContext(ctx, "Starting sendInfoMessage")
 
-	if err := n.sendMessage(chatID, text); err != nil {
-		n.logger.Error("error sending message ", "error", err)
+	if err := n.sendMessage(ctx, chatID, text); err != nil {
+		n.logger.ErrorContext(ctx, "error sending message ", "error", err)
 		return err
 	}
 
 	return nil
 }
 
-func (n *NotificationTelegram) sendMessage(chatID int64, text string) error {
+func (n *NotificationTelegram) SendDigest(ctx context.Context, chatID int64, digest *entity.Digest) error {
+	n.logger.DebugContext(ctx, "Starting sendDigest")
+
+	if err := n.sendMessage(ctx, chatID, formatDigest(digest)); err != nil {
+		n.logger.ErrorContext(ctx, "error sending message ", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// formatPrice adds the 24 hour change and the spread to a price when the
+// exchange reported them:
+//
+//	BTC: 60000.00 ▲ +1.23% (spread 0.10)
+func formatPrice(price *entity.Price) string {
+	line := fmt.Sprintf("%s: %s", price.Symbol, price.Price)
+
+	if change, ok := price.ChangePercent(); ok {
+		// Rounded first so that the arrow agrees with the number shown.
+		change = math.Round(change*100) / 100
+		var arrow string
+		switch {
+		case change > 0:
+			arrow = "▲"
+		case change < 0:
+			arrow = "▼"
+		default:
+			arrow, change = "▶", 0 // not -0
+		}
+		line += fmt.Sprintf(" %s %+.2f%%", arrow, change)
+	}
+
+	if spread, ok := price.Spread(); ok {
+		line += fmt.Sprintf(" (spread %s)", spread)
+	}
+
+	return line
+}
+
+func formatDigest(digest *entity.Digest) string {
+	var b strings.Builder
+
+	title := "Daily"
+	if digest.Period == entity.DigestWeekly {
+		title = "Weekly"
+	}
+
+	fmt.Fprintf(&b, "%s Market Digest\n", title)
+	fmt.Fprintf(&b, "%s - %s (UTC)\n\n",
+		digest.From.UTC().Format("2006-01-02 15:04"), digest.To.UTC().Format("2006-01-02 15:04"))
+
+	if len(digest.Symbols) == 0 {
+		b.WriteString("No price history for this period yet.")
+		return b.String()
+	}
+
+	for _, s := range digest.Symbols {
+		fmt.Fprintf(&b, "%s: O %.2f  H %.2f  L %.2f  C %.2f (%+.2f%%)\n",
+			s.Symbol, s.Open, s.High, s.Low, s.Close, s.ChangePct)
+	}
+
+	b.WriteString("\nBiggest movers:\n")
+	for i, s := range digest.Movers {
+		fmt.Fprintf(&b, "%d. %s %+.2f%%\n", i+1, s.Symbol, s.ChangePct)
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (n *NotificationTelegram) sendMessage(ctx context.Context, chatID int64, text string) error {
+	_, span := tracing.Start(ctx, "telegram.sendMessage", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int64("telegram.chat_id", chatID)))
+
 	msg := tgbotapi.NewMessage(chatID, text)
 	_, err := n.api.Send(msg)
+	if wait, ok := retryAfter(err); ok {
+		n.logger.WarnContext(ctx, "telegram rate limit hit, retrying", "chat_id", chatID, "retry_after", wait)
+		select {
+		case <-ctx.Done():
+		case <-n.clock.After(wait):
+			_, err = n.api.Send(msg)
+		}
+	}
+
+	class := errorClass(err)
+	span.SetAttributes(attribute.String("telegram.result", class))
+	tracing.End(span, err)
+	metrics.NotificationsSent.WithLabelValues(class).Inc()
+
+	return classifyError(err)
+}
+
+// retryAfter reports how long Telegram asked to wait before retrying a
+// rate-limited request, if the wait is short enough to honour.
+func retryAfter(err error) (time.Duration, bool) {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	wait := time.Duration(apiErr.RetryAfter) * time.Second
+	return wait, wait > 0 && wait <= maxRetryAfter
+}
+
+// errorClass groups send errors into a small fixed set of metric labels.
+func errorClass(err error) string {
+	if err == nil {
+		return "ok"
+	}
+
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusForbidden:
+			return "blocked"
+		case http.StatusTooManyRequests:
+			return "rate_limited"
+		case http.StatusBadRequest:
+			return "bad_request"
+		default:
+			return "api_error"
+		}
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return "timeout"
+	}
+
+	return "network"
+}
+
+// classifyError wraps Telegram API errors that mean the chat is gone so that
+// callers can recognise them with errors.Is(err, service.ErrChatBlocked).
+func classifyError(err error) error {
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusForbidden {
+		return fmt.Errorf("%w: %s", service.ErrChatBlocked, apiErr.Message)
+	}
+
 	return err
 }
 
 func (n *NotificationTelegram) CheckAPI(ctx context.Context) error {
-	n.logger.Debug("Starting checkAPI")
+	n.logger.DebugContext(ctx, "Starting checkAPI")
 
 	_, err := n.api.GetMe()
 	if err != nil {
-		n.logger.Error("telegram API check failed", "error", err)
+		n.logger.ErrorContext(ctx, "telegram API check failed", "error", err)
 		return fmt.Errorf("telegram API unavailable: %w", err)
 	}
 
-	n.logger.Debug("Telegram API check passed")
+	n.logger.DebugContext(ctx, "Telegram API check passed")
 	return nil
 }
 
-func (n *NotificationTelegram) GetBotAPI() *tgbotapi.BotAPI {
-	return n.api
+func (n *NotificationTelegram) SetWebhook(ctx context.Context, url string) error {
+	n.logger.DebugContext(ctx, "Starting setWebhook")
+
+	config, err := tgbotapi.NewWebhook(url)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	if _, err := n.api.Request(config); err != nil {
+		n.logger.ErrorContext(ctx, "error setting webhook", "error", err)
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,134 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/clock"
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/infrastructure/notification/telegram/telegramtest"
+)
+
+func newTestNotification(t *testing.T) (service.Notification, *telegramtest.Server, *clock.Fake) {
+	t.Helper()
+
+	srv := telegramtest.NewServer("test-token")
+	t.Cleanup(srv.Close)
+
+	clk := clock.NewFake(time.Date(2025, 1, 6, 9, 30, 0, 0, time.UTC))
+	n, err := NewNotificationTelegram(slog.Default(), "test-token", srv.URL, clk)
+	if err != nil {
+		t.Fatalf("NewNotificationTelegram failed: %v", err)
+	}
+	return n, srv, clk
+}
+
+func TestNewNotificationTelegram_BadToken(t *testing.T) {
+	srv := telegramtest.NewServer("test-token")
+	defer srv.Close()
+
+	if _, err := NewNotificationTelegram(slog.Default(), "wrong-token", srv.URL, clock.Real()); err == nil {
+		t.Error("NewNotificationTelegram accepted a token the API refuses")
+	}
+}
+
+func TestNotificationTelegram_Send(t *testing.T) {
+	n, srv, _ := newTestNotification(t)
+	ctx := context.Background()
+
+	prices := &entity.PriceResponse{
+		BTC: &entity.Price{Symbol: entity.BTC, Price: "60000.00"},
+		ETH: &entity.Price{Symbol: entity.ETH, Price: "3000.00"},
+	}
+	if err := n.SendAllPrices(ctx, 42, prices); err != nil {
+		t.Fatalf("SendAllPrices failed: %v", err)
+	}
+	if err := n.DeactivateUser(ctx, 42); err != nil {
+		t.Fatalf("DeactivateUser failed: %v", err)
+	}
+
+	got := srv.MessagesTo(42)
+	if len(got) != 2 {
+		t.Fatalf("Sent %d messages, want 2: %+v", len(got), got)
+	}
+	if want := "Current Crypto Prices: \nBTC: 60000.00\nETH: 3000.00\nLast update: 2025-01-06 09:30:00"; got[0].Text != want {
+		t.Errorf("Prices message = %q, want %q", got[0].Text, want)
+	}
+	if got[1].Text != "You have been successfully deactivated" {
+		t.Errorf("Deactivation message = %q", got[1].Text)
+	}
+}
+
+func TestNotificationTelegram_BlockedChat(t *testing.T) {
+	n, srv, _ := newTestNotification(t)
+	srv.BlockChat(42)
+
+	err := n.SendInfoMessage(context.Background(), 42, "hello")
+	if !errors.Is(err, service.ErrChatBlocked) {
+		t.Errorf("SendInfoMessage to a blocked chat = %v, want ErrChatBlocked", err)
+	}
+	if got := srv.Messages(); len(got) != 0 {
+		t.Errorf("Blocked chat received %+v", got)
+	}
+}
+
+func TestNotificationTelegram_RateLimited(t *testing.T) {
+	n, srv, clk := newTestNotification(t)
+	ctx := context.Background()
+
+	srv.RateLimit(42, 1, 10)
+	sent := make(chan error, 1)
+	go func() { sent <- n.SendInfoMessage(ctx, 42, "hello") }()
+
+	clk.BlockUntil(1)
+	clk.Advance(10 * time.Second)
+	if err := <-sent; err != nil {
+		t.Fatalf("SendInfoMessage was not retried after the rate limit: %v", err)
+	}
+	if got := srv.MessagesTo(42); len(got) != 1 || got[0].Text != "hello" {
+		t.Errorf("Sent messages = %+v, want the retried one", got)
+	}
+
+	// A wait longer than the bot is willing to hold the caller fails.
+	srv.RateLimit(42, 1, 3600)
+	if err := n.SendInfoMessage(ctx, 42, "again"); err == nil {
+		t.Error("SendInfoMessage waited out a one hour rate limit")
+	}
+}
+
+func TestNotificationTelegram_SetWebhook(t *testing.T) {
+	n, srv, _ := newTestNotification(t)
+
+	if err := n.SetWebhook(context.Background(), "https://bot.example.com/webhook/telegram"); err != nil {
+		t.Fatalf("SetWebhook failed: %v", err)
+	}
+	if got := srv.Webhook(); got != "https://bot.example.com/webhook/telegram" {
+		t.Errorf("Registered webhook = %q", got)
+	}
+}
+
+func TestFormatPrice(t *testing.T) {
+	tests := []struct {
+		name   string
+		ticker entity.Ticker
+		want   string
+	}{
+		{"No market data", entity.Ticker{}, "BTC: 60000.00"},
+		{"Rising", entity.Ticker{Change24h: "0.0123", Bid: "59999.9", Ask: "60000.0"}, "BTC: 60000.00 ▲ +1.23% (spread 0.1)"},
+		{"Falling", entity.Ticker{Change24h: "-0.05"}, "BTC: 60000.00 ▼ -5.00%"},
+		{"Flat after rounding", entity.Ticker{Change24h: "-0.00001"}, "BTC: 60000.00 ▶ +0.00%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price := &entity.Price{Symbol: entity.BTC, Price: "60000.00", Ticker: tt.ticker}
+			if got := formatPrice(price); got != tt.want {
+				t.Errorf("formatPrice() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+// Package telegramtest is an in-process fake of the Telegram Bot API for
+// tests. Point TG_API_URL (or telegram.NewBotAPI) at Server.URL: the fake
+// records the messages the bot sends, fails sends on demand and pushes
+// updates to the registered webhook the way Telegram does.
+package telegramtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BotID is the user ID getMe reports for the fake bot.
+const BotID = 1000
+
+// Message is a message the bot sent.
+type Message struct {
+	ChatID    int64
+	Text      string
+	ParseMode string
+}
+
+type Server struct {
+	// URL is the base URL of the fake, to be used as TG_API_URL.
+	URL string
+
+	token string
+	srv   *httptest.Server
+
+	mu           sync.Mutex
+	messages     []Message
+	blocked      map[int64]bool
+	rateLimits   map[int64]rateLimit
+	webhook      string
+	nextUpdateID int
+	// changed is closed and replaced whenever a message is recorded.
+	changed chan struct{}
+}
+
+type rateLimit struct {
+	times      int
+	retryAfter int
+}
+
+// NewServer starts a fake that accepts requests for token. Close it when
+// done.
+func NewServer(token string) *Server {
+	s := &Server{
+		token:      token,
+		blocked:    make(map[int64]bool),
+		rateLimits: make(map[int64]rateLimit),
+		changed:    make(chan struct{}),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.srv.URL
+
+	return s
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Messages returns every message sent so far, in order.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+// MessagesTo returns the messages sent to chatID so far, in order.
+func (s *Server) MessagesTo(chatID int64) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.messagesTo(chatID)
+}
+
+func (s *Server) messagesTo(chatID int64) []Message {
+	var messages []Message
+	for _, m := range s.messages {
+		if m.ChatID == chatID {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// WaitMessages waits until at least n messages were sent to chatID and
+// returns them, or returns ctx.Err() with the messages sent so far.
+func (s *Server) WaitMessages(ctx context.Context, chatID int64, n int) ([]Message, error) {
+	for {
+		s.mu.Lock()
+		messages, changed := s.messagesTo(chatID), s.changed
+		s.mu.Unlock()
+
+		if len(messages) >= n {
+			return messages, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return messages, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// BlockChat makes every later send to chatID fail with 403, as when the
+// user blocked the bot.
+func (s *Server) BlockChat(chatID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blocked[chatID] = true
+}
+
+// RateLimit makes the next times sends to chatID fail with 429, asking the
+// bot to retry after retryAfter seconds.
+func (s *Server) RateLimit(chatID int64, times, retryAfter int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rateLimits[chatID] = rateLimit{times: times, retryAfter: retryAfter}
+}
+
+// Webhook returns the URL updates are pushed to, empty if none is set.
+func (s *Server) Webhook() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.webhook
+}
+
+// SetWebhook sets the URL updates are pushed to, like the setWebhook
+// method. Tests use it for plain-HTTP URLs the bot itself refuses to
+// register.
+func (s *Server) SetWebhook(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.webhook = url
+}
+
+// SendUpdate delivers a private text message from chatID to the webhook and
+// fails unless the bot acknowledges it.
+func (s *Server) SendUpdate(ctx context.Context, chatID int64, username, text string) error {
+	s.mu.Lock()
+	webhook := s.webhook
+	s.nextUpdateID++
+	updateID := s.nextUpdateID
+	s.mu.Unlock()
+
+	if webhook == "" {
+		return errors.New("telegramtest: no webhook set")
+	}
+
+	update := map[string]any{
+		"update_id": updateID,
+		"message": map[string]any{
+			"message_id": updateID,
+			"from":       map[string]any{"id": chatID, "is_bot": false, "username": username},
+			"chat":       map[string]any{"id": chatID, "type": "private", "username": username},
+			"date":       time.Now().Unix(),
+			"text":       text,
+		},
+	}
+	body, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegramtest: webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// handle serves /bot<token>/<method> the way the Bot API does: form
+// parameters in, {"ok": ..., "result": ...} out.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	token, method, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/bot"), "/")
+	if !ok || token != s.token {
+		writeError(w, http.StatusUnauthorized, "Unauthorized", 0)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: "+err.Error(), 0)
+		return
+	}
+
+	switch method {
+	case "getMe":
+		writeResult(w, map[string]any{"id": BotID, "is_bot": true, "first_name": "Fake", "username": "fake_bot"})
+	case "sendMessage":
+		s.sendMessage(w, r)
+	case "setWebhook":
+		s.SetWebhook(r.Form.Get("url"))
+		writeResult(w, true)
+	case "deleteWebhook":
+		s.SetWebhook("")
+		writeResult(w, true)
+	case "getWebhookInfo":
+		writeResult(w, map[string]any{"url": s.Webhook(), "has_custom_certificate": false, "pending_update_count": 0})
+	default:
+		writeError(w, http.StatusNotFound, "Not Found: method not found", 0)
+	}
+}
+
+func (s *Server) sendMessage(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.Form.Get("chat_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: chat not found", 0)
+		return
+	}
+	text := r.Form.Get("text")
+	if text == "" {
+		writeError(w, http.StatusBadRequest, "Bad Request: message text is empty", 0)
+		return
+	}
+
+	s.mu.Lock()
+	if s.blocked[chatID] {
+		s.mu.Unlock()
+		writeError(w, http.StatusForbidden, "Forbidden: bot was blocked by the user", 0)
+		return
+	}
+	if limit := s.rateLimits[chatID]; limit.times > 0 {
+		limit.times--
+		s.rateLimits[chatID] = limit
+		s.mu.Unlock()
+		writeError(w, http.StatusTooManyRequests, "Too Many Requests: retry after "+strconv.Itoa(limit.retryAfter), limit.retryAfter)
+		return
+	}
+
+	s.messages = append(s.messages, Message{ChatID: chatID, Text: text, ParseMode: r.Form.Get("parse_mode")})
+	messageID := len(s.messages)
+	close(s.changed)
+	s.changed = make(chan struct{})
+	s.mu.Unlock()
+
+	writeResult(w, map[string]any{
+		"message_id": messageID,
+		"from":       map[string]any{"id": BotID, "is_bot": true, "username": "fake_bot"},
+		"chat":       map[string]any{"id": chatID, "type": "private"},
+		"date":       time.Now().Unix(),
+		"text":       text,
+	})
+}
+
+func writeResult(w http.ResponseWriter, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+func writeError(w http.ResponseWriter, code int, description string, retryAfter int) {
+	resp := map[string]any{"ok": false, "error_code": code, "description": description}
+	if retryAfter > 0 {
+		resp["parameters"] = map[string]any{"retry_after": retryAfter}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,274 @@
+package chi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"tgBotFinal/internal/domain/service"
+	"time"
+
+	"tgBotFinal/internal/entity"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultPerPage = 50
+	maxPerPage     = 500
+)
+
+type userListResponse struct {
+	Users   []*entity.User `json:"users"`
+	Total   int            `json:"total"`
+	Page    int            `json:"page"`
+	PerPage int            `json:"per_page"`
+}
+
+func (c *ChiRouter) setupAdminRoutes(r chi.Router) {
+	r.Group(c.setupUserRoutes)
+	r.Group(c.setupBroadcastRoutes)
+	r.Group(c.setupSystemRoutes)
+}
+
+func (c *ChiRouter) setupUserRoutes(r chi.Router) {
+	r.Use(c.requireScope(entity.ScopeAdminUsers))
+
+	r.Get("/users", c.listUsersHandler)
+	r.Get("/users/stats", c.userGrowthHandler)
+	r.Get("/users/{chatID}", c.getUserHandler)
+	r.Post("/users/{chatID}/activate", c.setUserActiveHandler(true))
+	r.Post("/users/{chatID}/deactivate", c.setUserActiveHandler(false))
+	r.Post("/users/{chatID}/unban", c.unbanUserHandler)
+	r.Delete("/users/{chatID}", c.deleteUserHandler)
+}
+
+func (c *ChiRouter) listUsersHandler(w http.ResponseWriter, r *http.Request) {
+	filter, page, perPage, err := parseUserFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	users, err := c.userRepo.List(r.Context(), filter)
+	if err != nil {
+		c.logger.ErrorContext(r.Context(), "Error listing users", "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	total, err := c.userRepo.Count(r.Context(), filter)
+	if err != nil {
+		c.logger.ErrorContext(r.Context(), "Error counting users", "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userListResponse{
+		Users:   users,
+		Total:   total,
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+func (c *ChiRouter) getUserHandler(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := chatIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := c.userRepo.GetByChatID(r.Context(), chatID)
+	if errors.Is(err, service.ErrUserNotFound) {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		c.logger.ErrorContext(r.Context(), "Error getting user", "chat_id", chatID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+func (c *ChiRouter) setUserActiveHandler(active bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatID, ok := chatIDParam(w, r)
+		if !ok {
+			return
+		}
+
+		err := c.userRepo.SetActive(r.Context(), chatID, active)
+		if errors.Is(err, service.ErrUserNotFound) {
+			writeError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		if err != nil {
+			c.logger.ErrorContext(r.Context(), "Error setting user active", "chat_id", chatID, "error", err)
+			writeError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		c.logger.InfoContext(r.Context(), "Admin changed user state", "chat_id", chatID, "active", active)
+		writeJSON(w, http.StatusOK, map[string]any{"chat_id": chatID, "active": active})
+	}
+}
+
+func (c *ChiRouter) unbanUserHandler(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := chatIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	err := c.userRepo.SetBannedUntil(r.Context(), chatID, nil)
+	if errors.Is(err, service.ErrUserNotFound) {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		c.logger.ErrorContext(r.Context(), "Error unbanning user", "chat_id", chatID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if c.limiter != nil {
+		c.limiter.Reset(chatID)
+	}
+
+	c.logger.InfoContext(r.Context(), "Admin unbanned user", "chat_id", chatID)
+	writeJSON(w, http.StatusOK, map[string]any{"chat_id": chatID, "banned": false})
+}
+
+func (c *ChiRouter) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	chatID, ok := chatIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	err := c.userRepo.Delete(r.Context(), chatID)
+	if errors.Is(err, service.ErrUserNotFound) {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		c.logger.ErrorContext(r.Context(), "Error deleting user", "chat_id", chatID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	c.logger.InfoContext(r.Context(), "Admin deleted user", "chat_id", chatID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *ChiRouter) userGrowthHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	bucket := entity.GrowthBucket(q.Get("bucket"))
+	switch bucket {
+	case "":
+		bucket = entity.GrowthDay
+	case entity.GrowthDay, entity.GrowthWeek, entity.GrowthMonth:
+	default:
+		writeError(w, http.StatusBadRequest, "bucket must be one of day, week, month")
+		return
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse(time.DateOnly, v); err != nil {
+			writeError(w, http.StatusBadRequest, "from must be YYYY-MM-DD")
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse(time.DateOnly, v); err != nil {
+			writeError(w, http.StatusBadRequest, "to must be YYYY-MM-DD")
+			return
+		}
+	}
+	if to.Before(from) {
+		writeError(w, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+
+	points, err := c.userRepo.GrowthStats(r.Context(), from, to, bucket)
+	if err != nil {
+		c.logger.ErrorContext(r.Context(), "Error getting user growth stats", "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"bucket": bucket,
+		"from":   from,
+		"to":     to,
+		"points": points,
+	})
+}
+
+// parseUserFilter reads active, digest, q, page and per_page query parameters.
+func parseUserFilter(r *http.Request) (entity.UserFilter, int, int, error) {
+	q := r.URL.Query()
+	filter := entity.UserFilter{Username: q.Get("q")}
+
+	if v := q.Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, 0, 0, errors.New("active must be true or false")
+		}
+		filter.Active = &active
+	}
+
+	if v := q.Get("digest"); v != "" {
+		period, ok := entity.ParseDigestPeriod(v)
+		if !ok {
+			return filter, 0, 0, errors.New("digest must be one of off, daily, weekly")
+		}
+		filter.Digest = period
+	}
+
+	page, perPage := 1, defaultPerPage
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return filter, 0, 0, errors.New("page must be a positive integer")
+		}
+		page = n
+	}
+	if v := q.Get("per_page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPerPage {
+			return filter, 0, 0, errors.New("per_page must be between 1 and 500")
+		}
+		perPage = n
+	}
+
+	filter.Limit = perPage
+	filter.Offset = (page - 1) * perPage
+
+	return filter, page, perPage, nil
+}
+
+func chatIDParam(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	chatID, err := strconv.ParseInt(chi.URLParam(r, "chatID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "chat ID must be an integer")
+		return 0, false
+	}
+
+	return chatID, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
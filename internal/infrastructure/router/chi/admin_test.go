@@ -0,0 +1,248 @@
+package chi
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+	"time"
+)
+
+type stubUserRepo struct {
+	service.UserRepository
+	users map[int64]*entity.User
+}
+
+func (s *stubUserRepo) GetByChatID(ctx context.Context, chatID int64) (*entity.User, error) {
+	user, ok := s.users[chatID]
+	if !ok {
+		return nil, service.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *stubUserRepo) SaveOrUpdate(ctx context.Context, user *entity.User) error {
+	if s.users == nil {
+		s.users = make(map[int64]*entity.User)
+	}
+	s.users[user.ChatID] = user
+	return nil
+}
+
+func (s *stubUserRepo) SetActive(ctx context.Context, chatID int64, active bool) error {
+	user, ok := s.users[chatID]
+	if !ok {
+		return service.ErrUserNotFound
+	}
+	user.Active = active
+	return nil
+}
+
+func (s *stubUserRepo) SetBannedUntil(ctx context.Context, chatID int64, until *time.Time) error {
+	user, ok := s.users[chatID]
+	if !ok {
+		return service.ErrUserNotFound
+	}
+	user.BannedUntil = until
+	return nil
+}
+
+type stubAuthenticator map[string]*entity.APIKey
+
+func (s stubAuthenticator) Authenticate(ctx context.Context, plain string) (*entity.APIKey, error) {
+	if key, ok := s[plain]; ok {
+		return key, nil
+	}
+	return nil, service.ErrInvalidAPIKey
+}
+
+var testKeys = stubAuthenticator{
+	"admin":  {ID: 1, Scopes: []entity.Scope{entity.ScopeAdminUsers}},
+	"prices": {ID: 2, Scopes: []entity.Scope{entity.ScopeReadPrices}},
+	"system": {ID: 3, Scopes: []entity.Scope{entity.ScopeAdminSystem}},
+}
+
+func newAdminTestRouter() (*ChiRouter, *stubUserRepo) {
+	repo := &stubUserRepo{users: map[int64]*entity.User{
+		42: {ChatID: 42, Username: "alice", Active: true},
+	}}
+
+	router := NewChiRouter(slog.Default(), repo, nil, nil, nil, nil, nil, testKeys, nil, nil, nil, []string{"https://ops.example.com"}, nil).(*ChiRouter)
+	router.SetupMiddleware()
+	router.SetupRoutes()
+
+	return router, repo
+}
+
+func TestAdminRoutes_Auth(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"Missing key", "", "", http.StatusUnauthorized},
+		{"Unknown key", "Authorization", "Bearer nope", http.StatusUnauthorized},
+		{"Missing scope", "Authorization", "Bearer prices", http.StatusForbidden},
+		{"Bearer key", "Authorization", "Bearer admin", http.StatusOK},
+		{"Header key", "X-API-Key", "admin", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := newAdminTestRouter()
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/users/42", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+
+			router.router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("Status = %v, want %v", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	router, _ := newAdminTestRouter()
+
+	tests := []struct {
+		origin     string
+		wantStatus int
+		wantHeader string
+	}{
+		{"https://ops.example.com", http.StatusNoContent, "https://ops.example.com"},
+		{"https://evil.example.com", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodOptions, "/admin/users", nil)
+		req.Header.Set("Origin", tt.origin)
+		rec := httptest.NewRecorder()
+
+		router.router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %v, want %v", tt.origin, rec.Code, tt.wantStatus)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantHeader {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", tt.origin, got, tt.wantHeader)
+		}
+	}
+}
+
+func TestAdminRoutes_Users(t *testing.T) {
+	router, repo := newAdminTestRouter()
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer admin")
+		rec := httptest.NewRecorder()
+		router.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/admin/users/42")
+	var user entity.User
+	if err := json.NewDecoder(rec.Body).Decode(&user); err != nil {
+		t.Fatalf("decode user: %v", err)
+	}
+	if user.Username != "alice" {
+		t.Errorf("Username = %v, want alice", user.Username)
+	}
+
+	if rec := do(http.MethodGet, "/admin/users/7"); rec.Code != http.StatusNotFound {
+		t.Errorf("Unknown user status = %v, want %v", rec.Code, http.StatusNotFound)
+	}
+
+	if rec := do(http.MethodGet, "/admin/users/abc"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Bad chat ID status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+
+	if rec := do(http.MethodPost, "/admin/users/42/deactivate"); rec.Code != http.StatusOK {
+		t.Errorf("Deactivate status = %v, want %v", rec.Code, http.StatusOK)
+	}
+	if repo.users[42].Active {
+		t.Errorf("User should be deactivated")
+	}
+
+	until := time.Now().Add(time.Hour)
+	repo.users[42].BannedUntil = &until
+	if rec := do(http.MethodPost, "/admin/users/42/unban"); rec.Code != http.StatusOK {
+		t.Errorf("Unban status = %v, want %v", rec.Code, http.StatusOK)
+	}
+	if repo.users[42].IsBanned(time.Now()) {
+		t.Errorf("User should be unbanned")
+	}
+}
+
+func TestParseUserFilter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/admin/users?active=false&digest=daily&q=bob&page=3&per_page=20", nil)
+
+	filter, page, perPage, err := parseUserFilter(req)
+	if err != nil {
+		t.Fatalf("parseUserFilter failed: %v", err)
+	}
+
+	if filter.Active == nil || *filter.Active {
+		t.Errorf("Active = %v, want false", filter.Active)
+	}
+	if filter.Digest != entity.DigestDaily || filter.Username != "bob" {
+		t.Errorf("Filter = %+v", filter)
+	}
+	if page != 3 || perPage != 20 || filter.Limit != 20 || filter.Offset != 40 {
+		t.Errorf("Pagination = page %d, per_page %d, limit %d, offset %d", page, perPage, filter.Limit, filter.Offset)
+	}
+
+	for _, query := range []string{"active=maybe", "digest=hourly", "page=0", "per_page=1000"} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/users?"+query, nil)
+		if _, _, _, err := parseUserFilter(req); err == nil {
+			t.Errorf("Expected error for %q", query)
+		}
+	}
+}
+
+// countingLimiter allows every command and counts them.
+type countingLimiter struct {
+	calls int
+}
+
+func (l *countingLimiter) Allow(ctx context.Context, chatID int64, command string) entity.RateDecision {
+	l.calls++
+	return entity.RateDecision{Allowed: true}
+}
+
+func (l *countingLimiter) Reset(chatID int64) {}
+
+func TestAllowCommand_StoredBan(t *testing.T) {
+	until := time.Now().Add(time.Hour)
+	repo := &stubUserRepo{users: map[int64]*entity.User{
+		42: {ChatID: 42, Active: true, BannedUntil: &until},
+	}}
+	limiter := &countingLimiter{}
+	router := NewChiRouter(slog.Default(), repo, nil, nil, nil, nil, nil, testKeys, nil, nil, limiter, nil, nil).(*ChiRouter)
+
+	if router.allowCommand(context.Background(), 42, "/help") {
+		t.Fatal("Command from banned chat allowed")
+	}
+	if limiter.calls != 0 {
+		t.Errorf("Limiter called %d times for a banned chat, want 0", limiter.calls)
+	}
+
+	// Lifted elsewhere, e.g. from the command line.
+	repo.SetBannedUntil(context.Background(), 42, nil)
+	if !router.allowCommand(context.Background(), 42, "/help") {
+		t.Error("Command after unban refused")
+	}
+	if limiter.calls != 1 {
+		t.Errorf("Limiter called %d times, want 1", limiter.calls)
+	}
+}
@@ -0,0 +1,90 @@
+package chi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"tgBotFinal/internal/domain/service"
+
+	"tgBotFinal/internal/entity"
+)
+
+type apiKeyContextKey struct{}
+
+// requireScope authenticates the request by its API key and rejects it unless
+// the key carries scope. Keys are accepted as "Authorization: Bearer <key>"
+// or "X-API-Key: <key>".
+func (c *ChiRouter) requireScope(scope entity.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			plain := apiKeyFromRequest(r)
+			if plain == "" || c.auth == nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				writeError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			key, err := c.auth.Authenticate(r.Context(), plain)
+			if errors.Is(err, service.ErrInvalidAPIKey) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+				writeError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+			if err != nil {
+				c.logger.ErrorContext(r.Context(), "failed to authenticate api key", "error", err)
+				writeError(w, http.StatusInternalServerError, "Internal server error")
+				return
+			}
+
+			if !key.HasScope(scope) {
+				c.logger.WarnContext(r.Context(), "api key lacks scope", "key_id", key.ID, "scope", scope, "path", r.URL.Path)
+				writeError(w, http.StatusForbidden, "Forbidden")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), apiKeyContextKey{}, key)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func apiKeyFromRequest(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// corsMiddleware allows cross-origin requests only from the configured
+// origins. An entry of "*" allows any origin.
+func corsMiddleware(origins []string) func(http.Handler) http.Handler {
+	allowAny := slices.Contains(origins, "*")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			allowed := origin != "" && (allowAny || slices.Contains(origins, origin))
+
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-API-Key")
+				w.Header().Add("Vary", "Origin")
+			}
+
+			if r.Method == http.MethodOptions {
+				if !allowed {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
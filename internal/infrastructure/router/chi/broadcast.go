@@ -0,0 +1,192 @@
+package chi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"tgBotFinal/internal/domain/service"
+	"unicode"
+
+	"tgBotFinal/internal/entity"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type broadcastRequest struct {
+	Text string `json:"text"`
+}
+
+func (c *ChiRouter) isAdminChat(chatID int64) bool {
+	return slices.Contains(c.adminChatIDs, chatID)
+}
+
+// commandArgument returns everything after the command word, preserving the
+// original line breaks.
+func commandArgument(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+		return strings.TrimSpace(text[i:])
+	}
+	return ""
+}
+
+func (c *ChiRouter) handleBroadcastCommand(ctx context.Context, chatID int64, text string) {
+	c.logger.DebugContext(ctx, "Handling broadcast command")
+
+	body := commandArgument(text)
+	if body == "" {
+		c.sendInfo(ctx, chatID, "Использование: /broadcast <текст сообщения>")
+		return
+	}
+
+	draft, err := c.broadcaster.Prepare(ctx, body, chatID)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to prepare broadcast", "error", err)
+		c.sendInfo(ctx, chatID, "Не удалось подготовить рассылку: "+err.Error())
+		return
+	}
+
+	c.sendInfo(ctx, chatID, fmt.Sprintf(`Предпросмотр рассылки %s
+Получателей: %d
+
+%s
+
+/broadcast_confirm - отправить
+/broadcast_cancel - отменить`, draft.ID, draft.Recipients, draft.Text))
+}
+
+func (c *ChiRouter) handleBroadcastConfirmCommand(ctx context.Context, chatID int64) {
+	c.logger.DebugContext(ctx, "Handling broadcast confirm command")
+
+	draft, err := c.broadcaster.Latest(chatID)
+	if err != nil || draft.Status != entity.BroadcastDraft {
+		c.sendInfo(ctx, chatID, "Нет рассылки, ожидающей подтверждения. Используйте /broadcast <текст>")
+		return
+	}
+
+	_, err = c.broadcaster.Confirm(ctx, draft.ID, func(b entity.Broadcast) {
+		c.sendInfo(context.WithoutCancel(ctx), chatID, formatBroadcastProgress(b))
+	})
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to confirm broadcast", "id", draft.ID, "error", err)
+		c.sendInfo(ctx, chatID, "Не удалось запустить рассылку: "+err.Error())
+		return
+	}
+
+	c.sendInfo(ctx, chatID, fmt.Sprintf("Рассылка %s запущена. /broadcast_cancel - остановить", draft.ID))
+}
+
+func (c *ChiRouter) handleBroadcastCancelCommand(ctx context.Context, chatID int64) {
+	c.logger.DebugContext(ctx, "Handling broadcast cancel command")
+
+	latest, err := c.broadcaster.Latest(chatID)
+	if err != nil {
+		c.sendInfo(ctx, chatID, "Нет активной рассылки")
+		return
+	}
+
+	// A running broadcast reports its final state through the progress
+	// callback, so only drafts need an explicit reply.
+	cancelled, err := c.broadcaster.Cancel(latest.ID)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to cancel broadcast", "id", latest.ID, "error", err)
+		c.sendInfo(ctx, chatID, "Не удалось отменить рассылку: "+err.Error())
+		return
+	}
+
+	if latest.Status == entity.BroadcastDraft {
+		c.sendInfo(ctx, chatID, fmt.Sprintf("Рассылка %s отменена", cancelled.ID))
+	}
+}
+
+func formatBroadcastProgress(b entity.Broadcast) string {
+	var status string
+	switch b.Status {
+	case entity.BroadcastDone:
+		status = "завершена"
+	case entity.BroadcastCancelled:
+		status = "остановлена"
+	case entity.BroadcastFailed:
+		status = "не удалась"
+	default:
+		status = "выполняется"
+	}
+
+	return fmt.Sprintf("Рассылка %s %s: %d/%d\nОтправлено: %d\nОшибок: %d\nЗаблокировали бота: %d",
+		b.ID, status, b.Processed(), b.Recipients, b.Sent, b.Failed, b.Blocked)
+}
+
+func (c *ChiRouter) setupBroadcastRoutes(r chi.Router) {
+	r.Use(c.requireScope(entity.ScopeAdminBroadcast))
+
+	r.Post("/broadcast", c.prepareBroadcastHandler)
+	r.Get("/broadcast/{id}", c.getBroadcastHandler)
+	r.Post("/broadcast/{id}/confirm", c.confirmBroadcastHandler)
+	r.Delete("/broadcast/{id}", c.cancelBroadcastHandler)
+}
+
+// prepareBroadcastHandler creates a draft and returns the dry-run preview.
+// Nothing is sent until the draft is confirmed.
+func (c *ChiRouter) prepareBroadcastHandler(w http.ResponseWriter, r *http.Request) {
+	var req broadcastRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	draft, err := c.broadcaster.Prepare(r.Context(), req.Text, 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, draft)
+}
+
+func (c *ChiRouter) getBroadcastHandler(w http.ResponseWriter, r *http.Request) {
+	b, err := c.broadcaster.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		c.writeBroadcastError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, b)
+}
+
+func (c *ChiRouter) confirmBroadcastHandler(w http.ResponseWriter, r *http.Request) {
+	b, err := c.broadcaster.Confirm(r.Context(), chi.URLParam(r, "id"), nil)
+	if err != nil {
+		c.writeBroadcastError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, b)
+}
+
+func (c *ChiRouter) cancelBroadcastHandler(w http.ResponseWriter, r *http.Request) {
+	b, err := c.broadcaster.Cancel(chi.URLParam(r, "id"))
+	if err != nil {
+		c.writeBroadcastError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, b)
+}
+
+func (c *ChiRouter) writeBroadcastError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrBroadcastNotFound):
+		writeError(w, http.StatusNotFound, "Broadcast not found")
+	case errors.Is(err, service.ErrBroadcastState):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrShuttingDown):
+		writeError(w, http.StatusServiceUnavailable, "Service is shutting down")
+	default:
+		c.logger.Error("broadcast request failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
@@ -0,0 +1,249 @@
+package chi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type priceSource string
+
+const (
+	sourceCache priceSource = "cache"
+	sourceDB    priceSource = "db"
+	sourceLive  priceSource = "live"
+)
+
+type currencyResponse struct {
+	Symbol    entity.CurrencyName `json:"symbol"`
+	Price     string              `json:"price"`
+	UpdatedAt *time.Time          `json:"updated_at,omitempty"`
+	// Stale marks a cached price served past its TTL because it could not
+	// be refreshed yet.
+	Stale bool `json:"stale,omitempty"`
+	entity.Ticker
+}
+
+type currencyListResponse struct {
+	Source     priceSource         `json:"source"`
+	Currencies []*currencyResponse `json:"currencies"`
+}
+
+// priceTimeLayouts lists the formats entity.Price.Updated arrives in: the
+// RFC 3339 form the exchange clients and database/sql produce, and the
+// zone-less local time older releases left in the shared cache.
+var priceTimeLayouts = []string{time.RFC3339Nano, time.DateTime}
+
+func (c *ChiRouter) setupCurrencyRoutes(r chi.Router) {
+	r.Use(c.requireScope(entity.ScopeReadPrices))
+
+	r.Get("/currencies", c.getCurrenciesHandler)
+	r.Get("/currencies/{symbol}", c.getCurrencyHandler)
+
+	// Misspelled path kept for existing clients.
+	r.With(deprecated("/currencies")).Get("/currensies", c.getCurrenciesHandler)
+}
+
+func (c *ChiRouter) getCurrenciesHandler(w http.ResponseWriter, r *http.Request) {
+	source, ok := parsePriceSource(w, r)
+	if !ok {
+		return
+	}
+
+	prices, err := c.pricesFrom(r.Context(), source)
+	if err != nil {
+		c.logger.ErrorContext(r.Context(), "failed to get currencies", "source", source, "error", err)
+		writeError(w, http.StatusBadGateway, "Failed to get prices")
+		return
+	}
+
+	resp := currencyListResponse{Source: source, Currencies: make([]*currencyResponse, 0, len(prices))}
+	for _, price := range prices {
+		resp.Currencies = append(resp.Currencies, toCurrencyResponse(price))
+	}
+	sort.Slice(resp.Currencies, func(i, j int) bool {
+		return resp.Currencies[i].Symbol < resp.Currencies[j].Symbol
+	})
+
+	writeConditionalJSON(w, r, resp, lastModified(resp.Currencies...))
+}
+
+func (c *ChiRouter) getCurrencyHandler(w http.ResponseWriter, r *http.Request) {
+	source, ok := parsePriceSource(w, r)
+	if !ok {
+		return
+	}
+
+	symbol, ok := trackedSymbol(chi.URLParam(r, "symbol"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Unknown currency")
+		return
+	}
+
+	var (
+		price *entity.Price
+		err   error
+	)
+
+	switch source {
+	case sourceDB:
+		price, err = c.currencyRepo.GetBySymbol(r.Context(), symbol)
+	case sourceLive:
+		price, err = c.liveClient.GetPriceBySymbol(r.Context(), symbol)
+	default:
+		var prices []*entity.Price
+		prices, err = c.pricesFrom(r.Context(), sourceCache)
+		for _, p := range prices {
+			if p.Symbol == symbol {
+				price = p
+			}
+		}
+	}
+
+	if errors.Is(err, service.ErrCurrencyNotFound) {
+		writeError(w, http.StatusNotFound, "No price for currency yet")
+		return
+	}
+	if err != nil {
+		c.logger.ErrorContext(r.Context(), "failed to get currency", "symbol", symbol, "source", source, "error", err)
+		writeError(w, http.StatusBadGateway, "Failed to get price")
+		return
+	}
+
+	if price == nil {
+		writeError(w, http.StatusNotFound, "No price for currency yet")
+		return
+	}
+
+	resp := toCurrencyResponse(price)
+	writeConditionalJSON(w, r, resp, lastModified(resp))
+}
+
+func (c *ChiRouter) pricesFrom(ctx context.Context, source priceSource) ([]*entity.Price, error) {
+	switch source {
+	case sourceDB:
+		return c.currencyRepo.GetAll(ctx)
+	case sourceLive:
+		return c.liveClient.GetTickers(ctx)
+	default:
+		return c.cryptClient.GetTickers(ctx)
+	}
+}
+
+func parsePriceSource(w http.ResponseWriter, r *http.Request) (priceSource, bool) {
+	switch source := priceSource(r.URL.Query().Get("source")); source {
+	case "":
+		return sourceCache, true
+	case sourceCache, sourceDB, sourceLive:
+		return source, true
+	default:
+		writeError(w, http.StatusBadRequest, "source must be one of cache, db, live")
+		return "", false
+	}
+}
+
+func trackedSymbol(s string) (entity.CurrencyName, bool) {
+	s = strings.ToUpper(s)
+	for _, symbol := range entity.TokenList {
+		if string(symbol) == s {
+			return symbol, true
+		}
+	}
+	return "", false
+}
+
+func toCurrencyResponse(price *entity.Price) *currencyResponse {
+	resp := &currencyResponse{Symbol: price.Symbol, Price: price.Price, Stale: price.Stale, Ticker: price.Ticker}
+
+	for _, layout := range priceTimeLayouts {
+		if t, err := time.ParseInLocation(layout, price.Updated, time.Local); err == nil {
+			t = t.UTC()
+			resp.UpdatedAt = &t
+			break
+		}
+	}
+
+	return resp
+}
+
+func lastModified(currencies ...*currencyResponse) time.Time {
+	var latest time.Time
+	for _, c := range currencies {
+		if c.UpdatedAt != nil && c.UpdatedAt.After(latest) {
+			latest = *c.UpdatedAt
+		}
+	}
+	return latest
+}
+
+// writeConditionalJSON writes v with ETag and Last-Modified validators and
+// answers 304 Not Modified when the client's copy is still current.
+func writeConditionalJSON(w http.ResponseWriter, r *http.Request, v any, modified time.Time) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !modified.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil {
+			return !modified.Truncate(time.Second).After(t)
+		}
+	}
+
+	return false
+}
+
+// deprecated marks responses from a legacy route and points clients to its
+// successor.
+func deprecated(successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
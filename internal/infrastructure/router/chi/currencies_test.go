@@ -0,0 +1,222 @@
+package chi
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+	"time"
+)
+
+type stubCurrencyRepo struct {
+	service.CurrencyRepository
+	prices []*entity.Price
+}
+
+func (s *stubCurrencyRepo) GetAll(ctx context.Context) ([]*entity.Price, error) {
+	return s.prices, nil
+}
+
+func (s *stubCurrencyRepo) GetBySymbol(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error) {
+	for _, p := range s.prices {
+		if p.Symbol == symbol {
+			return p, nil
+		}
+	}
+	return nil, service.ErrCurrencyNotFound
+}
+
+type stubCryptoClient struct {
+	prices *entity.PriceResponse
+}
+
+func (s *stubCryptoClient) GetPriceBySymbol(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error) {
+	for _, p := range s.prices.List() {
+		if p.Symbol == symbol {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *stubCryptoClient) GetAllPrices(ctx context.Context) (*entity.PriceResponse, error) {
+	return s.prices, nil
+}
+
+func (s *stubCryptoClient) GetTickers(ctx context.Context) ([]*entity.Price, error) {
+	return s.prices.List(), nil
+}
+
+func newCurrencyTestRouter() *ChiRouter {
+	repo := &stubCurrencyRepo{prices: []*entity.Price{
+		{Symbol: entity.ETH, Price: "3000", Updated: "2025-11-10T09:00:00Z"},
+		{Symbol: entity.BTC, Price: "50000", Updated: "2025-11-10T09:05:00Z"},
+	}}
+	cached := &stubCryptoClient{prices: &entity.PriceResponse{
+		BTC: &entity.Price{Symbol: entity.BTC, Price: "50100", Updated: "2025-11-10T09:06:00Z"},
+	}}
+	live := &stubCryptoClient{prices: &entity.PriceResponse{
+		BTC: &entity.Price{Symbol: entity.BTC, Price: "50200", Updated: "2025-11-10 09:07:00",
+			Ticker: entity.Ticker{Change24h: "0.0125", Bid: "50199.5", Ask: "50200.5"}},
+	}}
+
+	router := NewChiRouter(slog.Default(), nil, repo, nil, cached, live, nil, testKeys, nil, nil, nil, nil, nil).(*ChiRouter)
+	router.SetupRoutes()
+	return router
+}
+
+func getWithKey(router *ChiRouter, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("X-API-Key", "prices")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	router.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCurrencies_List(t *testing.T) {
+	router := newCurrencyTestRouter()
+
+	rec := getWithKey(router, "/currencies?source=db", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	var resp currencyListResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if resp.Source != sourceDB || len(resp.Currencies) != 2 || resp.Currencies[0].Symbol != entity.BTC {
+		t.Errorf("Response = %+v", resp)
+	}
+
+	if got := rec.Header().Get("Last-Modified"); got != "Mon, 10 Nov 2025 09:05:00 GMT" {
+		t.Errorf("Last-Modified = %q", got)
+	}
+
+	if rec := getWithKey(router, "/currencies?source=bogus", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("Bad source status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestCurrencies_Sources(t *testing.T) {
+	router := newCurrencyTestRouter()
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"db", "50000"},
+		{"cache", "50100"},
+		{"", "50100"},
+		{"live", "50200"},
+	}
+
+	for _, tt := range tests {
+		rec := getWithKey(router, "/currencies/btc?source="+tt.source, nil)
+
+		var resp currencyResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%q: decode: %v", tt.source, err)
+		}
+		if resp.Price != tt.want {
+			t.Errorf("source %q: price = %v, want %v", tt.source, resp.Price, tt.want)
+		}
+	}
+
+	rec := getWithKey(router, "/currencies?source=live", nil)
+	var list map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	btc := list["currencies"].([]any)[0].(map[string]any)
+	if btc["change_24h"] != "0.0125" || btc["bid"] != "50199.5" || btc["ask"] != "50200.5" {
+		t.Errorf("Live BTC = %v, want its 24h change, bid and ask", btc)
+	}
+	if _, ok := btc["high_24h"]; ok {
+		t.Errorf("Live BTC = %v, want unknown fields left out", btc)
+	}
+
+	if rec := getWithKey(router, "/currencies/DOGE", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Unknown symbol status = %v, want %v", rec.Code, http.StatusNotFound)
+	}
+
+	if rec := getWithKey(router, "/currencies/ETH?source=live", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Missing price status = %v, want %v", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestCurrencies_UpdatedAtOnNonUTCHost(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("MSK", 3*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	router := newCurrencyTestRouter()
+
+	tests := []struct {
+		source string
+		want   time.Time
+	}{
+		{"db", time.Date(2025, 11, 10, 9, 5, 0, 0, time.UTC)},
+		{"cache", time.Date(2025, 11, 10, 9, 6, 0, 0, time.UTC)},
+		// A zone-less time is local to the host that wrote it.
+		{"live", time.Date(2025, 11, 10, 6, 7, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		rec := getWithKey(router, "/currencies/BTC?source="+tt.source, nil)
+
+		var resp currencyResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%q: decode: %v", tt.source, err)
+		}
+		if resp.UpdatedAt == nil || !resp.UpdatedAt.Equal(tt.want) {
+			t.Errorf("source %q: updated_at = %v, want %v", tt.source, resp.UpdatedAt, tt.want)
+		}
+		if got, want := rec.Header().Get("Last-Modified"), tt.want.Format(http.TimeFormat); got != want {
+			t.Errorf("source %q: Last-Modified = %q, want %q", tt.source, got, want)
+		}
+	}
+}
+
+func TestCurrencies_ConditionalRequests(t *testing.T) {
+	router := newCurrencyTestRouter()
+
+	first := getWithKey(router, "/currencies?source=db", nil)
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("ETag header missing")
+	}
+
+	if rec := getWithKey(router, "/currencies?source=db", map[string]string{"If-None-Match": etag}); rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match status = %v, want %v", rec.Code, http.StatusNotModified)
+	}
+
+	if rec := getWithKey(router, "/currencies?source=db", map[string]string{"If-None-Match": `"other"`}); rec.Code != http.StatusOK {
+		t.Errorf("Stale ETag status = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	lastModified := first.Header().Get("Last-Modified")
+	if rec := getWithKey(router, "/currencies?source=db", map[string]string{"If-Modified-Since": lastModified}); rec.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since status = %v, want %v", rec.Code, http.StatusNotModified)
+	}
+}
+
+func TestCurrencies_DeprecatedAlias(t *testing.T) {
+	router := newCurrencyTestRouter()
+
+	rec := getWithKey(router, "/currensies?source=db", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status = %v, want %v", rec.Code, http.StatusOK)
+	}
+	if rec.Header().Get("Deprecation") != "true" {
+		t.Errorf("Deprecation header missing")
+	}
+}
@@ -0,0 +1,72 @@
+package chi
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/logger"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+type logLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// requestLogger attaches the request ID to every record logged with the
+// request context and logs each request once it has been served.
+func (c *ChiRouter) requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := logger.WithAttrs(r.Context(), slog.String("request_id", middleware.GetReqID(r.Context())))
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		c.logger.InfoContext(ctx, "HTTP request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", ww.Status(),
+			"bytes", ww.BytesWritten(),
+			"duration", time.Since(start),
+			"remote_addr", r.RemoteAddr)
+	})
+}
+
+func (c *ChiRouter) setupSystemRoutes(r chi.Router) {
+	r.Use(c.requireScope(entity.ScopeAdminSystem))
+
+	r.Get("/log-level", c.getLogLevelHandler)
+	r.Put("/log-level", c.setLogLevelHandler)
+}
+
+func (c *ChiRouter) getLogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, logLevelResponse{Level: logger.Level().String()})
+}
+
+// setLogLevelHandler changes the log level of the running process. The
+// change is not persisted and is lost on restart.
+func (c *ChiRouter) setLogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	var req logLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	previous := logger.Level()
+	if err := logger.SetLevel(req.Level); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.logger.WarnContext(r.Context(), "Log level changed", "from", previous.String(), "to", logger.Level().String())
+	writeJSON(w, http.StatusOK, logLevelResponse{Level: logger.Level().String()})
+}
@@ -0,0 +1,53 @@
+package chi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tgBotFinal/internal/logger"
+)
+
+func TestLogLevelEndpoint(t *testing.T) {
+	router, _ := newAdminTestRouter()
+
+	if err := logger.SetLevel("info"); err != nil {
+		t.Fatalf("SetLevel failed: %v", err)
+	}
+	defer logger.SetLevel("info")
+
+	do := func(method, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/admin/log-level", strings.NewReader(body))
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		router.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPut, "admin", `{"level":"debug"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("Status without admin:system = %v, want %v", rec.Code, http.StatusForbidden)
+	}
+
+	rec := do(http.MethodPut, "system", `{"level":"DEBUG"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	var resp logLevelResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Level != "DEBUG" || logger.Level().String() != "DEBUG" {
+		t.Errorf("Level = %v (current %v), want DEBUG", resp.Level, logger.Level())
+	}
+
+	if rec := do(http.MethodPut, "system", `{"level":"loud"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Invalid level status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+
+	if rec := do(http.MethodGet, "system", ""); !strings.Contains(rec.Body.String(), `"level":"DEBUG"`) {
+		t.Errorf("GET body = %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,46 @@
+package chi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	router, _ := newAdminTestRouter()
+
+	server := httptest.NewServer(router.router)
+	defer server.Close()
+
+	for _, path := range []string{"/health/live", "/admin/users/42", "/no/such/path"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+	}
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	text := string(body)
+
+	for _, want := range []string{
+		`tgbot_http_requests_total{method="GET",route="/health/live",status="200"}`,
+		`tgbot_http_requests_total{method="GET",route="/admin/users/{chatID}",status="401"}`,
+		`tgbot_http_requests_total{method="GET",route="unmatched",status="404"}`,
+		`tgbot_http_request_duration_seconds_bucket{method="GET",route="/health/live"`,
+		`tgbot_price_cache_age_seconds`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics output missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package chi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+)
+
+// allowCommand applies bans and rate limits to a command from chatID and
+// reports whether to handle it. Refused commands get at most one reply per
+// window; admin chats are never limited.
+//
+// The ban is read from the stored user first, so a ban lifted by an admin
+// on any replica or from the command line applies at once, and commands of
+// a banned chat are not counted towards its limits.
+func (c *ChiRouter) allowCommand(ctx context.Context, chatID int64, command string) bool {
+	if c.isAdminChat(chatID) {
+		return true
+	}
+
+	user, err := c.userRepo.GetByChatID(ctx, chatID)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+	case err != nil:
+		c.logger.WarnContext(ctx, "failed to check user ban", "error", err)
+	case user.IsBanned(time.Now()):
+		c.logger.DebugContext(ctx, "Ignoring command from banned chat", "banned_until", user.BannedUntil)
+		return false
+	}
+
+	if c.limiter != nil {
+		decision := c.limiter.Allow(ctx, chatID, command)
+		switch {
+		case decision.BannedUntil != nil:
+			c.sendInfo(ctx, chatID, fmt.Sprintf("Слишком много запросов. Бот не будет отвечать вам до %s UTC.",
+				decision.BannedUntil.UTC().Format("02.01.2006 15:04")))
+			return false
+		case !decision.Allowed:
+			c.logger.DebugContext(ctx, "Command rate limited", "warn", decision.Warn)
+			if decision.Warn {
+				c.sendInfo(ctx, chatID, "Слишком много запросов, пожалуйста, подождите немного.")
+			}
+			return false
+		}
+	}
+
+	return true
+}
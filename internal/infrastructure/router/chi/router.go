@@ -7,39 +7,80 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
+	"sync"
 	"tgBotFinal/internal/domain/service"
 	"time"
 
 	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/infrastructure/metrics"
+	"tgBotFinal/internal/infrastructure/tracing"
+	"tgBotFinal/internal/infrastructure/updates"
+	"tgBotFinal/internal/logger"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// updateTimeout bounds the work done for a single Telegram update.
+const updateTimeout = 30 * time.Second
+
 type ChiRouter struct {
 	router        *chi.Mux
+	serverMu      sync.Mutex
 	server        *http.Server
 	logger        *slog.Logger
 	userRepo      service.UserRepository
+	currencyRepo  service.CurrencyRepository
 	notification  service.Notification
 	cryptClient   service.CryptoClient
+	liveClient    service.CryptoClient
 	healthChecker service.HealthChecker
+	auth          service.Authenticator
+	broadcaster   service.Broadcaster
+	updates       service.UpdateQueue
+	limiter       service.RateLimiter
+	corsOrigins   []string
+	adminChatIDs  []int64
 }
 
 func NewChiRouter(
 	logger *slog.Logger,
 	userRepo service.UserRepository,
+	currencyRepo service.CurrencyRepository,
 	notification service.Notification,
 	cryptClient service.CryptoClient,
+	liveClient service.CryptoClient,
 	healthChecker service.HealthChecker,
+	auth service.Authenticator,
+	broadcaster service.Broadcaster,
+	queue service.UpdateQueue,
+	limiter service.RateLimiter,
+	corsOrigins []string,
+	adminChatIDs []int64,
 ) service.Router {
+	if queue == nil {
+		queue = updates.NewProcessor(4, 64, updates.Reject, 0, logger)
+	}
+
 	return &ChiRouter{
 		router:        chi.NewRouter(),
 		logger:        logger.With(slog.String("component", "chi.Router")),
 		userRepo:      userRepo,
+		currencyRepo:  currencyRepo,
 		notification:  notification,
 		cryptClient:   cryptClient,
+		liveClient:    liveClient,
 		healthChecker: healthChecker,
+		auth:          auth,
+		broadcaster:   broadcaster,
+		updates:       queue,
+		limiter:       limiter,
+		corsOrigins:   corsOrigins,
+		adminChatIDs:  adminChatIDs,
 	}
 }
 
@@ -48,24 +89,12 @@ func (c *ChiRouter) SetupMiddleware() {
 
 	c.router.Use(middleware.RequestID)
 	c.router.Use(middleware.RealIP)
-	c.router.Use(middleware.Logger)
+	c.router.Use(c.requestLogger)
 	c.router.Use(middleware.Recoverer)
+	c.router.Use(instrument)
 	c.router.Use(middleware.Timeout(60 * time.Second))
 
-	c.router.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-
-			next.ServeHTTP(w, r)
-		})
-	})
+	c.router.Use(corsMiddleware(c.corsOrigins))
 }
 
 func (c *ChiRouter) SetupRoutes() {
@@ -77,10 +106,18 @@ func (c *ChiRouter) SetupRoutes() {
 	c.router.Get("/health/ready", c.readinessHandler)
 	c.router.Get("/health/detalied", c.detailedHealthHandler)
 
-	// API routes
+	// Prometheus metrics
+	c.router.Method(http.MethodGet, "/metrics", metrics.Handler())
+
+	// Telegram webhook
 	c.router.Post("/webhook/telegram", c.telegramWebhookHandler)
-	c.router.Get("/users/active", c.getActiveUsersHandler)
-	c.router.Get("/currensies", c.getCurrenciesHandler)
+
+	// API routes
+	c.router.With(c.requireScope(entity.ScopeAdminUsers)).Get("/users/active", c.getActiveUsersHandler)
+	c.router.Group(c.setupCurrencyRoutes)
+
+	// Admin routes
+	c.router.Route("/admin", c.setupAdminRoutes)
 
 	c.router.NotFound(c.notFoundHandler)
 	c.router.MethodNotAllowed(c.methodNotAllowedHandler)
@@ -89,15 +126,18 @@ func (c *ChiRouter) SetupRoutes() {
 func (c *ChiRouter) Start(port string) error {
 	c.logger.Debug("Starting HTTP server", "port", port)
 
-	c.server = &http.Server{
+	server := &http.Server{
 		Addr:         ":" + port,
 		Handler:      c.router,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	c.serverMu.Lock()
+	c.server = server
+	c.serverMu.Unlock()
 
-	if err := c.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("server faied to start: %w", err)
 	}
 
@@ -105,9 +145,13 @@ func (c *ChiRouter) Start(port string) error {
 }
 
 func (c *ChiRouter) telegramWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.Start(r.Context(), "telegram.webhook", trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		c.logger.Error("failed to read request body", "error", err)
+		c.logger.ErrorContext(ctx, "failed to read request body", "error", err)
+		span.SetStatus(codes.Error, "read body")
 		http.Error(w, `{"error": "Bad request"}`, http.StatusBadRequest)
 		return
 	}
@@ -116,14 +160,36 @@ func (c *ChiRouter) telegramWebhookHandler(w http.ResponseWriter, r *http.Reques
 	var update entity.TelegramUpdate
 
 	if err := json.Unmarshal(body, &update); err != nil {
-		c.logger.Error("failed to parse telegram update", "error", err)
+		c.logger.ErrorContext(ctx, "failed to parse telegram update", "error", err)
+		span.SetStatus(codes.Error, "invalid JSON")
 		http.Error(w, `{"error": "Invalid JSON"}`, http.StatusBadRequest)
 		return
 	}
 
-	c.logger.Debug("received telegram update", "update", update.UpdateID)
-
-	go c.handleTelegramUpdate(context.Background(), update)
+	span.SetAttributes(attribute.Int("telegram.update_id", update.UpdateID))
+	c.logger.DebugContext(ctx, "received telegram update", "update", update.UpdateID)
+
+	// Telegram only waits for the acknowledgement, so the update is handled
+	// after the response, after the earlier updates of the same chat. The
+	// context keeps the request's span but not its cancellation; it is
+	// cancelled if the update outlives the shutdown deadline. A refused
+	// update gets a 503 so Telegram delivers it again later.
+	updateCtx := context.WithoutCancel(ctx)
+	err = c.updates.Submit(updateKey(update), func(taskCtx context.Context) {
+		ctx, cancel := context.WithTimeout(updateCtx, updateTimeout)
+		defer cancel()
+		stop := context.AfterFunc(taskCtx, cancel)
+		defer stop()
+
+		c.handleTelegramUpdate(ctx, update)
+	})
+	if err != nil {
+		c.logger.WarnContext(ctx, "Refusing telegram update", "update", update.UpdateID, "error", err)
+		span.SetStatus(codes.Error, "update refused")
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, `{"error": "Service unavailable"}`, http.StatusServiceUnavailable)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -131,24 +197,40 @@ func (c *ChiRouter) telegramWebhookHandler(w http.ResponseWriter, r *http.Reques
 }
 
 func (c *ChiRouter) handleTelegramUpdate(ctx context.Context, update entity.TelegramUpdate) {
+	ctx = logger.WithAttrs(ctx, slog.Int("update_id", update.UpdateID))
+	ctx, span := tracing.Start(ctx, "telegram.update",
+		trace.WithAttributes(attribute.Int("telegram.update_id", update.UpdateID)))
+	defer span.End()
 
 	defer func() {
 		if r := recover(); r != nil {
-			c.logger.Error("panic in telegram update handler", "recover", r)
+			c.logger.ErrorContext(ctx, "panic in telegram update handler", "recover", r)
+			span.SetStatus(codes.Error, "panic")
 		}
 	}()
 
 	if update.Message == nil {
-		c.logger.Debug("Update doesn`t contain message")
+		c.logger.DebugContext(ctx, "Update doesn`t contain message")
 		return
 	}
 
 	message := update.Message
 	chatID := message.ChatID
 	text := message.Text
+	command, args := parseCommand(text)
+
+	ctx = logger.WithAttrs(ctx, slog.Int64("chat_id", chatID.ID), slog.String("command", command))
+	span.SetAttributes(
+		attribute.Int64("telegram.chat_id", chatID.ID),
+		attribute.String("telegram.command", command),
+	)
+
+	if !c.allowCommand(ctx, chatID.ID, command) {
+		span.SetAttributes(attribute.Bool("telegram.rate_limited", true))
+		return
+	}
 
-	c.logger.Info("Processing telegram message",
-		"chat_id", chatID,
+	c.logger.InfoContext(ctx, "Processing telegram message",
 		"username", message.From.Username,
 		"text", text)
 
@@ -159,38 +241,78 @@ func (c *ChiRouter) handleTelegramUpdate(ctx context.Context, update entity.Tele
 	}
 
 	if err := c.userRepo.SaveOrUpdate(ctx, user); err != nil {
-		c.logger.Error("failed to save user", "chatID", chatID, "error", err)
+		c.logger.ErrorContext(ctx, "failed to save user", "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "save user")
 		return
 	}
 
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				c.logger.Error("panic in command handler", "chat_id", chatID, "recover", r)
-			}
-		}()
-
-		switch text {
-		case "/start":
-			c.handleStartCommand(ctx, chatID.ID, user)
-		case "/stop":
-			c.handleStopCommand(ctx, chatID.ID, user)
-		case "/price", "/prices":
-			c.handleHelpCommand(ctx, chatID.ID)
-		case "/help":
-			c.handleHelpCommand(ctx, chatID.ID)
-		default:
-			c.handleUnknowCommand(ctx, chatID.ID)
-		}
-	}()
+	switch command {
+	case "/start":
+		c.handleStartCommand(ctx, chatID.ID, user)
+	case "/stop":
+		c.handleStopCommand(ctx, chatID.ID, user)
+	case "/price", "/prices":
+		c.handlePriceCommand(ctx, chatID.ID)
+	case "/help":
+		c.handleHelpCommand(ctx, chatID.ID)
+	case "/digest":
+		c.handleDigestCommand(ctx, chatID.ID, args)
+	case "/timezone":
+		c.handleTimezoneCommand(ctx, chatID.ID, args)
+	case "/broadcast", "/broadcast_confirm", "/broadcast_cancel":
+		c.handleAdminCommand(ctx, chatID.ID, command, text)
+	default:
+		c.handleUnknowCommand(ctx, chatID.ID)
+	}
+}
+
+// updateKey orders updates per chat. Updates without a message have no
+// chat and are spread by their ID.
+func updateKey(update entity.TelegramUpdate) int64 {
+	if update.Message != nil {
+		return update.Message.ChatID.ID
+	}
+	return int64(update.UpdateID)
+}
+
+// handleAdminCommand dispatches commands reserved for admin chats. Other
+// chats get the regular unknown command reply.
+func (c *ChiRouter) handleAdminCommand(ctx context.Context, chatID int64, command, text string) {
+	if !c.isAdminChat(chatID) {
+		c.logger.WarnContext(ctx, "admin command from non-admin chat", "command", command)
+		c.handleUnknowCommand(ctx, chatID)
+		return
+	}
+
+	switch command {
+	case "/broadcast":
+		c.handleBroadcastCommand(ctx, chatID, text)
+	case "/broadcast_confirm":
+		c.handleBroadcastConfirmCommand(ctx, chatID)
+	case "/broadcast_cancel":
+		c.handleBroadcastCancelCommand(ctx, chatID)
+	}
+}
+
+// parseCommand splits a message into a command and its arguments, dropping
+// the optional @botname suffix Telegram adds in group chats.
+func parseCommand(text string) (string, []string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	command, _, _ := strings.Cut(fields[0], "@")
+	return strings.ToLower(command), fields[1:]
 }
 
 func (c *ChiRouter) handleStartCommand(ctx context.Context, chatID int64, user *entity.User) {
-	c.logger.Debug("Handling start command", "chatId", chatID)
+	c.logger.DebugContext(ctx, "Handling start command")
 
 	user.Active = true
 	if err := c.userRepo.SaveOrUpdate(ctx, user); err != nil {
-		c.logger.Error("failed to activate user", "chatID", chatID, "error", err)
+		c.logger.ErrorContext(ctx, "failed to activate user", "error", err)
 		c.notification.SendInfoMessage(ctx, chatID, "Failed to activate user")
 		return
 	}
@@ -201,6 +323,8 @@ func (c *ChiRouter) handleStartCommand(ctx context.Context, chatID int64, user *
 
 *Доступные команды:*
 /price - Текущие цены
+/digest off|daily|weekly - Дайджест рынка
+/timezone - Часовой пояс для дайджеста
 /stop - Отписаться от рассылки
 /help - Помощь
 
@@ -209,44 +333,44 @@ func (c *ChiRouter) handleStartCommand(ctx context.Context, chatID int64, user *
 • ETH (Ethereum)`
 
 	if err := c.notification.SendInfoMessage(ctx, chatID, message); err != nil {
-		c.logger.Warn("failed to activation message", "chatID", chatID, "error", err)
+		c.logger.WarnContext(ctx, "failed to activation message", "error", err)
 	}
 
 	c.handlePriceCommand(ctx, chatID)
 }
 
 func (c *ChiRouter) handleStopCommand(ctx context.Context, chatID int64, user *entity.User) {
-	c.logger.Debug("Handling stop command", "chatId", chatID)
+	c.logger.DebugContext(ctx, "Handling stop command")
 
 	user.Active = false
 	if err := c.userRepo.SaveOrUpdate(ctx, user); err != nil {
-		c.logger.Error("failed to deactivated user", "chatID", chatID, "error", err)
+		c.logger.ErrorContext(ctx, "failed to deactivated user", "error", err)
 		c.notification.SendInfoMessage(ctx, chatID, "Failed to deactivated user")
 		return
 	}
 
 	if err := c.notification.DeactivateUser(ctx, chatID); err != nil {
-		c.logger.Warn("failed to deactivated user", "chatID", chatID, "error", err)
+		c.logger.WarnContext(ctx, "failed to deactivated user", "error", err)
 	}
 }
 
 func (c *ChiRouter) handlePriceCommand(ctx context.Context, chatID int64) {
-	c.logger.Debug("Handling price command", "chatId", chatID)
+	c.logger.DebugContext(ctx, "Handling price command")
 
 	prices, err := c.cryptClient.GetAllPrices(ctx)
 	if err != nil {
-		c.logger.Error("failed to get prices for command", "chatId", chatID, "error", err)
+		c.logger.ErrorContext(ctx, "failed to get prices for command", "error", err)
 		c.notification.SendInfoMessage(ctx, chatID, "Failed to get prices. Please try again later.")
 		return
 	}
 
 	if err := c.notification.SendAllPrices(ctx, chatID, prices); err != nil {
-		c.logger.Warn("failed to send all prices", "chatId", chatID, "error", err)
+		c.logger.WarnContext(ctx, "failed to send all prices", "error", err)
 	}
 }
 
 func (c *ChiRouter) handleHelpCommand(ctx context.Context, chatID int64) {
-	c.logger.Debug("Handling help command", "chatId", chatID)
+	c.logger.DebugContext(ctx, "Handling help command")
 
 	helpText := `Crypto Price Bot Help
 
@@ -254,36 +378,101 @@ func (c *ChiRouter) handleHelpCommand(ctx context.Context, chatID int64) {
 /start - Подписаться на рассылку
 /stop - Отписаться от рассылки  
 /price - Текущие цены монет
+/digest off|daily|weekly - Дайджест рынка (OHLC, лидеры роста/падения)
+/timezone <зона> - Часовой пояс, например Europe/Moscow
 /help - Это сообщение
 
 *Функции:
 • Автоматическая рассылка каждые 15 минут
 • Отслеживание BTC и ETH
+• Ежедневный или еженедельный дайджест рынка
 • Точные цены с Bybit API
 
 Для начала работы используйте /start`
 
 	if err := c.notification.SendInfoMessage(ctx, chatID, helpText); err != nil {
-		c.logger.Warn("failed to send help message", "chatId", chatID, "error", err)
+		c.logger.WarnContext(ctx, "failed to send help message", "error", err)
+	}
+}
+
+func (c *ChiRouter) handleDigestCommand(ctx context.Context, chatID int64, args []string) {
+	c.logger.DebugContext(ctx, "Handling digest command")
+
+	if len(args) != 1 {
+		c.sendInfo(ctx, chatID, "Использование: /digest off|daily|weekly")
+		return
+	}
+
+	period, ok := entity.ParseDigestPeriod(strings.ToLower(args[0]))
+	if !ok {
+		c.sendInfo(ctx, chatID, "Использование: /digest off|daily|weekly")
+		return
+	}
+
+	if err := c.userRepo.SetDigest(ctx, chatID, period); err != nil {
+		c.logger.ErrorContext(ctx, "failed to set digest", "error", err)
+		c.sendInfo(ctx, chatID, "Failed to update digest settings")
+		return
+	}
+
+	var message string
+	switch period {
+	case entity.DigestDaily:
+		message = "Ежедневный дайджест включен. Он будет приходить в 09:00 по вашему времени (/timezone)."
+	case entity.DigestWeekly:
+		message = "Еженедельный дайджест включен. Он будет приходить по понедельникам в 09:00 по вашему времени (/timezone)."
+	default:
+		message = "Дайджест отключен."
+	}
+
+	c.sendInfo(ctx, chatID, message)
+}
+
+func (c *ChiRouter) handleTimezoneCommand(ctx context.Context, chatID int64, args []string) {
+	c.logger.DebugContext(ctx, "Handling timezone command")
+
+	if len(args) != 1 {
+		c.sendInfo(ctx, chatID, "Использование: /timezone Europe/Moscow")
+		return
+	}
+
+	if _, err := time.LoadLocation(args[0]); err != nil {
+		c.sendInfo(ctx, chatID, "Неизвестный часовой пояс. Пример: /timezone Europe/Moscow")
+		return
+	}
+
+	if err := c.userRepo.SetTimezone(ctx, chatID, args[0]); err != nil {
+		c.logger.ErrorContext(ctx, "failed to set timezone", "error", err)
+		c.sendInfo(ctx, chatID, "Failed to update timezone")
+		return
+	}
+
+	c.sendInfo(ctx, chatID, "Часовой пояс установлен: "+args[0])
+}
+
+func (c *ChiRouter) sendInfo(ctx context.Context, chatID int64, text string) {
+	if err := c.notification.SendInfoMessage(ctx, chatID, text); err != nil {
+		c.logger.WarnContext(ctx, "failed to send info message", "error", err)
 	}
 }
 
 func (c *ChiRouter) handleUnknowCommand(ctx context.Context, chatID int64) {
-	c.logger.Debug("Handling unknow command", "chatId", chatID)
+	c.logger.DebugContext(ctx, "Handling unknow command")
 
 	message := `Неизвестная команда
 
 Используйте /help для просмотра доступных команд`
 
 	if err := c.notification.SendInfoMessage(ctx, chatID, message); err != nil {
-		c.logger.Warn("failed to send unknow message", "chatId", chatID, "error", err)
+		c.logger.WarnContext(ctx, "failed to send unknow message", "error", err)
 	}
 }
 
 func (c *ChiRouter) getActiveUsersHandler(w http.ResponseWriter, r *http.Request) {
-	users, err := c.userRepo.GetAllActive(r.Context())
+	active := true
+	count, err := c.userRepo.Count(r.Context(), entity.UserFilter{Active: &active})
 	if err != nil {
-		c.logger.Error("Error getting active users", "error", err)
+		c.logger.ErrorContext(r.Context(), "Error getting active users", "error", err)
 		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
 
 		return
@@ -291,15 +480,7 @@ func (c *ChiRouter) getActiveUsersHandler(w http.ResponseWriter, r *http.Request
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(fmt.Sprintf(`{"active_users":%d}`, len(users))))
-}
-
-func (c *ChiRouter) getCurrenciesHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Get currencies from repository and return as JSON
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"currencies":["BTC", "ETH"]}`))
+	w.Write([]byte(fmt.Sprintf(`{"active_users":%d}`, count)))
 }
 
 func (c *ChiRouter) notFoundHandler(w http.ResponseWriter, r *http.Request) {
@@ -317,8 +498,12 @@ func (c *ChiRouter) methodNotAllowedHandler(w http.ResponseWriter, r *http.Reque
 func (c *ChiRouter) Shutdown(ctx context.Context) error {
 	c.logger.Info("Shutting down HTTP server")
 
-	if c.server != nil {
-		if err := c.server.Shutdown(ctx); err != nil {
+	c.serverMu.Lock()
+	server := c.server
+	c.serverMu.Unlock()
+
+	if server != nil {
+		if err := server.Shutdown(ctx); err != nil {
 			c.logger.Error("Error shutting down HTTP server", "error", err)
 			return err
 		}
@@ -417,6 +602,18 @@ func (c *ChiRouter) detailedHealthHandler(w http.ResponseWriter, r *http.Request
 		}
 	}
 
+	// Leadership is informational: followers are as healthy as the leader.
+	leader := c.healthChecker.LeaderStatus()
+	role := "follower"
+	if leader.Leader {
+		role = "leader since " + leader.Since.Format(time.RFC3339)
+	}
+	response.Checks["leader"] = entity.HealCheck{
+		Status:    entity.HealStatusOk,
+		Message:   fmt.Sprintf("Instance %s is %s", leader.InstanceID, role),
+		Timestamp: time.Now(),
+	}
+
 	if !allHealthy {
 		response.Status = entity.HealStatusError
 		c.writeHealthResponse(w, response, http.StatusServiceUnavailable)
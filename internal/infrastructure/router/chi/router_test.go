@@ -0,0 +1,58 @@
+package chi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/infrastructure/updates"
+)
+
+type stubQueue struct {
+	err  error
+	keys []int64
+}
+
+func (s *stubQueue) Submit(key int64, task func(ctx context.Context)) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.keys = append(s.keys, key)
+	task(context.Background())
+	return nil
+}
+
+func TestTelegramWebhook_Queue(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"queued", nil, http.StatusOK},
+		{"queue full", updates.ErrQueueFull, http.StatusServiceUnavailable},
+		{"shutting down", service.ErrShuttingDown, http.StatusServiceUnavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			queue := &stubQueue{err: tc.err}
+			router := NewChiRouter(slog.Default(), nil, nil, nil, nil, nil, nil, testKeys, nil, queue, nil, nil, nil).(*ChiRouter)
+			router.SetupMiddleware()
+			router.SetupRoutes()
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook/telegram", strings.NewReader(`{"update_id": 7}`))
+			rec := httptest.NewRecorder()
+			router.router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.want {
+				t.Errorf("Status = %d, want %d", rec.Code, tc.want)
+			}
+			if tc.err == nil && !slices.Equal(queue.keys, []int64{7}) {
+				t.Errorf("Queued keys = %v, want [7]", queue.keys)
+			}
+		})
+	}
+}
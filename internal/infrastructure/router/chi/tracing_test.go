@@ -0,0 +1,70 @@
+package chi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type stubNotification struct {
+	service.Notification
+	sent chan trace.SpanContext
+}
+
+func (s *stubNotification) SendInfoMessage(ctx context.Context, chatID int64, text string) error {
+	s.sent <- trace.SpanContextFromContext(ctx)
+	return nil
+}
+
+func TestTelegramWebhook_PropagatesTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	router, _ := newAdminTestRouter()
+	notifier := &stubNotification{sent: make(chan trace.SpanContext, 1)}
+	router.notification = notifier
+
+	body := `{"update_id": 7, "message": {"message_id": 1, "from": {"id": 42}, "chat": {"id": 42}, "text": "/help"}}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook/telegram", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	router.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	var sendCtx trace.SpanContext
+	select {
+	case sendCtx = <-notifier.sent:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("update was not handled")
+	}
+
+	var webhook sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "telegram.webhook" {
+			webhook = span
+		}
+	}
+	if webhook == nil {
+		t.Fatalf("telegram.webhook span not recorded")
+	}
+
+	if sendCtx.TraceID() != webhook.SpanContext().TraceID() {
+		t.Errorf("send trace = %s, want %s", sendCtx.TraceID(), webhook.SpanContext().TraceID())
+	}
+}
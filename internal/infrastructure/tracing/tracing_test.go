@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestStdoutExporter(t *testing.T) {
+	var buf bytes.Buffer
+
+	exp, err := NewStdoutExporter(&buf)
+	if err != nil {
+		t.Fatalf("NewStdoutExporter failed: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	_, child := provider.Tracer("test").Start(ctx, "child")
+
+	End(child, errors.New("boom"))
+	parent.End()
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{`"Name":"child"`, `"Name":"parent"`, `"Description":"boom"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("exporter output missing %s", want)
+		}
+	}
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), "zipkin", "test"); err == nil {
+		t.Errorf("Expected error for unknown exporter")
+	}
+
+	shutdown, err := Setup(context.Background(), ExporterNone, "test")
+	if err != nil {
+		t.Fatalf("Setup(none) failed: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown failed: %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+// Package updates processes incoming Telegram updates on a bounded pool of
+// workers.
+package updates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/infrastructure/metrics"
+)
+
+// ErrQueueFull is returned by Submit under the Reject policy when the queue
+// of the task's key stayed full for the whole wait.
+var ErrQueueFull = errors.New("update queue is full")
+
+// Policy decides what happens to a task whose queue is full.
+type Policy int
+
+const (
+	// Reject refuses the task, so the webhook answers 503 and Telegram
+	// delivers the update again later.
+	Reject Policy = iota
+	// Drop discards the task. The update is acknowledged and lost.
+	Drop
+)
+
+func (p Policy) String() string {
+	if p == Drop {
+		return "drop"
+	}
+	return "reject"
+}
+
+// ParsePolicy parses "reject" or "drop".
+func ParsePolicy(s string) (Policy, error) {
+	switch strings.ToLower(s) {
+	case "reject":
+		return Reject, nil
+	case "drop":
+		return Drop, nil
+	}
+	return Reject, fmt.Errorf("unknown overflow policy %q", s)
+}
+
+type job struct {
+	task     func(ctx context.Context)
+	queuedAt time.Time
+}
+
+// Processor runs tasks on a fixed number of workers, each draining its own
+// bounded queue. Tasks are assigned to a queue by key, so tasks with the
+// same key (a chat) run one at a time in submission order, while different
+// keys run in parallel. Chats sharing a queue also wait for each other.
+type Processor struct {
+	shards []chan job
+	policy Policy
+	wait   time.Duration
+	logger *slog.Logger
+
+	// ctx is given to tasks and cancelled when Shutdown's deadline passes.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.RWMutex
+	closed  bool
+	workers sync.WaitGroup
+}
+
+// NewProcessor starts workers, each with a queue of queueSize tasks. When a
+// queue is full, Submit waits up to wait for room before applying policy.
+func NewProcessor(workers, queueSize int, policy Policy, wait time.Duration, logger *slog.Logger) *Processor {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &Processor{
+		shards: make([]chan job, max(1, workers)),
+		policy: policy,
+		wait:   wait,
+		logger: logger.With(slog.String("component", "updates.Processor")),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	for i := range p.shards {
+		p.shards[i] = make(chan job, max(1, queueSize))
+		p.workers.Add(1)
+		go p.work(p.shards[i])
+	}
+
+	return p
+}
+
+// Submit queues task behind the earlier tasks with the same key. It fails
+// with ErrQueueFull when the queue has no room and the policy is Reject,
+// and with service.ErrShuttingDown once Shutdown was called.
+func (p *Processor) Submit(key int64, task func(ctx context.Context)) error {
+	// The read lock keeps Shutdown from closing the queue while a task is
+	// being added to it.
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		metrics.Updates.WithLabelValues("rejected").Inc()
+		return service.ErrShuttingDown
+	}
+
+	queue := p.shards[uint64(key)%uint64(len(p.shards))]
+	j := job{task: task, queuedAt: time.Now()}
+
+	if p.enqueue(queue, j) {
+		metrics.Updates.WithLabelValues("queued").Inc()
+		metrics.UpdateQueueDepth.Inc()
+		return nil
+	}
+
+	if p.policy == Drop {
+		p.logger.Warn("Update queue is full, dropping update", "key", key)
+		metrics.Updates.WithLabelValues("dropped").Inc()
+		return nil
+	}
+
+	metrics.Updates.WithLabelValues("rejected").Inc()
+	return ErrQueueFull
+}
+
+// Shutdown stops accepting tasks and waits until the queued ones have run.
+// If ctx is done first, the running tasks' context is cancelled, the tasks
+// still queued are discarded and ctx's error is returned.
+func (p *Processor) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for _, queue := range p.shards {
+			close(queue)
+		}
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return fmt.Errorf("updates still pending: %w", ctx.Err())
+	}
+}
+
+// enqueue adds j to queue, waiting up to p.wait for room.
+func (p *Processor) enqueue(queue chan<- job, j job) bool {
+	select {
+	case queue <- j:
+		return true
+	default:
+	}
+
+	if p.wait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(p.wait)
+	defer timer.Stop()
+
+	select {
+	case queue <- j:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (p *Processor) work(queue <-chan job) {
+	defer p.workers.Done()
+
+	for j := range queue {
+		metrics.UpdateQueueDepth.Dec()
+
+		if p.ctx.Err() != nil {
+			metrics.Updates.WithLabelValues("dropped").Inc()
+			continue
+		}
+
+		metrics.UpdateQueueWait.Observe(time.Since(j.queuedAt).Seconds())
+		p.run(j.task)
+	}
+}
+
+func (p *Processor) run(task func(ctx context.Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.logger.Error("panic in update task", "recover", r)
+		}
+	}()
+
+	task(p.ctx)
+}
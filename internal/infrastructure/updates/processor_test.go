@@ -0,0 +1,200 @@
+package updates
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+)
+
+// block returns a task that waits for release and a channel closed once it
+// has started.
+func block(release <-chan struct{}) (func(ctx context.Context), <-chan struct{}) {
+	started := make(chan struct{})
+	return func(ctx context.Context) {
+		close(started)
+		<-release
+	}, started
+}
+
+func TestProcessor_SameKeyRunsInOrder(t *testing.T) {
+	p := NewProcessor(4, 100, Reject, 0, slog.Default())
+
+	var mu sync.Mutex
+	var got []int
+	for i := range 50 {
+		err := p.Submit(42, func(ctx context.Context) {
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			got = append(got, i)
+			mu.Unlock()
+		})
+		if err != nil {
+			t.Fatalf("Submit %d failed: %v", i, err)
+		}
+	}
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	want := make([]int, 50)
+	for i := range want {
+		want[i] = i
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Tasks ran in order %v", got)
+	}
+}
+
+func TestProcessor_OtherKeysAreNotBlocked(t *testing.T) {
+	p := NewProcessor(2, 10, Reject, 0, slog.Default())
+	release := make(chan struct{})
+	defer close(release)
+
+	task, started := block(release)
+	if err := p.Submit(0, task); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	<-started
+
+	done := make(chan struct{})
+	if err := p.Submit(1, func(ctx context.Context) { close(done) }); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Task of another key waited for a blocked key")
+	}
+}
+
+func TestProcessor_Overflow(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy Policy
+		want   error
+	}{
+		{"reject", Reject, ErrQueueFull},
+		{"drop", Drop, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewProcessor(1, 1, tc.policy, 10*time.Millisecond, slog.Default())
+			release := make(chan struct{})
+			defer close(release)
+
+			task, started := block(release)
+			if err := p.Submit(1, task); err != nil {
+				t.Fatalf("Submit failed: %v", err)
+			}
+			<-started
+
+			ran := make(chan struct{}, 2)
+			queued := func(ctx context.Context) { ran <- struct{}{} }
+			if err := p.Submit(1, queued); err != nil {
+				t.Fatalf("Submit into free slot failed: %v", err)
+			}
+
+			if err := p.Submit(1, queued); !errors.Is(err, tc.want) {
+				t.Errorf("Submit into full queue error = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestProcessor_WaitsForRoom(t *testing.T) {
+	p := NewProcessor(1, 1, Reject, time.Second, slog.Default())
+	release := make(chan struct{})
+
+	task, started := block(release)
+	if err := p.Submit(1, task); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	<-started
+	if err := p.Submit(1, func(context.Context) {}); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+
+	if err := p.Submit(1, func(context.Context) {}); err != nil {
+		t.Errorf("Submit should wait for room, got %v", err)
+	}
+}
+
+func TestProcessor_ShutdownDrainsQueue(t *testing.T) {
+	p := NewProcessor(2, 10, Reject, 0, slog.Default())
+
+	var mu sync.Mutex
+	var ran int
+	for key := range 10 {
+		err := p.Submit(int64(key), func(ctx context.Context) {
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			ran++
+			mu.Unlock()
+		})
+		if err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+	}
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if ran != 10 {
+		t.Errorf("Ran %d tasks before shutdown returned, want 10", ran)
+	}
+
+	if err := p.Submit(1, func(context.Context) {}); !errors.Is(err, service.ErrShuttingDown) {
+		t.Errorf("Submit after shutdown error = %v, want %v", err, service.ErrShuttingDown)
+	}
+}
+
+func TestProcessor_ShutdownDeadlineCancelsTasks(t *testing.T) {
+	p := NewProcessor(1, 10, Reject, 0, slog.Default())
+
+	cancelled := make(chan struct{})
+	started := make(chan struct{})
+	err := p.Submit(1, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+	})
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	<-started
+
+	var queuedRan bool
+	if err := p.Submit(1, func(context.Context) { queuedRan = true }); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown error = %v, want deadline exceeded", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("Running task was not cancelled at the deadline")
+	}
+
+	// Wait for the worker to discard the queued task.
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Second Shutdown failed: %v", err)
+	}
+	if queuedRan {
+		t.Error("Queued task ran after the deadline")
+	}
+}
@@ -0,0 +1,122 @@
+// Package lifecycle tracks background tasks and shuts the application down
+// in a fixed order.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type stage struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Manager runs background tasks and the shutdown stages registered with
+// OnShutdown. Tasks get a context that stays valid during shutdown so they
+// can finish their work; it is cancelled only when the shutdown deadline
+// passes.
+type Manager struct {
+	logger *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	stopping bool
+	tasks    sync.WaitGroup
+	stages   []stage
+}
+
+func New(logger *slog.Logger) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Manager{
+		logger: logger.With(slog.String("component", "lifecycle")),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Go runs task in the background and tracks it until it returns. It reports
+// false, without running task, once shutdown has begun.
+func (m *Manager) Go(name string, task func(ctx context.Context)) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopping {
+		return false
+	}
+
+	m.tasks.Add(1)
+	go func() {
+		defer m.tasks.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				m.logger.Error("panic in background task", "task", name, "recover", r)
+			}
+		}()
+
+		task(m.ctx)
+	}()
+
+	return true
+}
+
+// OnShutdown adds a shutdown stage. Stages run in the order they were added.
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stages = append(m.stages, stage{name: name, fn: fn})
+}
+
+// Wait blocks until every task started with Go has returned. If ctx is done
+// first, the tasks' context is cancelled and ctx's error is returned. It is
+// meant to be used as a shutdown stage.
+func (m *Manager) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.tasks.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		m.cancel()
+		return fmt.Errorf("tasks still running: %w", ctx.Err())
+	}
+}
+
+// Shutdown stops accepting tasks and runs every stage in order, even after
+// one fails or ctx is done, so resources are always released. It returns
+// the errors of all failed stages.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.stopping = true
+	stages := m.stages
+	m.mu.Unlock()
+
+	defer m.cancel()
+
+	var errs []error
+	for _, s := range stages {
+		start := time.Now()
+
+		if err := s.fn(ctx); err != nil {
+			m.logger.Error("Shutdown stage failed", "stage", s.name, "duration", time.Since(start), "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+			continue
+		}
+
+		m.logger.Info("Shutdown stage completed", "stage", s.name, "duration", time.Since(start))
+	}
+
+	return errors.Join(errs...)
+}
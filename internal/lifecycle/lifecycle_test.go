@@ -0,0 +1,133 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (r *recorder) add(step string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, step)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.steps)
+}
+
+func TestShutdown_RunsStagesInOrderAfterDrainingTasks(t *testing.T) {
+	m := New(slog.Default())
+	rec := &recorder{}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	m.Go("update", func(ctx context.Context) {
+		close(started)
+		<-release
+		rec.add("task done")
+	})
+	<-started
+
+	m.OnShutdown("http server", func(context.Context) error {
+		rec.add("http server")
+		close(release)
+		return nil
+	})
+	m.OnShutdown("tasks", m.Wait)
+	m.OnShutdown("database", func(context.Context) error {
+		rec.add("database")
+		return nil
+	})
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	want := []string{"http server", "task done", "database"}
+	if got := rec.get(); !slices.Equal(got, want) {
+		t.Errorf("Steps = %v, want %v", got, want)
+	}
+}
+
+func TestGo_RejectedOnceShutdownStarts(t *testing.T) {
+	m := New(slog.Default())
+
+	var accepted bool
+	m.OnShutdown("http server", func(context.Context) error {
+		accepted = m.Go("late update", func(context.Context) {})
+		return nil
+	})
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if accepted {
+		t.Error("Go accepted a task during shutdown")
+	}
+}
+
+func TestTaskContext_OutlivesSignalButNotDeadline(t *testing.T) {
+	m := New(slog.Default())
+
+	cancelled := make(chan struct{})
+	started := make(chan struct{})
+	m.Go("send", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+	})
+	<-started
+
+	m.OnShutdown("tasks", m.Wait)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := m.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown error = %v, want deadline exceeded", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("Task context was not cancelled at the deadline")
+	}
+}
+
+func TestShutdown_ContinuesPastFailedStage(t *testing.T) {
+	m := New(slog.Default())
+	rec := &recorder{}
+	errBroken := errors.New("broken")
+
+	m.OnShutdown("broadcasts", func(context.Context) error {
+		rec.add("broadcasts")
+		return errBroken
+	})
+	m.OnShutdown("database", func(context.Context) error {
+		rec.add("database")
+		return nil
+	})
+
+	err := m.Shutdown(context.Background())
+	if !errors.Is(err, errBroken) {
+		t.Errorf("Shutdown error = %v, want %v", err, errBroken)
+	}
+
+	want := []string{"broadcasts", "database"}
+	if got := rec.get(); !slices.Equal(got, want) {
+		t.Errorf("Steps = %v, want %v", got, want)
+	}
+}
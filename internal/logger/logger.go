@@ -1,25 +1,81 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
 )
 
-func NewLogger(level string) *slog.Logger {
-	var logLevel slog.Level
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// level is shared by every logger built by this package so the verbosity can
+// be changed at runtime with SetLevel.
+var level = new(slog.LevelVar)
+
+func NewLogger(level, format string) *slog.Logger {
+	return New(os.Stdout, level, format)
+}
+
+// New builds a logger that writes to w in the given format ("json" or
+// "text").
+func New(w io.Writer, lvl, format string) *slog.Logger {
+	parsed, levelErr := ParseLevel(lvl)
+	level.Set(parsed)
+
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, FormatText) {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
 
-	switch level {
+	logger := slog.New(contextHandler{handler})
+	if levelErr != nil {
+		logger.Warn("Falling back to info log level", "error", levelErr)
+	}
+
+	return logger
+}
+
+// ParseLevel parses a level name case-insensitively. An empty name means
+// info; an unknown one is reported and also falls back to info.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
 	case "debug":
-		logLevel = slog.LevelDebug
-	case "info":
-		logLevel = slog.LevelInfo
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
 	default:
-		logLevel = slog.LevelInfo
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q", s)
+	}
+}
+
+// SetLevel changes the level of every logger created by NewLogger.
+func SetLevel(s string) error {
+	parsed, err := ParseLevel(s)
+	if err != nil {
+		return err
 	}
 
-	logger := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: logLevel,
-	})
+	level.Set(parsed)
+	return nil
+}
 
-	return slog.New(logger)
+// Level reports the current log level.
+func Level() slog.Level {
+	return level.Level()
 }
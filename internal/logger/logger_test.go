@@ -2,10 +2,13 @@ package logger
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"log/slog"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestNewLogger(t *testing.T) {
@@ -15,8 +18,10 @@ func TestNewLogger(t *testing.T) {
 		expected slog.Level
 	}{
 		{"Debug level", "debug", slog.LevelDebug},
+		{"Capitalised debug level", "Debug", slog.LevelDebug},
 		{"Info level", "info", slog.LevelInfo},
 		{"Warn level", "warn", slog.LevelWarn},
+		{"Warning level", "WARNING", slog.LevelWarn},
 		{"Error level", "error", slog.LevelError},
 		{"Unknow level", "unknow", slog.LevelInfo},
 		{"Empty level", "", slog.LevelInfo},
@@ -24,27 +29,98 @@ func TestNewLogger(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			logger := NewLogger(tt.level)
-			assert.NotNil(t, logger)
-
 			var buf bytes.Buffer
-			handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
-				Level: tt.expected,
-			})
-
-			testLogger := slog.New(handler)
+			logger := New(&buf, tt.level, FormatJSON)
+			assert.NotNil(t, logger)
 
-			assert.NotNil(t, testLogger)
+			assert.Equal(t, tt.expected, Level())
+			assert.True(t, logger.Enabled(context.Background(), tt.expected))
+			assert.False(t, logger.Enabled(context.Background(), tt.expected-1))
 		})
 	}
 }
 
-func TestLoggerLevels(t *testing.T) {
-	logger := NewLogger("debug")
-	assert.NotNil(t, logger)
+func TestNewLogger_Format(t *testing.T) {
+	var buf bytes.Buffer
+
+	New(&buf, "info", FormatText).Info("hello", "chat_id", 42)
+	assert.Contains(t, buf.String(), "msg=hello chat_id=42")
+
+	buf.Reset()
+	New(&buf, "info", "").Info("hello", "chat_id", 42)
+	assert.Contains(t, buf.String(), `"msg":"hello","chat_id":42`)
+}
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "info", FormatJSON)
+
+	logger.Debug("hidden")
+	assert.Empty(t, buf.String())
+
+	assert.NoError(t, SetLevel("DEBUG"))
+	logger.Debug("shown")
+	assert.Contains(t, buf.String(), "shown")
+
+	assert.Error(t, SetLevel("verbose"))
+	assert.Equal(t, slog.LevelDebug, Level())
+}
+
+func TestContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "info", FormatJSON)
+
+	ctx := WithAttrs(context.Background(), slog.String("request_id", "req-1"), slog.Int64("chat_id", 1))
+	ctx = WithAttrs(ctx, slog.Int64("chat_id", 42), slog.String("command", "/price"))
+
+	logger.InfoContext(ctx, "with context")
+	assert.Contains(t, buf.String(), `"request_id":"req-1","chat_id":42,"command":"/price"`)
+
+	buf.Reset()
+	logger.InfoContext(ctx, "explicit", "chat_id", 7)
+	assert.Contains(t, buf.String(), `"chat_id":7`)
+	assert.NotContains(t, buf.String(), `"chat_id":42`)
+
+	buf.Reset()
+	logger.Info("without context")
+	assert.NotContains(t, buf.String(), "request_id")
+}
+
+func TestTraceAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(contextHandler{slog.NewJSONHandler(&buf, nil)})
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	logger.InfoContext(ctx, "with span")
+	assert.Contains(t, buf.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+	assert.Contains(t, buf.String(), `"span_id":"00f067aa0ba902b7"`)
+
+	buf.Reset()
+	logger.With("component", "test").InfoContext(context.Background(), "without span")
+	assert.NotContains(t, buf.String(), "trace_id")
+}
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "info", FormatJSON)
+
+	const token = "123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw"
+
+	logger.Info("config",
+		"tg_token", "anything",
+		"url", "https://api.telegram.org/bot"+token+"/getMe",
+		"error", errors.New("Post https://api.telegram.org/bot"+token+"/sendMessage: timeout"),
+		"key", "tgb_0123456789abcdef0123456789abcdef0123456789abcdef")
 
-	logger.Debug("debug message")
-	logger.Info("info message")
-	logger.Warn("warn message")
-	logger.Error("error message")
+	out := buf.String()
+	assert.NotContains(t, out, token)
+	assert.NotContains(t, out, "anything")
+	assert.NotContains(t, out, "tgb_0123")
+	assert.Contains(t, out, `"url":"https://api.telegram.org/bot[REDACTED]/getMe"`)
 }
@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// secretKeys are attribute keys whose values are never logged.
+var secretKeys = []string{"token", "password", "secret", "authorization", "api_key", "apikey"}
+
+var secretPatterns = []*regexp.Regexp{
+	// Telegram bot tokens, also when embedded in Bot API URLs.
+	regexp.MustCompile(`\d{6,}:[A-Za-z0-9_-]{30,}`),
+	// API keys issued by the apikey command.
+	regexp.MustCompile(`tgb_[0-9a-f]{16,}`),
+}
+
+// redactAttr hides secrets from log output: values of attributes named like
+// a credential, and anything that looks like a bot token or API key inside
+// strings and errors.
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if s := Redact(a.Value.String()); s != a.Value.String() {
+			return slog.String(a.Key, s)
+		}
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			if s := Redact(err.Error()); s != err.Error() {
+				return slog.String(a.Key, s)
+			}
+		}
+	}
+
+	return a
+}
+
+// Redact replaces bot tokens and API keys in s.
+func Redact(s string) string {
+	for _, re := range secretPatterns {
+		s = re.ReplaceAllString(s, redacted)
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+type APIKeyRepo struct {
+	store *Store
+}
+
+func NewAPIKeyRepo(store *Store) service.APIKeyRepository {
+	return &APIKeyRepo{store: store}
+}
+
+// Keys are never deleted, so a key's ID is its position in the store
+// plus one, like a BIGSERIAL.
+func (ar *APIKeyRepo) Create(ctx context.Context, key *entity.APIKey, hash string) error {
+	ar.store.write(ctx, func() {
+		key.ID = int64(len(ar.store.apiKeys)) + 1
+		key.CreatedAt = time.Now()
+
+		stored := *key
+		stored.Scopes = slices.Clone(key.Scopes)
+		ar.store.apiKeys = append(ar.store.apiKeys, apiKeyEntry{key: stored, hash: hash})
+	})
+
+	return nil
+}
+
+func (ar *APIKeyRepo) GetByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	var key *entity.APIKey
+	ar.store.read(func() {
+		for _, entry := range ar.store.apiKeys {
+			if entry.hash == hash {
+				key = copyKey(entry.key)
+				return
+			}
+		}
+	})
+
+	if key == nil {
+		return nil, service.ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (ar *APIKeyRepo) List(ctx context.Context) ([]*entity.APIKey, error) {
+	var keys []*entity.APIKey
+	ar.store.read(func() {
+		for _, entry := range ar.store.apiKeys {
+			keys = append(keys, copyKey(entry.key))
+		}
+	})
+
+	return keys, nil
+}
+
+func (ar *APIKeyRepo) Revoke(ctx context.Context, id int64) error {
+	revoked := false
+	ar.store.write(ctx, func() {
+		if id < 1 || id > int64(len(ar.store.apiKeys)) {
+			return
+		}
+
+		entry := ar.store.apiKeys[id-1]
+		if entry.key.RevokedAt != nil {
+			return
+		}
+
+		now := time.Now()
+		entry.key.RevokedAt = &now
+		ar.store.apiKeys[id-1] = entry
+		revoked = true
+	})
+
+	if !revoked {
+		return service.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (ar *APIKeyRepo) TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error {
+	ar.store.write(ctx, func() {
+		if id >= 1 && id <= int64(len(ar.store.apiKeys)) {
+			ar.store.apiKeys[id-1].key.LastUsedAt = &usedAt
+		}
+	})
+
+	return nil
+}
+
+func copyKey(key entity.APIKey) *entity.APIKey {
+	key.Scopes = slices.Clone(key.Scopes)
+	return &key
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+type CandleRepo struct {
+	store *Store
+}
+
+func NewCandleRepo(store *Store) service.CandleRepository {
+	return &CandleRepo{store: store}
+}
+
+func (cr *CandleRepo) Upsert(ctx context.Context, candles []*entity.Candle) error {
+	cr.store.write(ctx, func() {
+		for _, candle := range candles {
+			stored := *candle
+			stored.OpenTime = stored.OpenTime.UTC()
+			key := candleKey{symbol: stored.Symbol, interval: stored.Interval, openTime: stored.OpenTime.UnixNano()}
+			cr.store.candles[key] = stored
+		}
+	})
+
+	return nil
+}
+
+func (cr *CandleRepo) GetRange(ctx context.Context, symbol entity.CurrencyName, interval entity.CandleInterval, from, to time.Time) ([]*entity.Candle, error) {
+	var candles []*entity.Candle
+
+	cr.store.read(func() {
+		for key, candle := range cr.store.candles {
+			if key.symbol != symbol || key.interval != interval || candle.OpenTime.Before(from) || !candle.OpenTime.Before(to) {
+				continue
+			}
+			candles = append(candles, &candle)
+		}
+	})
+
+	slices.SortFunc(candles, func(a, b *entity.Candle) int {
+		return a.OpenTime.Compare(b.OpenTime)
+	})
+	return candles, nil
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+type CurrencyRepo struct {
+	store *Store
+}
+
+func NewCurrencyRepo(store *Store) service.CurrencyRepository {
+	return &CurrencyRepo{store: store}
+}
+
+func (cr *CurrencyRepo) SaveOrUpdate(ctx context.Context, currency *entity.Price) error {
+	stored := entity.Price{
+		Symbol:  currency.Symbol,
+		Price:   currency.Price,
+		Updated: time.Now().Format(time.RFC3339),
+		Ticker:  currency.Ticker,
+	}
+
+	cr.store.write(ctx, func() {
+		cr.store.currencies[currency.Symbol] = stored
+	})
+
+	return nil
+}
+
+func (cr *CurrencyRepo) GetBySymbol(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error) {
+	var (
+		currency entity.Price
+		ok       bool
+	)
+	cr.store.read(func() {
+		currency, ok = cr.store.currencies[symbol]
+	})
+
+	if !ok {
+		return nil, service.ErrCurrencyNotFound
+	}
+	return &currency, nil
+}
+
+func (cr *CurrencyRepo) GetAll(ctx context.Context) ([]*entity.Price, error) {
+	var currencies []*entity.Price
+	cr.store.read(func() {
+		for _, currency := range cr.store.currencies {
+			currencies = append(currencies, &currency)
+		}
+	})
+
+	slices.SortFunc(currencies, func(a, b *entity.Price) int {
+		return strings.Compare(string(a.Symbol), string(b.Symbol))
+	})
+
+	return currencies, nil
+}
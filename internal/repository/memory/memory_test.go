@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"tgBotFinal/internal/entity"
+	"tgBotFinal/internal/repository/repotest"
+)
+
+func TestRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		store := NewStore()
+		return repotest.Repositories{
+			Users:        NewUserRepo(store),
+			Currencies:   NewCurrencyRepo(store),
+			PriceHistory: NewPriceHistoryRepo(store),
+			APIKeys:      NewAPIKeyRepo(store),
+			Candles:      NewCandleRepo(store),
+			UnitOfWork:   NewUnitOfWork(store),
+		}
+	})
+}
+
+func TestUserRepo_Concurrent(t *testing.T) {
+	store := NewStore()
+	users, uow := NewUserRepo(store), NewUnitOfWork(store)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = users.SaveOrUpdate(ctx, &entity.User{ChatID: int64(i), Active: true})
+		}()
+		go func() {
+			defer wg.Done()
+			_ = uow.WithTx(ctx, func(ctx context.Context) error {
+				return users.SaveOrUpdate(ctx, &entity.User{ChatID: int64(100 + i), Active: i%2 == 0})
+			})
+		}()
+	}
+	wg.Wait()
+
+	count, _ := users.Count(ctx, entity.UserFilter{})
+	active, _ := users.GetAllActive(ctx)
+	if count != 100 || len(active) != 75 {
+		t.Errorf("Count = %d, active = %d, want 100 users of which 75 active", count, len(active))
+	}
+}
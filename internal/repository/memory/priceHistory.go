@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+type PriceHistoryRepo struct {
+	store *Store
+}
+
+func NewPriceHistoryRepo(store *Store) service.PriceHistoryRepository {
+	return &PriceHistoryRepo{store: store}
+}
+
+func (pr *PriceHistoryRepo) Append(ctx context.Context, price *entity.Price, recordedAt time.Time) error {
+	// The price column is NUMERIC in Postgres, so malformed prices fail.
+	if _, err := strconv.ParseFloat(price.Price, 64); err != nil {
+		return err
+	}
+
+	pr.store.write(ctx, func() {
+		pr.store.history = append(pr.store.history, historyEntry{price: *price, recordedAt: recordedAt.UnixNano()})
+	})
+
+	return nil
+}
+
+func (pr *PriceHistoryRepo) Prune(ctx context.Context, before time.Time) (int64, error) {
+	var pruned int64
+	beforeNano := before.UnixNano()
+
+	pr.store.write(ctx, func() {
+		kept := make([]historyEntry, 0, len(pr.store.history))
+		for _, entry := range pr.store.history {
+			if entry.recordedAt < beforeNano {
+				pruned++
+				continue
+			}
+			kept = append(kept, entry)
+		}
+		pr.store.history = kept
+	})
+
+	return pruned, nil
+}
+
+func (pr *PriceHistoryRepo) GetOHLC(ctx context.Context, symbol entity.CurrencyName, from, to time.Time) (*entity.OHLC, error) {
+	var (
+		ohlc             = entity.OHLC{Symbol: symbol}
+		firstAt, lastAt  int64
+		fromNano, toNano = from.UnixNano(), to.UnixNano()
+	)
+
+	pr.store.read(func() {
+		for _, entry := range pr.store.history {
+			if entry.price.Symbol != symbol || entry.recordedAt < fromNano || entry.recordedAt >= toNano {
+				continue
+			}
+
+			price, _ := strconv.ParseFloat(entry.price.Price, 64)
+			if ohlc.Samples == 0 {
+				ohlc.Open, ohlc.High, ohlc.Low, ohlc.Close = price, price, price, price
+				firstAt, lastAt = entry.recordedAt, entry.recordedAt
+			}
+			ohlc.Samples++
+
+			ohlc.High = max(ohlc.High, price)
+			ohlc.Low = min(ohlc.Low, price)
+			// Entries are in insertion order, which breaks ties like
+			// the id column does in SQL.
+			if entry.recordedAt < firstAt {
+				ohlc.Open, firstAt = price, entry.recordedAt
+			}
+			if entry.recordedAt >= lastAt {
+				ohlc.Close, lastAt = price, entry.recordedAt
+			}
+		}
+	})
+
+	if ohlc.Samples == 0 {
+		return nil, service.ErrNoPriceHistory
+	}
+	return &ohlc, nil
+}
@@ -0,0 +1,144 @@
+// Package memory keeps the bot's data in process memory. The repositories
+// behave like the Postgres ones, upserts and filters included, and are safe
+// for concurrent use; they back tests and the demo mode.
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+// Store holds the data of every repository built on it. Repositories that
+// share a store also share its unit of work.
+type Store struct {
+	// txMu serialises transactions and the writes made outside of them, so
+	// rolling a transaction back never discards somebody else's write.
+	txMu sync.Mutex
+
+	mu         sync.RWMutex
+	users      map[int64]entity.User
+	currencies map[entity.CurrencyName]entity.Price
+	history    []historyEntry
+	apiKeys    []apiKeyEntry
+	candles    map[candleKey]entity.Candle
+}
+
+type historyEntry struct {
+	price      entity.Price
+	recordedAt int64
+}
+
+type candleKey struct {
+	symbol   entity.CurrencyName
+	interval entity.CandleInterval
+	openTime int64
+}
+
+type apiKeyEntry struct {
+	key  entity.APIKey
+	hash string
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:      make(map[int64]entity.User),
+		currencies: make(map[entity.CurrencyName]entity.Price),
+		candles:    make(map[candleKey]entity.Candle),
+	}
+}
+
+type txKey struct{}
+
+// write runs fn with the store locked for writing. Outside of a
+// transaction it first waits for the running one to finish.
+func (s *Store) write(ctx context.Context, fn func()) {
+	if ctx.Value(txKey{}) != s {
+		s.txMu.Lock()
+		defer s.txMu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn()
+}
+
+func (s *Store) read(fn func()) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fn()
+}
+
+type snapshot struct {
+	users      map[int64]entity.User
+	currencies map[entity.CurrencyName]entity.Price
+	history    []historyEntry
+	apiKeys    []apiKeyEntry
+	candles    map[candleKey]entity.Candle
+}
+
+// The stored values are never modified in place, so copying the
+// containers is enough.
+func (s *Store) snapshot() snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return snapshot{
+		users:      maps.Clone(s.users),
+		currencies: maps.Clone(s.currencies),
+		history:    slices.Clone(s.history),
+		apiKeys:    slices.Clone(s.apiKeys),
+		candles:    maps.Clone(s.candles),
+	}
+}
+
+func (s *Store) restore(snap snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users, s.currencies, s.history, s.apiKeys = snap.users, snap.currencies, snap.history, snap.apiKeys
+	s.candles = snap.candles
+}
+
+type UnitOfWork struct {
+	store *Store
+}
+
+func NewUnitOfWork(store *Store) service.UnitOfWork {
+	return &UnitOfWork{store: store}
+}
+
+// WithTx runs fn with the other writers of the store held off and restores
+// the store if fn returns an error or panics. Nested calls join the outer
+// transaction. Writes inside fn must use the context passed to it; with
+// any other context they would wait for the transaction forever.
+func (u *UnitOfWork) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	s := u.store
+	if ctx.Value(txKey{}) == s {
+		return fn(ctx)
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	snap := s.snapshot()
+	committed := false
+	defer func() {
+		if !committed {
+			s.restore(snap)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, s)); err != nil {
+		return err
+	}
+
+	committed = true
+	return nil
+}
@@ -0,0 +1,227 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+type UserRepo struct {
+	store *Store
+}
+
+func NewUserRepo(store *Store) service.UserRepository {
+	return &UserRepo{store: store}
+}
+
+// SaveOrUpdate creates the user or updates its username and active flag,
+// then fills user with the stored row.
+func (ur *UserRepo) SaveOrUpdate(ctx context.Context, user *entity.User) error {
+	now := time.Now()
+
+	ur.store.write(ctx, func() {
+		stored, ok := ur.store.users[user.ChatID]
+		if !ok {
+			stored = entity.User{ChatID: user.ChatID, Digest: entity.DigestOff, Timezone: "UTC", CreatedAt: now}
+		}
+		stored.Username = user.Username
+		stored.Active = user.Active
+		stored.UpdatedAt = now
+
+		ur.store.users[user.ChatID] = stored
+		*user = stored
+	})
+
+	return nil
+}
+
+func (ur *UserRepo) GetByChatID(ctx context.Context, chatID int64) (*entity.User, error) {
+	var (
+		user entity.User
+		ok   bool
+	)
+	ur.store.read(func() {
+		user, ok = ur.store.users[chatID]
+	})
+
+	if !ok {
+		return nil, service.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (ur *UserRepo) GetAll(ctx context.Context) ([]*entity.User, error) {
+	return ur.List(ctx, entity.UserFilter{})
+}
+
+func (ur *UserRepo) GetAllActive(ctx context.Context) ([]*entity.User, error) {
+	active := true
+	return ur.List(ctx, entity.UserFilter{Active: &active})
+}
+
+func (ur *UserRepo) SetDigest(ctx context.Context, chatID int64, period entity.DigestPeriod) error {
+	if !ur.update(ctx, chatID, func(user *entity.User) { user.Digest = period }) {
+		return service.ErrUserNotFound
+	}
+	return nil
+}
+
+func (ur *UserRepo) SetTimezone(ctx context.Context, chatID int64, timezone string) error {
+	if !ur.update(ctx, chatID, func(user *entity.User) { user.Timezone = timezone }) {
+		return service.ErrUserNotFound
+	}
+	return nil
+}
+
+func (ur *UserRepo) GetDigestSubscribers(ctx context.Context) ([]*entity.User, error) {
+	return ur.filter(func(user *entity.User) bool {
+		return user.Active && user.Digest != entity.DigestOff
+	}), nil
+}
+
+func (ur *UserRepo) MarkDigestSent(ctx context.Context, chatID int64, sentAt time.Time) error {
+	found := false
+	ur.store.write(ctx, func() {
+		if user, ok := ur.store.users[chatID]; ok {
+			user.LastDigestAt = &sentAt
+			ur.store.users[chatID] = user
+			found = true
+		}
+	})
+	if !found {
+		return service.ErrUserNotFound
+	}
+	return nil
+}
+
+// List orders the users like Postgres: newest first, then by chat ID.
+func (ur *UserRepo) List(ctx context.Context, filter entity.UserFilter) ([]*entity.User, error) {
+	users := ur.filter(func(user *entity.User) bool { return matches(user, filter) })
+
+	slices.SortFunc(users, func(a, b *entity.User) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ChatID, b.ChatID)
+	})
+
+	users = users[min(filter.Offset, len(users)):]
+	if filter.Limit > 0 && filter.Limit < len(users) {
+		users = users[:filter.Limit]
+	}
+
+	return users, nil
+}
+
+func (ur *UserRepo) Count(ctx context.Context, filter entity.UserFilter) (int, error) {
+	var count int
+	ur.store.read(func() {
+		for _, user := range ur.store.users {
+			if matches(&user, filter) {
+				count++
+			}
+		}
+	})
+
+	return count, nil
+}
+
+func (ur *UserRepo) SetActive(ctx context.Context, chatID int64, active bool) error {
+	if !ur.update(ctx, chatID, func(user *entity.User) { user.Active = active }) {
+		return service.ErrUserNotFound
+	}
+	return nil
+}
+
+func (ur *UserRepo) SetBannedUntil(ctx context.Context, chatID int64, until *time.Time) error {
+	if until != nil {
+		t := *until
+		until = &t
+	}
+
+	if !ur.update(ctx, chatID, func(user *entity.User) { user.BannedUntil = until }) {
+		return service.ErrUserNotFound
+	}
+	return nil
+}
+
+func (ur *UserRepo) Delete(ctx context.Context, chatID int64) error {
+	var ok bool
+	ur.store.write(ctx, func() {
+		if _, ok = ur.store.users[chatID]; ok {
+			delete(ur.store.users, chatID)
+		}
+	})
+
+	if !ok {
+		return service.ErrUserNotFound
+	}
+	return nil
+}
+
+func (ur *UserRepo) GrowthStats(ctx context.Context, from, to time.Time, bucket entity.GrowthBucket) ([]*entity.UserGrowthPoint, error) {
+	if !bucket.Valid() {
+		return nil, fmt.Errorf("unknown growth bucket %q", bucket)
+	}
+
+	var signups []time.Time
+	ur.store.read(func() {
+		for _, user := range ur.store.users {
+			signups = append(signups, user.CreatedAt)
+		}
+	})
+	slices.SortFunc(signups, time.Time.Compare)
+
+	return entity.GrowthPoints(from, to, bucket, signups), nil
+}
+
+// update applies change to the stored user and bumps UpdatedAt. It
+// reports whether the user exists.
+func (ur *UserRepo) update(ctx context.Context, chatID int64, change func(user *entity.User)) bool {
+	var ok bool
+	ur.store.write(ctx, func() {
+		var user entity.User
+		if user, ok = ur.store.users[chatID]; ok {
+			change(&user)
+			user.UpdatedAt = time.Now()
+			ur.store.users[chatID] = user
+		}
+	})
+
+	return ok
+}
+
+// filter returns copies of the users keep accepts.
+func (ur *UserRepo) filter(keep func(user *entity.User) bool) []*entity.User {
+	users := make([]*entity.User, 0)
+	ur.store.read(func() {
+		for _, user := range ur.store.users {
+			if keep(&user) {
+				users = append(users, &user)
+			}
+		}
+	})
+
+	return users
+}
+
+// matches mirrors the WHERE clause of the Postgres repository; the
+// username match ignores case like ILIKE.
+func matches(user *entity.User, filter entity.UserFilter) bool {
+	if filter.Active != nil && user.Active != *filter.Active {
+		return false
+	}
+	if filter.Digest != "" && user.Digest != filter.Digest {
+		return false
+	}
+	if filter.Username != "" && !strings.Contains(strings.ToLower(user.Username), strings.ToLower(filter.Username)) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"tgBotFinal/internal/domain/service"
+	"time"
+
+	"tgBotFinal/internal/entity"
+
+	"github.com/lib/pq"
+)
+
+type APIKeyRepo struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewAPIKeyRepo(db *sql.DB, logger *slog.Logger) service.APIKeyRepository {
+	return &APIKeyRepo{db: db, logger: logger.With(slog.String("component", "APIKeyRepo"))}
+}
+
+const apiKeyColumns = `id, name, prefix, scopes, created_at, last_used_at, revoked_at`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*entity.APIKey, error) {
+	var (
+		key        entity.APIKey
+		scopes     pq.StringArray
+		lastUsedAt sql.NullTime
+		revokedAt  sql.NullTime
+	)
+
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &scopes, &key.CreatedAt, &lastUsedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, entity.Scope(scope))
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return &key, nil
+}
+
+func (ar *APIKeyRepo) Create(ctx context.Context, key *entity.APIKey, hash string) error {
+	ar.logger.DebugContext(ctx, "create api key", "name", key.Name)
+
+	scopes := make(pq.StringArray, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;
+`
+	err := conn(ctx, ar.db).QueryRowContext(ctx, query, key.Name, key.Prefix, hash, scopes).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		ar.logger.ErrorContext(ctx, "failed to create api key", "name", key.Name, "err", err)
+	}
+
+	return err
+}
+
+func (ar *APIKeyRepo) GetByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1;`
+
+	key, err := scanAPIKey(conn(ctx, ar.db).QueryRowContext(ctx, query, hash))
+	if err == sql.ErrNoRows {
+		return nil, service.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		ar.logger.ErrorContext(ctx, "failed to get api key", "err", err)
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (ar *APIKeyRepo) List(ctx context.Context) ([]*entity.APIKey, error) {
+	rows, err := conn(ctx, ar.db).QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id;`)
+	if err != nil {
+		ar.logger.ErrorContext(ctx, "failed to list api keys", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*entity.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			ar.logger.ErrorContext(ctx, "failed to list api keys", "err", err)
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (ar *APIKeyRepo) Revoke(ctx context.Context, id int64) error {
+	ar.logger.DebugContext(ctx, "revoke api key", "id", id)
+
+	query := `UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL;`
+
+	res, err := conn(ctx, ar.db).ExecContext(ctx, query, id)
+	if err != nil {
+		ar.logger.ErrorContext(ctx, "failed to revoke api key", "id", id, "err", err)
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func (ar *APIKeyRepo) TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error {
+	_, err := conn(ctx, ar.db).ExecContext(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1;`, id, usedAt)
+	return err
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+// candleBatch is the number of candles written per statement, well below
+// the limit of 65535 parameters.
+const candleBatch = 500
+
+type CandleRepo struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewCandleRepo(db *sql.DB, logger *slog.Logger) service.CandleRepository {
+	return &CandleRepo{db: db, logger: logger.With(slog.String("component", "CandleRepo"))}
+}
+
+func (cr *CandleRepo) Upsert(ctx context.Context, candles []*entity.Candle) error {
+	for len(candles) > 0 {
+		n := min(len(candles), candleBatch)
+		if err := cr.upsert(ctx, candles[:n]); err != nil {
+			cr.logger.ErrorContext(ctx, "failed to upsert candles", "symbol", candles[0].Symbol, "err", err)
+			return err
+		}
+		candles = candles[n:]
+	}
+
+	return nil
+}
+
+func (cr *CandleRepo) upsert(ctx context.Context, candles []*entity.Candle) error {
+	var (
+		values = make([]string, 0, len(candles))
+		args   = make([]any, 0, 8*len(candles))
+	)
+	for _, c := range candles {
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8))
+		args = append(args, c.Symbol, c.Interval, c.OpenTime, c.Open, c.High, c.Low, c.Close, c.Volume)
+	}
+
+	query := `
+		INSERT INTO candles (symbol, "interval", open_time, open, high, low, close, volume)
+		VALUES ` + strings.Join(values, ", ") + `
+		ON CONFLICT (symbol, "interval", open_time)
+		DO UPDATE SET open = EXCLUDED.open, high = EXCLUDED.high, low = EXCLUDED.low,
+			close = EXCLUDED.close, volume = EXCLUDED.volume
+		`
+
+	_, err := conn(ctx, cr.db).ExecContext(ctx, query, args...)
+	return err
+}
+
+func (cr *CandleRepo) GetRange(ctx context.Context, symbol entity.CurrencyName, interval entity.CandleInterval, from, to time.Time) ([]*entity.Candle, error) {
+	cr.logger.DebugContext(ctx, "Getting candles", "symbol", symbol, "interval", interval, "from", from, "to", to)
+
+	query := `
+		SELECT open_time, open, high, low, close, volume
+		FROM candles
+		WHERE symbol = $1 AND "interval" = $2 AND open_time >= $3 AND open_time < $4
+		ORDER BY open_time
+		`
+
+	rows, err := conn(ctx, cr.db).QueryContext(ctx, query, symbol, interval, from, to)
+	if err != nil {
+		cr.logger.ErrorContext(ctx, "failed to get candles", "symbol", symbol, "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candles []*entity.Candle
+	for rows.Next() {
+		candle := entity.Candle{Symbol: symbol, Interval: interval}
+		if err := rows.Scan(&candle.OpenTime, &candle.Open, &candle.High, &candle.Low, &candle.Close, &candle.Volume); err != nil {
+			cr.logger.ErrorContext(ctx, "failed to scan candle", "symbol", symbol, "err", err)
+			return nil, err
+		}
+		candle.OpenTime = candle.OpenTime.UTC()
+		candles = append(candles, &candle)
+	}
+
+	return candles, rows.Err()
+}
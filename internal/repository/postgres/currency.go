@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"log/slog"
 	"tgBotFinal/internal/domain/service"
 	"time"
@@ -21,77 +22,93 @@ func NewCurrencyRepo(db *sql.DB, logger *slog.Logger) service.CurrencyRepository
 }
 
 func (cr *CurrencyRepo) SaveOrUpdate(ctx context.Context, currency *entity.Price) error {
-	cr.logger.Debug("Saving currency", "symbol", currency.Symbol)
+	cr.logger.DebugContext(ctx, "Saving currency", "symbol", currency.Symbol)
 
 	query := `
-		INSERT INTO currencies (symbol, price, updated)
-		VALUES ($1, $2, $3)
+		INSERT INTO currencies (symbol, price, updated, change_24h, high_24h, low_24h, volume_24h, bid, ask)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT (symbol)
-		DO UPDATE SET price=$2, updated = $3
+		DO UPDATE SET price = EXCLUDED.price, updated = EXCLUDED.updated,
+			change_24h = EXCLUDED.change_24h, high_24h = EXCLUDED.high_24h, low_24h = EXCLUDED.low_24h,
+			volume_24h = EXCLUDED.volume_24h, bid = EXCLUDED.bid, ask = EXCLUDED.ask
 		`
 
-	_, err := cr.db.ExecContext(ctx, query, currency.Symbol, currency.Price, time.Now().Format("2006-01-02 15:04:05"))
+	t := currency.Ticker
+	_, err := conn(ctx, cr.db).ExecContext(ctx, query, currency.Symbol, currency.Price, time.Now(),
+		t.Change24h, t.High24h, t.Low24h, t.Volume24h, t.Bid, t.Ask)
 	if err != nil {
-		cr.logger.Error("failed to save currency", "symbol", currency.Symbol, "err", err)
+		cr.logger.ErrorContext(ctx, "failed to save currency", "symbol", currency.Symbol, "err", err)
 	} else {
-		cr.logger.Debug("saved currency successfully", "symbol", currency.Symbol)
+		cr.logger.DebugContext(ctx, "saved currency successfully", "symbol", currency.Symbol)
 	}
 
 	return err
 }
 
 func (cr *CurrencyRepo) GetBySymbol(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error) {
-	cr.logger.Debug("Getting currency by symbol", "symbol", symbol)
+	cr.logger.DebugContext(ctx, "Getting currency by symbol", "symbol", symbol)
 
-	query := `
-		SELECT symbol, price, updated FROM currencies
-		WHERE symbol=$1;
-`
-
-	var currency entity.Price
-	err := cr.db.QueryRowContext(ctx, query, symbol).Scan(
-		&currency.Symbol, &currency.Price, &currency.Updated)
+	query := `SELECT ` + currencyColumns + ` FROM currencies WHERE symbol = $1;`
 
-	if err == sql.ErrNoRows {
-		cr.logger.Debug("no currency by symbol", "symbol", symbol)
-		return nil, nil
+	currency, err := scanCurrency(conn(ctx, cr.db).QueryRowContext(ctx, query, symbol))
+	if errors.Is(err, sql.ErrNoRows) {
+		cr.logger.DebugContext(ctx, "no currency by symbol", "symbol", symbol)
+		return nil, service.ErrCurrencyNotFound
 	}
 
 	if err != nil {
-		cr.logger.Error("failed to get currency by symbol", "symbol", symbol, "err", err)
-	} else {
-		cr.logger.Debug("got currency by symbol", "symbol", symbol)
+		cr.logger.ErrorContext(ctx, "failed to get currency by symbol", "symbol", symbol, "err", err)
+		return nil, err
 	}
 
-	return &currency, nil
-
+	cr.logger.DebugContext(ctx, "got currency by symbol", "symbol", symbol)
+	return currency, nil
 }
 
 func (cr *CurrencyRepo) GetAll(ctx context.Context) ([]*entity.Price, error) {
-	cr.logger.Debug("Getting all currencies")
+	cr.logger.DebugContext(ctx, "Getting all currencies")
 
-	query := `
-		SELECT symbol, price, updated FROM currencies
-		`
+	query := `SELECT ` + currencyColumns + ` FROM currencies ORDER BY symbol;`
 
-	rows, err := cr.db.QueryContext(ctx, query)
+	rows, err := conn(ctx, cr.db).QueryContext(ctx, query)
 	if err != nil {
-		cr.logger.Error("failed to get all currencies", "err", err)
+		cr.logger.ErrorContext(ctx, "failed to get all currencies", "err", err)
 		return nil, err
 	}
 	defer rows.Close()
 
 	var currencies []*entity.Price
 	for rows.Next() {
-		var currency entity.Price
-		if err := rows.Scan(&currency.Symbol, &currency.Price, &currency.Updated); err != nil {
-			cr.logger.Error("failed to get all currencies", "err", err)
+		currency, err := scanCurrency(rows)
+		if err != nil {
+			cr.logger.ErrorContext(ctx, "failed to get all currencies", "err", err)
 			return nil, err
 		}
 
-		currencies = append(currencies, &currency)
+		currencies = append(currencies, currency)
+	}
+
+	cr.logger.DebugContext(ctx, "got all currencies")
+	return currencies, rows.Err()
+}
+
+const currencyColumns = `symbol, price, updated, change_24h, high_24h, low_24h, volume_24h, bid, ask`
+
+func scanCurrency(row interface{ Scan(...any) error }) (*entity.Price, error) {
+	var (
+		currency entity.Price
+		updated  sql.NullTime
+	)
+
+	t := &currency.Ticker
+	if err := row.Scan(&currency.Symbol, &currency.Price, &updated,
+		&t.Change24h, &t.High24h, &t.Low24h, &t.Volume24h, &t.Bid, &t.Ask); err != nil {
+		return nil, err
+	}
+
+	if updated.Valid {
+		currency.Updated = updated.Time.Format(time.RFC3339)
 	}
 
-	cr.logger.Debug("got all currencies")
-	return currencies, nil
+	return &currency, nil
 }
@@ -0,0 +1,111 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/infrastructure/database"
+
+	_ "github.com/lib/pq"
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// The integration tests run against a Postgres started in Docker:
+//
+//	go test -tags integration ./internal/repository/postgres/
+//
+// Set TEST_DATABASE_URL to use an existing, disposable database instead.
+
+var (
+	startOnce sync.Once
+	sharedDB  *sql.DB
+	startErr  error
+	container *tcpostgres.PostgresContainer
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+
+	if sharedDB != nil {
+		sharedDB.Close()
+	}
+	if container != nil {
+		_ = testcontainers.TerminateContainer(container)
+	}
+
+	os.Exit(code)
+}
+
+// testDB returns a migrated database with empty tables. The database is
+// started once and shared by every test of the package.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	if os.Getenv("TEST_DATABASE_URL") == "" {
+		testcontainers.SkipIfProviderIsNotHealthy(t)
+	}
+
+	startOnce.Do(func() {
+		sharedDB, startErr = startDB()
+	})
+	if startErr != nil {
+		t.Skipf("PostgreSQL not available: %v", startErr)
+	}
+
+	_, err := sharedDB.Exec(`TRUNCATE users, currencies, price_history, api_keys RESTART IDENTITY;`)
+	if err != nil {
+		t.Fatalf("truncate tables: %v", err)
+	}
+
+	return sharedDB
+}
+
+func startDB() (*sql.DB, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		var err error
+		container, err = tcpostgres.Run(ctx, "postgres:16-alpine",
+			tcpostgres.WithDatabase("crypto_bot"),
+			tcpostgres.WithUsername("test"),
+			tcpostgres.WithPassword("test"),
+			testcontainers.WithWaitStrategy(wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).WithStartupTimeout(time.Minute)),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		dsn, err = container.ConnectionString(ctx, "sslmode=disable")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := database.NewMigrator(db, database.Postgres, slog.Default()).Up("../../../migrations"); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
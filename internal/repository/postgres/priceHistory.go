@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"tgBotFinal/internal/domain/service"
+	"time"
+
+	"tgBotFinal/internal/entity"
+)
+
+type PriceHistoryRepo struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewPriceHistoryRepo(db *sql.DB, logger *slog.Logger) service.PriceHistoryRepository {
+	return &PriceHistoryRepo{db: db, logger: logger.With(slog.String("component", "PriceHistoryRepo"))}
+}
+
+func (pr *PriceHistoryRepo) Append(ctx context.Context, price *entity.Price, recordedAt time.Time) error {
+	query := `
+		INSERT INTO price_history (symbol, price, recorded_at)
+		VALUES ($1, $2, $3)
+		`
+
+	_, err := conn(ctx, pr.db).ExecContext(ctx, query, price.Symbol, price.Price, recordedAt)
+	if err != nil {
+		pr.logger.ErrorContext(ctx, "failed to append price history", "symbol", price.Symbol, "err", err)
+	}
+
+	return err
+}
+
+func (pr *PriceHistoryRepo) Prune(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM price_history WHERE recorded_at < $1`
+
+	result, err := conn(ctx, pr.db).ExecContext(ctx, query, before)
+	if err != nil {
+		pr.logger.ErrorContext(ctx, "failed to prune price history", "before", before, "err", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (pr *PriceHistoryRepo) GetOHLC(ctx context.Context, symbol entity.CurrencyName, from, to time.Time) (*entity.OHLC, error) {
+	pr.logger.DebugContext(ctx, "Getting OHLC", "symbol", symbol, "from", from, "to", to)
+
+	query := `
+		SELECT
+			(array_agg(price ORDER BY recorded_at ASC))[1],
+			MAX(price),
+			MIN(price),
+			(array_agg(price ORDER BY recorded_at DESC))[1],
+			COUNT(*)
+		FROM price_history
+		WHERE symbol = $1 AND recorded_at >= $2 AND recorded_at < $3
+		`
+
+	var (
+		open, high, low, closePrice sql.NullFloat64
+		ohlc                        = entity.OHLC{Symbol: symbol}
+	)
+
+	err := conn(ctx, pr.db).QueryRowContext(ctx, query, symbol, from, to).Scan(&open, &high, &low, &closePrice, &ohlc.Samples)
+	if err != nil {
+		pr.logger.ErrorContext(ctx, "failed to get OHLC", "symbol", symbol, "err", err)
+		return nil, err
+	}
+
+	if ohlc.Samples == 0 {
+		pr.logger.DebugContext(ctx, "no price history for period", "symbol", symbol)
+		return nil, service.ErrNoPriceHistory
+	}
+
+	ohlc.Open = open.Float64
+	ohlc.High = high.Float64
+	ohlc.Low = low.Float64
+	ohlc.Close = closePrice.Float64
+
+	return &ohlc, nil
+}
@@ -0,0 +1,24 @@
+//go:build integration
+
+package postgres
+
+import (
+	"log/slog"
+	"testing"
+
+	"tgBotFinal/internal/repository/repotest"
+)
+
+func TestRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		db := testDB(t)
+		return repotest.Repositories{
+			Users:        NewUserRepo(db, slog.Default()),
+			Currencies:   NewCurrencyRepo(db, slog.Default()),
+			PriceHistory: NewPriceHistoryRepo(db, slog.Default()),
+			APIKeys:      NewAPIKeyRepo(db, slog.Default()),
+			Candles:      NewCandleRepo(db, slog.Default()),
+			UnitOfWork:   NewUnitOfWork(db, slog.Default()),
+		}
+	})
+}
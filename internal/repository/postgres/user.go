@@ -3,8 +3,12 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
 	"tgBotFinal/internal/domain/service"
+	"time"
 
 	"tgBotFinal/internal/entity"
 )
@@ -21,95 +25,351 @@ func NewUserRepo(db *sql.DB, logger *slog.Logger) service.UserRepository {
 	}
 }
 
+// SaveOrUpdate creates the user or updates its username and active flag,
+// then fills user with the stored row.
 func (ur *UserRepo) SaveOrUpdate(ctx context.Context, user *entity.User) error {
-	ur.logger.Debug("save user", "user", user.ChatID)
+	ur.logger.DebugContext(ctx, "save user", "user", user.ChatID)
 
 	query := `
-		INSERT INTO users VALUES ($1, $2, $3)
-		ON CONFLICT(ChatID)
-		DO UPDATE SET username = $2, active = $3;
+		INSERT INTO users (chat_id, username, active)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (chat_id)
+		DO UPDATE SET username = EXCLUDED.username, active = EXCLUDED.active, updated_at = CURRENT_TIMESTAMP
+		RETURNING ` + userColumns + `;
 `
-	_, err := ur.db.ExecContext(ctx, query, user.ChatID, user.Username, user.Active)
+	saved, err := scanUser(conn(ctx, ur.db).QueryRowContext(ctx, query, user.ChatID, user.Username, user.Active))
 	if err != nil {
-		ur.logger.Error("error save user", "err", err)
-	} else {
-		ur.logger.Info("save user", "user", user.ChatID)
+		ur.logger.ErrorContext(ctx, "error save user", "err", err)
+		return err
 	}
 
-	return err
+	*user = *saved
+	ur.logger.InfoContext(ctx, "save user", "user", user.ChatID)
+	return nil
 }
 
 func (ur *UserRepo) GetByChatID(ctx context.Context, chatID int64) (*entity.User, error) {
-	ur.logger.Debug("get user by chatID", "chatID", chatID)
+	ur.logger.DebugContext(ctx, "get user by chat id", "chat_id", chatID)
 
-	query := `SELECT * FROM users WHERE chat_id = $1;`
+	query := `SELECT ` + userColumns + ` FROM users WHERE chat_id = $1;`
 
-	var user entity.User
-	err := ur.db.QueryRowContext(ctx, query, chatID).Scan(
-		&user.ChatID, &user.Username, &user.Active)
-	if err == sql.ErrNoRows {
-		ur.logger.Debug("no user found", "err", err)
-		return nil, nil
+	user, err := scanUser(conn(ctx, ur.db).QueryRowContext(ctx, query, chatID))
+	if errors.Is(err, sql.ErrNoRows) {
+		ur.logger.DebugContext(ctx, "no user found", "chat_id", chatID)
+		return nil, service.ErrUserNotFound
 	}
 
 	if err != nil {
-		ur.logger.Error("error getting user", "err", err)
-	} else {
-		ur.logger.Debug("got user", "user", user.ChatID)
+		ur.logger.ErrorContext(ctx, "error getting user", "err", err)
+		return nil, err
 	}
 
-	return &user, err
+	ur.logger.DebugContext(ctx, "got user", "user", user.ChatID)
+	return user, nil
 }
 
 func (ur *UserRepo) GetAll(ctx context.Context) ([]*entity.User, error) {
-	ur.logger.Debug("get all users")
+	ur.logger.DebugContext(ctx, "get all users")
+
+	users, err := ur.List(ctx, entity.UserFilter{})
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error getting all users", "err", err)
+		return nil, err
+	}
+
+	ur.logger.DebugContext(ctx, "got all users")
+	return users, nil
+}
+
+func (ur *UserRepo) GetAllActive(ctx context.Context) ([]*entity.User, error) {
+	ur.logger.DebugContext(ctx, "get all active users")
+
+	active := true
+	users, err := ur.List(ctx, entity.UserFilter{Active: &active})
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error getting all active users", "err", err)
+		return nil, err
+	}
+
+	ur.logger.DebugContext(ctx, "got all active users")
+	return users, nil
+}
+
+func (ur *UserRepo) SetDigest(ctx context.Context, chatID int64, period entity.DigestPeriod) error {
+	ur.logger.DebugContext(ctx, "set digest", "chat_id", chatID, "digest", period)
+
+	query := `UPDATE users SET digest = $2, updated_at = CURRENT_TIMESTAMP WHERE chat_id = $1;`
+
+	res, err := conn(ctx, ur.db).ExecContext(ctx, query, chatID, period)
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error set digest", "chat_id", chatID, "err", err)
+		return err
+	}
+
+	return requireAffected(res)
+}
+
+func (ur *UserRepo) SetTimezone(ctx context.Context, chatID int64, timezone string) error {
+	ur.logger.DebugContext(ctx, "set timezone", "chat_id", chatID, "timezone", timezone)
+
+	query := `UPDATE users SET timezone = $2, updated_at = CURRENT_TIMESTAMP WHERE chat_id = $1;`
+
+	res, err := conn(ctx, ur.db).ExecContext(ctx, query, chatID, timezone)
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error set timezone", "chat_id", chatID, "err", err)
+		return err
+	}
+
+	return requireAffected(res)
+}
+
+func (ur *UserRepo) GetDigestSubscribers(ctx context.Context) ([]*entity.User, error) {
+	ur.logger.DebugContext(ctx, "get digest subscribers")
 
-	query := `SELECT * FROM users;`
+	query := `SELECT ` + userColumns + ` FROM users WHERE active = true AND digest <> 'off';`
+
+	rows, err := conn(ctx, ur.db).QueryContext(ctx, query)
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error getting digest subscribers", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
 
 	var users []*entity.User
-	rows, err := ur.db.QueryContext(ctx, query)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			ur.logger.ErrorContext(ctx, "error getting digest subscribers", "err", err)
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	ur.logger.DebugContext(ctx, "got digest subscribers", "count", len(users))
+	return users, rows.Err()
+}
+
+func (ur *UserRepo) MarkDigestSent(ctx context.Context, chatID int64, sentAt time.Time) error {
+	ur.logger.DebugContext(ctx, "mark digest sent", "chat_id", chatID)
+
+	query := `UPDATE users SET last_digest_at = $2 WHERE chat_id = $1;`
+
+	res, err := conn(ctx, ur.db).ExecContext(ctx, query, chatID, sentAt)
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error mark digest sent", "chat_id", chatID, "err", err)
+		return err
+	}
+
+	return requireAffected(res)
+}
+
+const userColumns = `chat_id, username, active, digest, timezone, last_digest_at, banned_until, created_at, updated_at`
+
+func scanUser(row interface{ Scan(...any) error }) (*entity.User, error) {
+	var (
+		user         entity.User
+		username     sql.NullString
+		lastDigestAt sql.NullTime
+		bannedUntil  sql.NullTime
+	)
+
+	if err := row.Scan(&user.ChatID, &username, &user.Active, &user.Digest, &user.Timezone,
+		&lastDigestAt, &bannedUntil, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	user.Username = username.String
+	if lastDigestAt.Valid {
+		user.LastDigestAt = &lastDigestAt.Time
+	}
+	if bannedUntil.Valid {
+		user.BannedUntil = &bannedUntil.Time
+	}
+
+	return &user, nil
+}
+
+// userFilterClause builds a WHERE clause and its arguments from filter.
+func userFilterClause(filter entity.UserFilter) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+
+	if filter.Active != nil {
+		args = append(args, *filter.Active)
+		conds = append(conds, fmt.Sprintf("active = $%d", len(args)))
+	}
+
+	if filter.Digest != "" {
+		args = append(args, filter.Digest)
+		conds = append(conds, fmt.Sprintf("digest = $%d", len(args)))
+	}
+
+	if filter.Username != "" {
+		args = append(args, "%"+escapeLike(filter.Username)+"%")
+		conds = append(conds, fmt.Sprintf(`username ILIKE $%d ESCAPE '\'`, len(args)))
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func (ur *UserRepo) List(ctx context.Context, filter entity.UserFilter) ([]*entity.User, error) {
+	ur.logger.DebugContext(ctx, "list users", "filter", filter)
+
+	where, args := userFilterClause(filter)
+	query := `SELECT ` + userColumns + ` FROM users` + where + ` ORDER BY created_at DESC, chat_id`
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := conn(ctx, ur.db).QueryContext(ctx, query, args...)
 	if err != nil {
-		ur.logger.Error("error getting all users", "err", err)
+		ur.logger.ErrorContext(ctx, "error listing users", "err", err)
 		return nil, err
 	}
 	defer rows.Close()
 
+	users := make([]*entity.User, 0)
 	for rows.Next() {
-		var user entity.User
-		if err := rows.Scan(&user.ChatID, &user.Username, &user.Active); err != nil {
-			ur.logger.Error("error getting all users", "err", err)
+		user, err := scanUser(rows)
+		if err != nil {
+			ur.logger.ErrorContext(ctx, "error listing users", "err", err)
 			return nil, err
 		}
 
-		users = append(users, &user)
+		users = append(users, user)
 	}
 
-	ur.logger.Debug("got all users")
-	return users, err
+	return users, rows.Err()
 }
 
-func (ur *UserRepo) GetAllActive(ctx context.Context) ([]*entity.User, error) {
-	ur.logger.Debug("get all active users")
-	query := `SELECT * FROM users WHERE active=true;`
+func (ur *UserRepo) Count(ctx context.Context, filter entity.UserFilter) (int, error) {
+	ur.logger.DebugContext(ctx, "count users", "filter", filter)
 
-	var users []*entity.User
-	rows, err := ur.db.QueryContext(ctx, query)
+	where, args := userFilterClause(filter)
+
+	var count int
+	if err := conn(ctx, ur.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM users`+where, args...).Scan(&count); err != nil {
+		ur.logger.ErrorContext(ctx, "error counting users", "err", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (ur *UserRepo) SetActive(ctx context.Context, chatID int64, active bool) error {
+	ur.logger.DebugContext(ctx, "set user active", "chat_id", chatID, "active", active)
+
+	query := `UPDATE users SET active = $2, updated_at = CURRENT_TIMESTAMP WHERE chat_id = $1;`
+
+	res, err := conn(ctx, ur.db).ExecContext(ctx, query, chatID, active)
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error set user active", "chat_id", chatID, "err", err)
+		return err
+	}
+
+	return requireAffected(res)
+}
+
+func (ur *UserRepo) SetBannedUntil(ctx context.Context, chatID int64, until *time.Time) error {
+	ur.logger.DebugContext(ctx, "set user banned until", "chat_id", chatID, "until", until)
+
+	query := `UPDATE users SET banned_until = $2, updated_at = CURRENT_TIMESTAMP WHERE chat_id = $1;`
+
+	res, err := conn(ctx, ur.db).ExecContext(ctx, query, chatID, until)
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error set user banned until", "chat_id", chatID, "err", err)
+		return err
+	}
+
+	return requireAffected(res)
+}
+
+func (ur *UserRepo) Delete(ctx context.Context, chatID int64) error {
+	ur.logger.DebugContext(ctx, "delete user", "chat_id", chatID)
+
+	res, err := conn(ctx, ur.db).ExecContext(ctx, `DELETE FROM users WHERE chat_id = $1;`, chatID)
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error delete user", "chat_id", chatID, "err", err)
+		return err
+	}
+
+	if err := requireAffected(res); err != nil {
+		return err
+	}
+
+	ur.logger.InfoContext(ctx, "deleted user", "chat_id", chatID)
+	return nil
+}
+
+func (ur *UserRepo) GrowthStats(ctx context.Context, from, to time.Time, bucket entity.GrowthBucket) ([]*entity.UserGrowthPoint, error) {
+	ur.logger.DebugContext(ctx, "user growth stats", "from", from, "to", to, "bucket", bucket)
+
+	query := `
+		WITH buckets AS (
+			SELECT generate_series(date_trunc($3, $1::timestamptz), $2::timestamptz, ('1 ' || $3)::interval) AS period_start
+		)
+		SELECT
+			b.period_start,
+			COUNT(u.chat_id),
+			(SELECT COUNT(*) FROM users WHERE created_at < b.period_start + ('1 ' || $3)::interval)
+		FROM buckets b
+		LEFT JOIN users u ON u.created_at >= b.period_start AND u.created_at < b.period_start + ('1 ' || $3)::interval
+		GROUP BY b.period_start
+		ORDER BY b.period_start;
+`
+
+	rows, err := conn(ctx, ur.db).QueryContext(ctx, query, from, to, string(bucket))
 	if err != nil {
-		ur.logger.Error("error getting all active users", "err", err)
+		ur.logger.ErrorContext(ctx, "error getting user growth stats", "err", err)
 		return nil, err
 	}
 	defer rows.Close()
 
+	points := make([]*entity.UserGrowthPoint, 0)
 	for rows.Next() {
-		var user entity.User
-		if err := rows.Scan(&user.ChatID, &user.Username, &user.Active); err != nil {
-			ur.logger.Error("error getting all active users", "err", err)
+		var point entity.UserGrowthPoint
+		if err := rows.Scan(&point.PeriodStart, &point.NewUsers, &point.TotalUsers); err != nil {
+			ur.logger.ErrorContext(ctx, "error getting user growth stats", "err", err)
 			return nil, err
 		}
 
-		users = append(users, &user)
+		points = append(points, &point)
 	}
 
-	ur.logger.Debug("got all active users")
-	return users, err
+	return points, rows.Err()
+}
+
+func requireAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return service.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// likeEscaper makes the wildcards of a LIKE pattern match literally, with
+// backslash as the ESCAPE character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
 }
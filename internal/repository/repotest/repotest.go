@@ -0,0 +1,514 @@
+// Package repotest is the conformance suite every storage backend runs, so
+// the service behaves the same whichever DATABASE_URL it is given.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+// Repositories are the repositories of one backend over one database.
+type Repositories struct {
+	Users        service.UserRepository
+	Currencies   service.CurrencyRepository
+	PriceHistory service.PriceHistoryRepository
+	APIKeys      service.APIKeyRepository
+	Candles      service.CandleRepository
+	UnitOfWork   service.UnitOfWork
+}
+
+// Run runs the suite. open is called by every subtest and must return
+// repositories over an empty, migrated database.
+func Run(t *testing.T, open func(t *testing.T) Repositories) {
+	t.Run("UserSaveOrUpdate", func(t *testing.T) { testUserSaveOrUpdate(t, open(t)) })
+	t.Run("UserNotFound", func(t *testing.T) { testUserNotFound(t, open(t)) })
+	t.Run("UserBan", func(t *testing.T) { testUserBan(t, open(t)) })
+	t.Run("UserListAndCount", func(t *testing.T) { testUserListAndCount(t, open(t)) })
+	t.Run("UserDigest", func(t *testing.T) { testUserDigest(t, open(t)) })
+	t.Run("UserGrowthStats", func(t *testing.T) { testUserGrowthStats(t, open(t)) })
+	t.Run("Currency", func(t *testing.T) { testCurrency(t, open(t)) })
+	t.Run("PriceHistory", func(t *testing.T) { testPriceHistory(t, open(t)) })
+	t.Run("Candles", func(t *testing.T) { testCandles(t, open(t)) })
+	t.Run("APIKey", func(t *testing.T) { testAPIKey(t, open(t)) })
+	t.Run("UnitOfWork", func(t *testing.T) { testUnitOfWork(t, open(t)) })
+}
+
+func testUserSaveOrUpdate(t *testing.T, repos Repositories) {
+	repo := repos.Users
+	ctx := context.Background()
+
+	user := &entity.User{ChatID: 42, Username: "alice", Active: true}
+	if err := repo.SaveOrUpdate(ctx, user); err != nil {
+		t.Fatalf("SaveOrUpdate (insert) failed: %v", err)
+	}
+	if user.CreatedAt.IsZero() || user.Digest != entity.DigestOff || user.Timezone != "UTC" {
+		t.Errorf("Saved user was not filled from the row: %+v", user)
+	}
+	created := user.CreatedAt
+
+	if err := repo.SetDigest(ctx, 42, entity.DigestDaily); err != nil {
+		t.Fatalf("SetDigest failed: %v", err)
+	}
+
+	updated := &entity.User{ChatID: 42, Username: "alice2", Active: false}
+	if err := repo.SaveOrUpdate(ctx, updated); err != nil {
+		t.Fatalf("SaveOrUpdate (update) failed: %v", err)
+	}
+
+	got, err := repo.GetByChatID(ctx, 42)
+	if err != nil {
+		t.Fatalf("GetByChatID failed: %v", err)
+	}
+	if got.Username != "alice2" || got.Active {
+		t.Errorf("User = %+v, want username alice2, inactive", got)
+	}
+	if got.Digest != entity.DigestDaily {
+		t.Errorf("Digest = %v, upsert must keep the other columns", got.Digest)
+	}
+	if !got.CreatedAt.Equal(created) {
+		t.Errorf("CreatedAt changed from %v to %v", created, got.CreatedAt)
+	}
+}
+
+func testUserNotFound(t *testing.T, repos Repositories) {
+	repo := repos.Users
+	ctx := context.Background()
+
+	if _, err := repo.GetByChatID(ctx, 7); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("GetByChatID error = %v, want %v", err, service.ErrUserNotFound)
+	}
+	if err := repo.SetActive(ctx, 7, false); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("SetActive error = %v, want %v", err, service.ErrUserNotFound)
+	}
+	if err := repo.SetBannedUntil(ctx, 7, nil); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("SetBannedUntil error = %v, want %v", err, service.ErrUserNotFound)
+	}
+	if err := repo.SetDigest(ctx, 7, entity.DigestDaily); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("SetDigest error = %v, want %v", err, service.ErrUserNotFound)
+	}
+	if err := repo.SetTimezone(ctx, 7, "Europe/Moscow"); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("SetTimezone error = %v, want %v", err, service.ErrUserNotFound)
+	}
+	if err := repo.MarkDigestSent(ctx, 7, time.Now()); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("MarkDigestSent error = %v, want %v", err, service.ErrUserNotFound)
+	}
+	if err := repo.Delete(ctx, 7); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("Delete error = %v, want %v", err, service.ErrUserNotFound)
+	}
+}
+
+func testUserBan(t *testing.T, repos Repositories) {
+	repo := repos.Users
+	ctx := context.Background()
+
+	if err := repo.SaveOrUpdate(ctx, &entity.User{ChatID: 1, Active: true}); err != nil {
+		t.Fatalf("SaveOrUpdate failed: %v", err)
+	}
+
+	until := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+	if err := repo.SetBannedUntil(ctx, 1, &until); err != nil {
+		t.Fatalf("SetBannedUntil failed: %v", err)
+	}
+	got, _ := repo.GetByChatID(ctx, 1)
+	if got.BannedUntil == nil || !got.BannedUntil.Equal(until) {
+		t.Errorf("BannedUntil = %v, want %v", got.BannedUntil, until)
+	}
+
+	if err := repo.SetBannedUntil(ctx, 1, nil); err != nil {
+		t.Fatalf("SetBannedUntil(nil) failed: %v", err)
+	}
+	got, _ = repo.GetByChatID(ctx, 1)
+	if got.BannedUntil != nil {
+		t.Errorf("BannedUntil = %v after unban", got.BannedUntil)
+	}
+}
+
+func testUserListAndCount(t *testing.T, repos Repositories) {
+	repo := repos.Users
+	ctx := context.Background()
+
+	for _, user := range []*entity.User{
+		{ChatID: 1, Username: "bob", Active: true},
+		{ChatID: 2, Username: "Bobby", Active: false},
+		{ChatID: 3, Username: "carol", Active: true},
+	} {
+		if err := repo.SaveOrUpdate(ctx, user); err != nil {
+			t.Fatalf("SaveOrUpdate failed: %v", err)
+		}
+	}
+
+	active := true
+	users, err := repo.List(ctx, entity.UserFilter{Active: &active})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(users) != 2 {
+		t.Errorf("Active users = %d, want 2", len(users))
+	}
+
+	count, err := repo.Count(ctx, entity.UserFilter{Username: "bob"})
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Users named like bob = %d, want 2 ignoring case", count)
+	}
+
+	page, err := repo.List(ctx, entity.UserFilter{Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(page) != 1 {
+		t.Errorf("Page size = %d, want 1", len(page))
+	}
+
+	rest, err := repo.List(ctx, entity.UserFilter{Offset: 1})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(rest) != 2 {
+		t.Errorf("Users after offset 1 = %d, want 2", len(rest))
+	}
+
+	// Wildcards in the filter match themselves.
+	for _, user := range []*entity.User{
+		{ChatID: 4, Username: "a_b%c", Active: true},
+		{ChatID: 5, Username: "axbyc", Active: true},
+	} {
+		if err := repo.SaveOrUpdate(ctx, user); err != nil {
+			t.Fatalf("SaveOrUpdate failed: %v", err)
+		}
+	}
+	for filter, want := range map[string]int{"a_b": 1, "b%c": 1, "_": 1, "%": 1, `\`: 0} {
+		count, err := repo.Count(ctx, entity.UserFilter{Username: filter})
+		if err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+		if count != want {
+			t.Errorf("Users named like %q = %d, want %d", filter, count, want)
+		}
+	}
+}
+
+func testUserDigest(t *testing.T, repos Repositories) {
+	repo := repos.Users
+	ctx := context.Background()
+
+	for _, user := range []*entity.User{
+		{ChatID: 1, Active: true},
+		{ChatID: 2, Active: true},
+		{ChatID: 3, Active: false},
+	} {
+		if err := repo.SaveOrUpdate(ctx, user); err != nil {
+			t.Fatalf("SaveOrUpdate failed: %v", err)
+		}
+	}
+	for _, chatID := range []int64{1, 3} {
+		if err := repo.SetDigest(ctx, chatID, entity.DigestDaily); err != nil {
+			t.Fatalf("SetDigest failed: %v", err)
+		}
+	}
+	if err := repo.SetTimezone(ctx, 1, "Europe/Berlin"); err != nil {
+		t.Fatalf("SetTimezone failed: %v", err)
+	}
+
+	subscribers, err := repo.GetDigestSubscribers(ctx)
+	if err != nil {
+		t.Fatalf("GetDigestSubscribers failed: %v", err)
+	}
+	if len(subscribers) != 1 || subscribers[0].ChatID != 1 || subscribers[0].Timezone != "Europe/Berlin" {
+		t.Fatalf("Subscribers = %+v, want the active chat 1 in Europe/Berlin", subscribers)
+	}
+
+	sentAt := time.Now().Truncate(time.Microsecond)
+	if err := repo.MarkDigestSent(ctx, 1, sentAt); err != nil {
+		t.Fatalf("MarkDigestSent failed: %v", err)
+	}
+	got, _ := repo.GetByChatID(ctx, 1)
+	if got.LastDigestAt == nil || !got.LastDigestAt.Equal(sentAt) {
+		t.Errorf("LastDigestAt = %v, want %v", got.LastDigestAt, sentAt)
+	}
+}
+
+func testUserGrowthStats(t *testing.T, repos Repositories) {
+	repo := repos.Users
+	ctx := context.Background()
+
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		if err := repo.SaveOrUpdate(ctx, &entity.User{ChatID: chatID, Active: true}); err != nil {
+			t.Fatalf("SaveOrUpdate failed: %v", err)
+		}
+	}
+
+	now := time.Now()
+	points, err := repo.GrowthStats(ctx, now.Add(-48*time.Hour), now, entity.GrowthDay)
+	if err != nil {
+		t.Fatalf("GrowthStats failed: %v", err)
+	}
+	if len(points) != 3 {
+		t.Fatalf("Points = %d, want one per day over three days", len(points))
+	}
+	if points[0].NewUsers != 0 || points[0].TotalUsers != 0 {
+		t.Errorf("First day = %+v, want no users", points[0])
+	}
+	if last := points[2]; last.NewUsers != 3 || last.TotalUsers != 3 {
+		t.Errorf("Today = %+v, want 3 new of 3 users", last)
+	}
+}
+
+func testCurrency(t *testing.T, repos Repositories) {
+	repo := repos.Currencies
+	ctx := context.Background()
+
+	if _, err := repo.GetBySymbol(ctx, entity.BTC); !errors.Is(err, service.ErrCurrencyNotFound) {
+		t.Errorf("GetBySymbol error = %v, want %v", err, service.ErrCurrencyNotFound)
+	}
+
+	for _, price := range []string{"50000", "51000"} {
+		if err := repo.SaveOrUpdate(ctx, &entity.Price{Symbol: entity.BTC, Price: price}); err != nil {
+			t.Fatalf("SaveOrUpdate failed: %v", err)
+		}
+	}
+	ticker := entity.Ticker{Change24h: "-0.0125", High24h: "3100", Low24h: "2950.5",
+		Volume24h: "1500.25", Bid: "2999.9", Ask: "3000.1"}
+	if err := repo.SaveOrUpdate(ctx, &entity.Price{Symbol: entity.ETH, Price: "3000", Ticker: ticker}); err != nil {
+		t.Fatalf("SaveOrUpdate failed: %v", err)
+	}
+
+	btc, err := repo.GetBySymbol(ctx, entity.BTC)
+	if err != nil {
+		t.Fatalf("GetBySymbol failed: %v", err)
+	}
+	if btc.Price != "51000" || btc.Updated == "" {
+		t.Errorf("BTC = %+v, want the latest price with a timestamp", btc)
+	}
+
+	all, err := repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if len(all) != 2 || all[0].Symbol != entity.BTC || all[1].Symbol != entity.ETH {
+		t.Fatalf("GetAll = %+v, want BTC and ETH", all)
+	}
+	if all[0].Ticker != (entity.Ticker{}) || all[1].Ticker != ticker {
+		t.Errorf("Tickers = %+v and %+v, want none for BTC and the saved one for ETH", all[0].Ticker, all[1].Ticker)
+	}
+}
+
+func testPriceHistory(t *testing.T, repos Repositories) {
+	repo := repos.PriceHistory
+	ctx := context.Background()
+	start := time.Date(2025, 11, 10, 0, 0, 0, 0, time.UTC)
+
+	_, err := repo.GetOHLC(ctx, entity.BTC, start, start.Add(time.Hour))
+	if !errors.Is(err, service.ErrNoPriceHistory) {
+		t.Errorf("GetOHLC error = %v, want %v", err, service.ErrNoPriceHistory)
+	}
+
+	for i, price := range []string{"100", "120", "90", "110"} {
+		err := repo.Append(ctx, &entity.Price{Symbol: entity.BTC, Price: price}, start.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	if err := repo.Append(ctx, &entity.Price{Symbol: entity.BTC, Price: "500"}, start.Add(time.Hour)); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	ohlc, err := repo.GetOHLC(ctx, entity.BTC, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetOHLC failed: %v", err)
+	}
+	want := entity.OHLC{Symbol: entity.BTC, Open: 100, High: 120, Low: 90, Close: 110, Samples: 4}
+	if *ohlc != want {
+		t.Errorf("OHLC = %+v, want %+v", *ohlc, want)
+	}
+
+	pruned, err := repo.Prune(ctx, start.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if pruned != 2 {
+		t.Errorf("Pruned %d prices, want 2", pruned)
+	}
+	ohlc, err = repo.GetOHLC(ctx, entity.BTC, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetOHLC after Prune failed: %v", err)
+	}
+	want = entity.OHLC{Symbol: entity.BTC, Open: 90, High: 110, Low: 90, Close: 110, Samples: 2}
+	if *ohlc != want {
+		t.Errorf("OHLC after Prune = %+v, want %+v", *ohlc, want)
+	}
+}
+
+func testCandles(t *testing.T, repos Repositories) {
+	repo := repos.Candles
+	ctx := context.Background()
+	start := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	// Digits are appended to price to tell the fields apart. The values
+	// have trailing zeros and more digits than a float64 holds, and must
+	// come back unchanged.
+	candle := func(symbol entity.CurrencyName, interval entity.CandleInterval, i int, price string) *entity.Candle {
+		return &entity.Candle{Symbol: symbol, Interval: interval, OpenTime: start.Add(time.Duration(i) * time.Minute),
+			Open: price, High: price + "1", Low: price + "2", Close: price + "3", Volume: "12.250000000000000000001"}
+	}
+
+	candles := []*entity.Candle{
+		candle(entity.BTC, entity.Interval1m, 2, "102.10"),
+		candle(entity.BTC, entity.Interval1m, 0, "100.10"),
+		candle(entity.BTC, entity.Interval1m, 1, "101.10"),
+		candle(entity.BTC, entity.Interval1m, 3, "103.10"),
+		candle(entity.ETH, entity.Interval1m, 1, "10.10"),
+		candle(entity.BTC, entity.Interval5m, 0, "200.10"),
+	}
+	if err := repo.Upsert(ctx, candles); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	// The candle still open when first stored is replaced once it closes.
+	if err := repo.Upsert(ctx, []*entity.Candle{candle(entity.BTC, entity.Interval1m, 1, "150.10")}); err != nil {
+		t.Fatalf("Upsert (update) failed: %v", err)
+	}
+
+	got, err := repo.GetRange(ctx, entity.BTC, entity.Interval1m, start, start.Add(3*time.Minute))
+	if err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+	want := []*entity.Candle{
+		candle(entity.BTC, entity.Interval1m, 0, "100.10"),
+		candle(entity.BTC, entity.Interval1m, 1, "150.10"),
+		candle(entity.BTC, entity.Interval1m, 2, "102.10"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("GetRange returned %d candles, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].OpenTime.Equal(want[i].OpenTime) {
+			t.Errorf("Candle %d opens at %v, want %v", i, got[i].OpenTime, want[i].OpenTime)
+		}
+		got[i].OpenTime = want[i].OpenTime
+		if *got[i] != *want[i] {
+			t.Errorf("Candle %d = %+v, want %+v", i, *got[i], *want[i])
+		}
+	}
+
+	got, err = repo.GetRange(ctx, entity.ETH, entity.Interval5m, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("GetRange returned %d candles, want none", len(got))
+	}
+}
+
+func testAPIKey(t *testing.T, repos Repositories) {
+	repo := repos.APIKeys
+	ctx := context.Background()
+
+	key := &entity.APIKey{Name: "grafana", Prefix: "tgb_abcd", Scopes: []entity.Scope{entity.ScopeReadPrices, entity.ScopeAdminUsers}}
+	if err := repo.Create(ctx, key, "hash"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if key.ID == 0 || key.CreatedAt.IsZero() {
+		t.Errorf("Created key = %+v, want an id and creation time", key)
+	}
+
+	got, err := repo.GetByHash(ctx, "hash")
+	if err != nil {
+		t.Fatalf("GetByHash failed: %v", err)
+	}
+	if !slices.Equal(got.Scopes, key.Scopes) || got.Name != "grafana" {
+		t.Errorf("Key = %+v, want %+v", got, key)
+	}
+
+	usedAt := time.Now().Truncate(time.Microsecond)
+	if err := repo.TouchLastUsed(ctx, key.ID, usedAt); err != nil {
+		t.Fatalf("TouchLastUsed failed: %v", err)
+	}
+	if err := repo.Revoke(ctx, key.ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if err := repo.Revoke(ctx, key.ID); !errors.Is(err, service.ErrAPIKeyNotFound) {
+		t.Errorf("Second Revoke error = %v, want %v", err, service.ErrAPIKeyNotFound)
+	}
+	if _, err := repo.GetByHash(ctx, "other"); !errors.Is(err, service.ErrAPIKeyNotFound) {
+		t.Errorf("GetByHash error = %v, want %v", err, service.ErrAPIKeyNotFound)
+	}
+
+	keys, err := repo.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(keys) != 1 || !keys[0].Revoked() || keys[0].LastUsedAt == nil || !keys[0].LastUsedAt.Equal(usedAt) {
+		t.Errorf("List = %+v, want the revoked key used at %v", keys, usedAt)
+	}
+}
+
+func testUnitOfWork(t *testing.T, repos Repositories) {
+	uow, users := repos.UnitOfWork, repos.Users
+	ctx := context.Background()
+
+	saveBoth := func(ctx context.Context, chatID int64) error {
+		if err := users.SaveOrUpdate(ctx, &entity.User{ChatID: chatID, Active: true}); err != nil {
+			return err
+		}
+		return repos.Currencies.SaveOrUpdate(ctx, &entity.Price{Symbol: entity.BTC, Price: "1"})
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		if err := uow.WithTx(ctx, func(ctx context.Context) error { return saveBoth(ctx, 1) }); err != nil {
+			t.Fatalf("WithTx failed: %v", err)
+		}
+		if _, err := users.GetByChatID(ctx, 1); err != nil {
+			t.Errorf("Committed user not found: %v", err)
+		}
+	})
+
+	t.Run("rollback on error", func(t *testing.T) {
+		errAbort := errors.New("abort")
+		err := uow.WithTx(ctx, func(ctx context.Context) error {
+			if err := saveBoth(ctx, 2); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("WithTx error = %v, want %v", err, errAbort)
+		}
+		if _, err := users.GetByChatID(ctx, 2); !errors.Is(err, service.ErrUserNotFound) {
+			t.Errorf("Rolled back user lookup error = %v, want %v", err, service.ErrUserNotFound)
+		}
+	})
+
+	t.Run("rollback on panic", func(t *testing.T) {
+		func() {
+			defer func() { _ = recover() }()
+			_ = uow.WithTx(ctx, func(ctx context.Context) error {
+				_ = saveBoth(ctx, 3)
+				panic("boom")
+			})
+		}()
+		if _, err := users.GetByChatID(ctx, 3); !errors.Is(err, service.ErrUserNotFound) {
+			t.Errorf("Rolled back user lookup error = %v, want %v", err, service.ErrUserNotFound)
+		}
+	})
+
+	t.Run("nested calls join the outer transaction", func(t *testing.T) {
+		errAbort := errors.New("abort")
+		_ = uow.WithTx(ctx, func(ctx context.Context) error {
+			if err := uow.WithTx(ctx, func(ctx context.Context) error { return saveBoth(ctx, 4) }); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if _, err := users.GetByChatID(ctx, 4); !errors.Is(err, service.ErrUserNotFound) {
+			t.Errorf("Inner work survived the outer rollback: %v", err)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+type CurrencyRepo struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewCurrencyRepo(db *sql.DB, logger *slog.Logger) service.CurrencyRepository {
+	return &CurrencyRepo{db: db, logger: logger.With(slog.String("component", "sqlite.CurrencyRepo"))}
+}
+
+func (cr *CurrencyRepo) SaveOrUpdate(ctx context.Context, currency *entity.Price) error {
+	cr.logger.DebugContext(ctx, "Saving currency", "symbol", currency.Symbol)
+
+	query := `
+		INSERT INTO currencies (symbol, price, updated, change_24h, high_24h, low_24h, volume_24h, bid, ask)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (symbol)
+		DO UPDATE SET price = excluded.price, updated = excluded.updated,
+			change_24h = excluded.change_24h, high_24h = excluded.high_24h, low_24h = excluded.low_24h,
+			volume_24h = excluded.volume_24h, bid = excluded.bid, ask = excluded.ask
+		`
+
+	t := currency.Ticker
+	_, err := conn(ctx, cr.db).ExecContext(ctx, query, string(currency.Symbol), currency.Price, utc(time.Now()),
+		t.Change24h, t.High24h, t.Low24h, t.Volume24h, t.Bid, t.Ask)
+	if err != nil {
+		cr.logger.ErrorContext(ctx, "failed to save currency", "symbol", currency.Symbol, "err", err)
+	}
+
+	return err
+}
+
+func (cr *CurrencyRepo) GetBySymbol(ctx context.Context, symbol entity.CurrencyName) (*entity.Price, error) {
+	cr.logger.DebugContext(ctx, "Getting currency by symbol", "symbol", symbol)
+
+	query := `SELECT ` + currencyColumns + ` FROM currencies WHERE symbol = ?;`
+
+	currency, err := scanCurrency(conn(ctx, cr.db).QueryRowContext(ctx, query, string(symbol)))
+	if errors.Is(err, sql.ErrNoRows) {
+		cr.logger.DebugContext(ctx, "no currency by symbol", "symbol", symbol)
+		return nil, service.ErrCurrencyNotFound
+	}
+
+	if err != nil {
+		cr.logger.ErrorContext(ctx, "failed to get currency by symbol", "symbol", symbol, "err", err)
+		return nil, err
+	}
+
+	return currency, nil
+}
+
+func (cr *CurrencyRepo) GetAll(ctx context.Context) ([]*entity.Price, error) {
+	cr.logger.DebugContext(ctx, "Getting all currencies")
+
+	query := `SELECT ` + currencyColumns + ` FROM currencies ORDER BY symbol;`
+
+	rows, err := conn(ctx, cr.db).QueryContext(ctx, query)
+	if err != nil {
+		cr.logger.ErrorContext(ctx, "failed to get all currencies", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var currencies []*entity.Price
+	for rows.Next() {
+		currency, err := scanCurrency(rows)
+		if err != nil {
+			cr.logger.ErrorContext(ctx, "failed to get all currencies", "err", err)
+			return nil, err
+		}
+
+		currencies = append(currencies, currency)
+	}
+
+	return currencies, rows.Err()
+}
+
+const currencyColumns = `symbol, price, updated, change_24h, high_24h, low_24h, volume_24h, bid, ask`
+
+func scanCurrency(row interface{ Scan(...any) error }) (*entity.Price, error) {
+	var (
+		currency entity.Price
+		updated  sql.NullTime
+	)
+
+	t := &currency.Ticker
+	if err := row.Scan(&currency.Symbol, &currency.Price, &updated,
+		&t.Change24h, &t.High24h, &t.Low24h, &t.Volume24h, &t.Bid, &t.Ask); err != nil {
+		return nil, err
+	}
+
+	if updated.Valid {
+		currency.Updated = updated.Time.Format(time.RFC3339)
+	}
+
+	return &currency, nil
+}
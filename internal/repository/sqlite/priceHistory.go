@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+type PriceHistoryRepo struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewPriceHistoryRepo(db *sql.DB, logger *slog.Logger) service.PriceHistoryRepository {
+	return &PriceHistoryRepo{db: db, logger: logger.With(slog.String("component", "sqlite.PriceHistoryRepo"))}
+}
+
+func (pr *PriceHistoryRepo) Append(ctx context.Context, price *entity.Price, recordedAt time.Time) error {
+	query := `INSERT INTO price_history (symbol, price, recorded_at) VALUES (?, ?, ?)`
+
+	_, err := conn(ctx, pr.db).ExecContext(ctx, query, string(price.Symbol), price.Price, utc(recordedAt))
+	if err != nil {
+		pr.logger.ErrorContext(ctx, "failed to append price history", "symbol", price.Symbol, "err", err)
+	}
+
+	return err
+}
+
+func (pr *PriceHistoryRepo) Prune(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM price_history WHERE recorded_at < ?`
+
+	result, err := conn(ctx, pr.db).ExecContext(ctx, query, utc(before))
+	if err != nil {
+		pr.logger.ErrorContext(ctx, "failed to prune price history", "before", before, "err", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (pr *PriceHistoryRepo) GetOHLC(ctx context.Context, symbol entity.CurrencyName, from, to time.Time) (*entity.OHLC, error) {
+	pr.logger.DebugContext(ctx, "Getting OHLC", "symbol", symbol, "from", from, "to", to)
+
+	query := `
+		WITH period AS (
+			SELECT id, price, recorded_at FROM price_history
+			WHERE symbol = ?1 AND recorded_at >= ?2 AND recorded_at < ?3
+		)
+		SELECT
+			(SELECT price FROM period ORDER BY recorded_at ASC, id ASC LIMIT 1),
+			MAX(price),
+			MIN(price),
+			(SELECT price FROM period ORDER BY recorded_at DESC, id DESC LIMIT 1),
+			COUNT(*)
+		FROM period
+		`
+
+	var (
+		open, high, low, closePrice sql.NullFloat64
+		ohlc                        = entity.OHLC{Symbol: symbol}
+	)
+
+	err := conn(ctx, pr.db).QueryRowContext(ctx, query, string(symbol), utc(from), utc(to)).
+		Scan(&open, &high, &low, &closePrice, &ohlc.Samples)
+	if err != nil {
+		pr.logger.ErrorContext(ctx, "failed to get OHLC", "symbol", symbol, "err", err)
+		return nil, err
+	}
+
+	if ohlc.Samples == 0 {
+		pr.logger.DebugContext(ctx, "no price history for period", "symbol", symbol)
+		return nil, service.ErrNoPriceHistory
+	}
+
+	ohlc.Open = open.Float64
+	ohlc.High = high.Float64
+	ohlc.Low = low.Float64
+	ohlc.Close = closePrice.Float64
+
+	return &ohlc, nil
+}
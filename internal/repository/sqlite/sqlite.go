@@ -0,0 +1,35 @@
+// Package sqlite stores the bot's data in a single SQLite file for
+// single-node deployments and tests. It uses the pure-Go modernc.org/sqlite
+// driver, so no C toolchain is needed.
+package sqlite
+
+import (
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// DSN returns the data source name for the database file at path. Writers
+// wait for each other instead of failing with SQLITE_BUSY, and timestamps
+// are stored in SQLite's own text format so they can be compared in SQL.
+//
+// SQLite allows a single writer; open the pool with SetMaxOpenConns(1).
+// An in-memory database (":memory:") additionally lives only as long as
+// its one connection.
+func DSN(path string) string {
+	return "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_time_format=sqlite"
+}
+
+// utc normalises t before it is written. Timestamps are compared as text,
+// which only orders them correctly when they share a time zone.
+func utc(t time.Time) time.Time {
+	return t.UTC()
+}
+
+// nullableUTC is utc for optional timestamps.
+func nullableUTC(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
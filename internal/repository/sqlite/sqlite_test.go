@@ -0,0 +1,43 @@
+package sqlite
+
+import (
+	"database/sql"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"tgBotFinal/internal/infrastructure/database"
+	"tgBotFinal/internal/repository/repotest"
+)
+
+// testDB returns a migrated database in a file removed after the test.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", DSN(filepath.Join(t.TempDir(), "bot.db")))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if err := database.NewMigrator(db, database.SQLite, slog.Default()).Up("../../../migrations"); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return db
+}
+
+func TestRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		db := testDB(t)
+		return repotest.Repositories{
+			Users:        NewUserRepo(db, slog.Default()),
+			Currencies:   NewCurrencyRepo(db, slog.Default()),
+			PriceHistory: NewPriceHistoryRepo(db, slog.Default()),
+			APIKeys:      NewAPIKeyRepo(db, slog.Default()),
+			Candles:      NewCandleRepo(db, slog.Default()),
+			UnitOfWork:   NewUnitOfWork(db, slog.Default()),
+		}
+	})
+}
@@ -0,0 +1,345 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"tgBotFinal/internal/domain/service"
+	"tgBotFinal/internal/entity"
+)
+
+type UserRepo struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewUserRepo(db *sql.DB, logger *slog.Logger) service.UserRepository {
+	return &UserRepo{db: db, logger: logger.With(slog.String("component", "sqlite.UserRepo"))}
+}
+
+// SaveOrUpdate creates the user or updates its username and active flag,
+// then fills user with the stored row.
+func (ur *UserRepo) SaveOrUpdate(ctx context.Context, user *entity.User) error {
+	ur.logger.DebugContext(ctx, "save user", "user", user.ChatID)
+
+	query := `
+		INSERT INTO users (chat_id, username, active, created_at, updated_at)
+		VALUES (?1, ?2, ?3, ?4, ?4)
+		ON CONFLICT (chat_id)
+		DO UPDATE SET username = excluded.username, active = excluded.active, updated_at = excluded.updated_at
+		RETURNING ` + userColumns + `;
+`
+	now := utc(time.Now())
+	saved, err := scanUser(conn(ctx, ur.db).QueryRowContext(ctx, query, user.ChatID, user.Username, user.Active, now))
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error save user", "err", err)
+		return err
+	}
+
+	*user = *saved
+	ur.logger.InfoContext(ctx, "save user", "user", user.ChatID)
+	return nil
+}
+
+func (ur *UserRepo) GetByChatID(ctx context.Context, chatID int64) (*entity.User, error) {
+	ur.logger.DebugContext(ctx, "get user by chat id", "chat_id", chatID)
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE chat_id = ?;`
+
+	user, err := scanUser(conn(ctx, ur.db).QueryRowContext(ctx, query, chatID))
+	if errors.Is(err, sql.ErrNoRows) {
+		ur.logger.DebugContext(ctx, "no user found", "chat_id", chatID)
+		return nil, service.ErrUserNotFound
+	}
+
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error getting user", "err", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (ur *UserRepo) GetAll(ctx context.Context) ([]*entity.User, error) {
+	return ur.List(ctx, entity.UserFilter{})
+}
+
+func (ur *UserRepo) GetAllActive(ctx context.Context) ([]*entity.User, error) {
+	active := true
+	return ur.List(ctx, entity.UserFilter{Active: &active})
+}
+
+func (ur *UserRepo) SetDigest(ctx context.Context, chatID int64, period entity.DigestPeriod) error {
+	ur.logger.DebugContext(ctx, "set digest", "chat_id", chatID, "digest", period)
+
+	res, err := ur.update(ctx, chatID, `digest = ?`, string(period))
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(res)
+}
+
+func (ur *UserRepo) SetTimezone(ctx context.Context, chatID int64, timezone string) error {
+	ur.logger.DebugContext(ctx, "set timezone", "chat_id", chatID, "timezone", timezone)
+
+	res, err := ur.update(ctx, chatID, `timezone = ?`, timezone)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(res)
+}
+
+func (ur *UserRepo) GetDigestSubscribers(ctx context.Context) ([]*entity.User, error) {
+	ur.logger.DebugContext(ctx, "get digest subscribers")
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE active = 1 AND digest <> 'off';`
+
+	users, err := ur.query(ctx, query)
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error getting digest subscribers", "err", err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (ur *UserRepo) MarkDigestSent(ctx context.Context, chatID int64, sentAt time.Time) error {
+	ur.logger.DebugContext(ctx, "mark digest sent", "chat_id", chatID)
+
+	res, err := conn(ctx, ur.db).ExecContext(ctx, `UPDATE users SET last_digest_at = ? WHERE chat_id = ?;`, utc(sentAt), chatID)
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error mark digest sent", "chat_id", chatID, "err", err)
+		return err
+	}
+
+	return requireAffected(res)
+}
+
+func (ur *UserRepo) List(ctx context.Context, filter entity.UserFilter) ([]*entity.User, error) {
+	ur.logger.DebugContext(ctx, "list users", "filter", filter)
+
+	where, args := userFilterClause(filter)
+	query := `SELECT ` + userColumns + ` FROM users` + where + ` ORDER BY created_at DESC, chat_id`
+
+	// SQLite only accepts OFFSET after a LIMIT; -1 means no limit.
+	if filter.Limit > 0 || filter.Offset > 0 {
+		limit := -1
+		if filter.Limit > 0 {
+			limit = filter.Limit
+		}
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, filter.Offset)
+	}
+
+	users, err := ur.query(ctx, query, args...)
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error listing users", "err", err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (ur *UserRepo) Count(ctx context.Context, filter entity.UserFilter) (int, error) {
+	ur.logger.DebugContext(ctx, "count users", "filter", filter)
+
+	where, args := userFilterClause(filter)
+
+	var count int
+	if err := conn(ctx, ur.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM users`+where, args...).Scan(&count); err != nil {
+		ur.logger.ErrorContext(ctx, "error counting users", "err", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (ur *UserRepo) SetActive(ctx context.Context, chatID int64, active bool) error {
+	ur.logger.DebugContext(ctx, "set user active", "chat_id", chatID, "active", active)
+
+	res, err := ur.update(ctx, chatID, `active = ?`, active)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(res)
+}
+
+func (ur *UserRepo) SetBannedUntil(ctx context.Context, chatID int64, until *time.Time) error {
+	ur.logger.DebugContext(ctx, "set user banned until", "chat_id", chatID, "until", until)
+
+	res, err := ur.update(ctx, chatID, `banned_until = ?`, nullableUTC(until))
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(res)
+}
+
+func (ur *UserRepo) Delete(ctx context.Context, chatID int64) error {
+	ur.logger.DebugContext(ctx, "delete user", "chat_id", chatID)
+
+	res, err := conn(ctx, ur.db).ExecContext(ctx, `DELETE FROM users WHERE chat_id = ?;`, chatID)
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error delete user", "chat_id", chatID, "err", err)
+		return err
+	}
+
+	if err := requireAffected(res); err != nil {
+		return err
+	}
+
+	ur.logger.InfoContext(ctx, "deleted user", "chat_id", chatID)
+	return nil
+}
+
+// GrowthStats buckets the signups in Go: SQLite has neither date_trunc
+// nor generate_series.
+func (ur *UserRepo) GrowthStats(ctx context.Context, from, to time.Time, bucket entity.GrowthBucket) ([]*entity.UserGrowthPoint, error) {
+	ur.logger.DebugContext(ctx, "user growth stats", "from", from, "to", to, "bucket", bucket)
+
+	if !bucket.Valid() {
+		return nil, fmt.Errorf("unknown growth bucket %q", bucket)
+	}
+	end := bucket.Next(bucket.Truncate(to))
+
+	rows, err := conn(ctx, ur.db).QueryContext(ctx,
+		`SELECT created_at FROM users WHERE created_at < ? ORDER BY created_at;`, utc(end))
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error getting user growth stats", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var signups []time.Time
+	for rows.Next() {
+		var createdAt time.Time
+		if err := rows.Scan(&createdAt); err != nil {
+			ur.logger.ErrorContext(ctx, "error getting user growth stats", "err", err)
+			return nil, err
+		}
+
+		signups = append(signups, createdAt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entity.GrowthPoints(from, to, bucket, signups), nil
+}
+
+const userColumns = `chat_id, username, active, digest, timezone, last_digest_at, banned_until, created_at, updated_at`
+
+func scanUser(row interface{ Scan(...any) error }) (*entity.User, error) {
+	var (
+		user         entity.User
+		username     sql.NullString
+		lastDigestAt sql.NullTime
+		bannedUntil  sql.NullTime
+	)
+
+	if err := row.Scan(&user.ChatID, &username, &user.Active, &user.Digest, &user.Timezone,
+		&lastDigestAt, &bannedUntil, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	user.Username = username.String
+	if lastDigestAt.Valid {
+		user.LastDigestAt = &lastDigestAt.Time
+	}
+	if bannedUntil.Valid {
+		user.BannedUntil = &bannedUntil.Time
+	}
+
+	return &user, nil
+}
+
+func (ur *UserRepo) query(ctx context.Context, query string, args ...any) ([]*entity.User, error) {
+	rows, err := conn(ctx, ur.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*entity.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// update sets one column of the user and bumps updated_at.
+func (ur *UserRepo) update(ctx context.Context, chatID int64, set string, value any) (sql.Result, error) {
+	query := `UPDATE users SET ` + set + `, updated_at = ? WHERE chat_id = ?;`
+
+	res, err := conn(ctx, ur.db).ExecContext(ctx, query, value, utc(time.Now()), chatID)
+	if err != nil {
+		ur.logger.ErrorContext(ctx, "error updating user", "chat_id", chatID, "set", set, "err", err)
+	}
+
+	return res, err
+}
+
+// userFilterClause builds a WHERE clause and its arguments from filter.
+// LIKE is case-insensitive for ASCII in SQLite, matching Postgres' ILIKE.
+func userFilterClause(filter entity.UserFilter) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+
+	if filter.Active != nil {
+		conds = append(conds, "active = ?")
+		args = append(args, *filter.Active)
+	}
+
+	if filter.Digest != "" {
+		conds = append(conds, "digest = ?")
+		args = append(args, string(filter.Digest))
+	}
+
+	if filter.Username != "" {
+		conds = append(conds, `username LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(filter.Username)+"%")
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func requireAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return service.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// likeEscaper makes the wildcards of a LIKE pattern match literally, with
+// backslash as the ESCAPE character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS change_24h TEXT NOT NULL DEFAULT '';
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS high_24h TEXT NOT NULL DEFAULT '';
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS low_24h TEXT NOT NULL DEFAULT '';
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS volume_24h TEXT NOT NULL DEFAULT '';
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS bid TEXT NOT NULL DEFAULT '';
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS ask TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE currencies DROP COLUMN IF EXISTS ask;
+ALTER TABLE currencies DROP COLUMN IF EXISTS bid;
+ALTER TABLE currencies DROP COLUMN IF EXISTS volume_24h;
+ALTER TABLE currencies DROP COLUMN IF EXISTS low_24h;
+ALTER TABLE currencies DROP COLUMN IF EXISTS high_24h;
+ALTER TABLE currencies DROP COLUMN IF EXISTS change_24h;
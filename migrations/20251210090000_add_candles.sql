@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS candles (
+    symbol VARCHAR(10) NOT NULL,
+    "interval" VARCHAR(4) NOT NULL,
+    open_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    open NUMERIC NOT NULL,
+    high NUMERIC NOT NULL,
+    low NUMERIC NOT NULL,
+    close NUMERIC NOT NULL,
+    volume NUMERIC NOT NULL,
+    PRIMARY KEY (symbol, "interval", open_time)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS candles;
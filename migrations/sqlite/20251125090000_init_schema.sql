@@ -0,0 +1,51 @@
+-- +goose Up
+-- SQLite counterpart of the Postgres migrations up to 20251120090000.
+-- Timestamps are written by the repositories as UTC text so they compare
+-- in order; scopes are stored comma separated.
+CREATE TABLE IF NOT EXISTS users (
+    chat_id INTEGER PRIMARY KEY,
+    username TEXT,
+    active BOOLEAN NOT NULL DEFAULT 1,
+    digest TEXT NOT NULL DEFAULT 'off',
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    last_digest_at TIMESTAMP,
+    banned_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS currencies (
+    symbol TEXT PRIMARY KEY,
+    price TEXT NOT NULL,
+    updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS price_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    symbol TEXT NOT NULL,
+    price NUMERIC NOT NULL,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_active ON users(active) WHERE active = 1;
+CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
+CREATE INDEX IF NOT EXISTS idx_users_digest ON users(digest) WHERE digest <> 'off';
+CREATE INDEX IF NOT EXISTS idx_currencies_updated ON currencies(updated);
+CREATE INDEX IF NOT EXISTS idx_price_history_symbol_recorded ON price_history(symbol, recorded_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS price_history;
+DROP TABLE IF EXISTS currencies;
+DROP TABLE IF EXISTS users;
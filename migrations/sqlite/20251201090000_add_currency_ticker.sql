@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE currencies ADD COLUMN change_24h TEXT NOT NULL DEFAULT '';
+ALTER TABLE currencies ADD COLUMN high_24h TEXT NOT NULL DEFAULT '';
+ALTER TABLE currencies ADD COLUMN low_24h TEXT NOT NULL DEFAULT '';
+ALTER TABLE currencies ADD COLUMN volume_24h TEXT NOT NULL DEFAULT '';
+ALTER TABLE currencies ADD COLUMN bid TEXT NOT NULL DEFAULT '';
+ALTER TABLE currencies ADD COLUMN ask TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE currencies DROP COLUMN ask;
+ALTER TABLE currencies DROP COLUMN bid;
+ALTER TABLE currencies DROP COLUMN volume_24h;
+ALTER TABLE currencies DROP COLUMN low_24h;
+ALTER TABLE currencies DROP COLUMN high_24h;
+ALTER TABLE currencies DROP COLUMN change_24h;